@@ -0,0 +1,108 @@
+package chain
+
+import (
+	"dogeuni-indexer/storage"
+	"encoding/json"
+	"errors"
+	"github.com/dogecoinw/doged/btcjson"
+	"github.com/dogecoinw/doged/chaincfg/chainhash"
+	"github.com/dogecoinw/go-dogecoin/log"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// cacheConfirmations is how deep a block or tx has to be buried before it is
+// cached. Above it a reorg may still move a tx to another block.
+const cacheConfirmations = 6
+
+// Cache keeps the verbose blocks and transactions a source returns in LevelDB,
+// keyed by hash, so walk-backs, replays and the parent tx lookups of decoders
+// are served from disk. The confirmations of a cached entry are the ones it
+// had when it was stored.
+type Cache struct {
+	ChainSource
+	level *storage.LevelDB
+	limit uint64
+}
+
+// NewCache caches the results of src in level, in at most limit bytes.
+func NewCache(src ChainSource, level *storage.LevelDB, limit uint64) *Cache {
+	return &Cache{
+		ChainSource: src,
+		level:       level,
+		limit:       limit,
+	}
+}
+
+func (c *Cache) GetBlockVerboseBool(blockHash *chainhash.Hash) (*btcjson.GetBlockVerboseResult, error) {
+	key := "block-" + blockHash.String()
+	block := &btcjson.GetBlockVerboseResult{}
+	if c.get(key, block) {
+		return block, nil
+	}
+
+	block, err := c.ChainSource.GetBlockVerboseBool(blockHash)
+	if err != nil {
+		return nil, err
+	}
+
+	if block.Confirmations >= cacheConfirmations {
+		c.set(key, block)
+	}
+	return block, nil
+}
+
+func (c *Cache) GetRawTransactionVerboseBool(txHash *chainhash.Hash) (*btcjson.TxRawResult, error) {
+	key := "tx-" + txHash.String()
+	tx := &btcjson.TxRawResult{}
+	if c.get(key, tx) {
+		return tx, nil
+	}
+
+	tx, err := c.ChainSource.GetRawTransactionVerboseBool(txHash)
+	if err != nil {
+		return nil, err
+	}
+
+	if tx.Confirmations >= cacheConfirmations {
+		c.set(key, tx)
+	}
+	return tx, nil
+}
+
+// GetRawMempool is passed through uncached.
+func (c *Cache) GetRawMempool() ([]*chainhash.Hash, error) {
+	mp, ok := c.ChainSource.(Mempool)
+	if !ok {
+		return nil, errors.New("source has no mempool")
+	}
+	return mp.GetRawMempool()
+}
+
+func (c *Cache) get(key string, v interface{}) bool {
+	data, err := c.level.GetChainCache(key)
+	if err != nil {
+		if !errors.Is(err, leveldb.ErrNotFound) {
+			log.Warn("chain cache", "get", key, "err", err.Error())
+		}
+		return false
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		log.Warn("chain cache", "decode", key, "err", err.Error())
+		return false
+	}
+	return true
+}
+
+// set stores v, a failing cache only costs the next lookup a node call.
+func (c *Cache) set(key string, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		log.Warn("chain cache", "encode", key, "err", err.Error())
+		return
+	}
+
+	if err := c.level.SetChainCache(key, data, c.limit); err != nil {
+		log.Warn("chain cache", "set", key, "err", err.Error())
+	}
+}
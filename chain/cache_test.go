@@ -0,0 +1,100 @@
+package chain
+
+import (
+	"dogeuni-indexer/storage"
+	"dogeuni-indexer/utils"
+	"encoding/json"
+	"fmt"
+	"github.com/dogecoinw/doged/btcjson"
+	"github.com/dogecoinw/doged/chaincfg/chainhash"
+	"testing"
+)
+
+// countingSource serves made up transactions and counts the calls reaching it.
+type countingSource struct {
+	memorySource
+	confirmations uint64
+	calls         int
+}
+
+func (s *countingSource) GetRawTransactionVerboseBool(txHash *chainhash.Hash) (*btcjson.TxRawResult, error) {
+	s.calls++
+	return &btcjson.TxRawResult{Txid: txHash.String(), Confirmations: s.confirmations}, nil
+}
+
+func newTestCache(t *testing.T, src ChainSource, limit uint64) *Cache {
+	t.Helper()
+
+	level := storage.NewLevelDB(utils.LevelDBConfig{Path: t.TempDir()})
+	t.Cleanup(level.Stop)
+	return NewCache(src, level, limit)
+}
+
+func testTxHash(i int) *chainhash.Hash {
+	hash, _ := chainhash.NewHashFromStr(fmt.Sprintf("%064x", i))
+	return hash
+}
+
+func TestCacheServesBuriedTxs(t *testing.T) {
+	src := &countingSource{confirmations: cacheConfirmations}
+	cache := newTestCache(t, src, 1<<20)
+
+	for i := 0; i < 3; i++ {
+		tx, err := cache.GetRawTransactionVerboseBool(testTxHash(1))
+		if err != nil || tx.Txid != testTxHash(1).String() {
+			t.Fatalf("GetRawTransactionVerboseBool = %+v, %v", tx, err)
+		}
+	}
+
+	if src.calls != 1 {
+		t.Fatalf("source called %d times, want 1", src.calls)
+	}
+}
+
+func TestCacheSkipsShallowTxs(t *testing.T) {
+	src := &countingSource{confirmations: cacheConfirmations - 1}
+	cache := newTestCache(t, src, 1<<20)
+
+	for i := 0; i < 2; i++ {
+		if _, err := cache.GetRawTransactionVerboseBool(testTxHash(1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if src.calls != 2 {
+		t.Fatalf("source called %d times, want 2", src.calls)
+	}
+}
+
+func TestCacheEvictsOldest(t *testing.T) {
+	src := &countingSource{confirmations: cacheConfirmations}
+
+	entry, err := json.Marshal(&btcjson.TxRawResult{Txid: testTxHash(1).String(), Confirmations: cacheConfirmations})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// room for two transactions
+	cache := newTestCache(t, src, uint64(len(entry))*5/2)
+
+	for i := 1; i <= 3; i++ {
+		if _, err := cache.GetRawTransactionVerboseBool(testTxHash(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	calls := src.calls
+	if _, err := cache.GetRawTransactionVerboseBool(testTxHash(3)); err != nil {
+		t.Fatal(err)
+	}
+	if src.calls != calls {
+		t.Fatal("the newest tx should be cached")
+	}
+
+	if _, err := cache.GetRawTransactionVerboseBool(testTxHash(1)); err != nil {
+		t.Fatal(err)
+	}
+	if src.calls != calls+1 {
+		t.Fatal("the oldest tx should be evicted")
+	}
+}
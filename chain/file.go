@@ -0,0 +1,273 @@
+package chain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/dogecoinw/doged/btcjson"
+	"github.com/dogecoinw/doged/chaincfg/chainhash"
+	"github.com/dogecoinw/doged/wire"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileSource replays blocks and transactions recorded as the node's verbose
+// JSON, in the layout a Recorder writes:
+//
+//	<dir>/blocks/<height>-<hash>.json   getblock result
+//	<dir>/txs/<txid[:2]>/<txid>.json    getrawtransaction result
+//
+// The index is built again when the blocks directory changes, so blocks
+// recorded while it is read, of a reorg too, are picked up by the next
+// GetBlockCount.
+type FileSource struct {
+	dir string
+
+	lock    sync.RWMutex
+	modTime time.Time
+	hashes  map[int64]*chainhash.Hash
+	heights map[chainhash.Hash]int64
+	count   int64
+}
+
+func NewFileSource(dir string) (*FileSource, error) {
+	s := &FileSource{dir: dir}
+	s.reset()
+	if err := s.load(); err != nil {
+		return nil, fmt.Errorf("NewFileSource err: %s", err.Error())
+	}
+	return s, nil
+}
+
+func (s *FileSource) reset() {
+	s.modTime = time.Time{}
+	s.hashes = make(map[int64]*chainhash.Hash)
+	s.heights = make(map[chainhash.Hash]int64)
+	s.count = -1
+}
+
+// load indexes the recorded blocks when the blocks directory changed since
+// the last call. Every block is found by hash, by height only those of the
+// chain that ends at the highest one, the last recorded when a reorg left
+// several. Where it did below, the chain is followed through PreviousHash. A
+// missing directory drops the index.
+func (s *FileSource) load() error {
+	blocks := filepath.Join(s.dir, "blocks")
+	info, err := os.Stat(blocks)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			s.lock.Lock()
+			s.reset()
+			s.lock.Unlock()
+		}
+		return err
+	}
+
+	s.lock.RLock()
+	fresh := info.ModTime().Equal(s.modTime)
+	s.lock.RUnlock()
+	if fresh {
+		return nil
+	}
+
+	dir, err := os.Open(blocks)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+
+	names, err := dir.Readdirnames(-1)
+	if err != nil {
+		return err
+	}
+
+	recorded := make(map[int64][]*chainhash.Hash)
+	heights := make(map[chainhash.Hash]int64)
+	count, first := int64(-1), int64(-1)
+	for _, file := range names {
+		name := strings.TrimSuffix(file, ".json")
+		parts := strings.SplitN(name, "-", 2)
+		if len(parts) != 2 || name == file {
+			continue
+		}
+
+		height, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil || height < 0 {
+			continue
+		}
+
+		hash, err := chainhash.NewHashFromStr(parts[1])
+		if err != nil {
+			continue
+		}
+
+		recorded[height] = append(recorded[height], hash)
+		heights[*hash] = height
+		if height > count {
+			count = height
+		}
+		if first < 0 || height < first {
+			first = height
+		}
+	}
+
+	hashes := make(map[int64]*chainhash.Hash, len(recorded))
+	var next *chainhash.Hash
+	for height := count; height >= first && count >= 0; height-- {
+		candidates := recorded[height]
+		switch {
+		case len(candidates) == 0:
+			next = nil
+			continue
+		case len(candidates) == 1:
+			next = candidates[0]
+		case next == nil:
+			next, err = s.lastRecorded(height, candidates)
+			if err != nil {
+				return err
+			}
+		default:
+			block := &btcjson.GetBlockVerboseResult{}
+			err = readJSON(blockPath(s.dir, height+1, next.String()), block)
+			if err != nil {
+				return fmt.Errorf("read block %d err: %s", height+1, err.Error())
+			}
+
+			next, err = chainhash.NewHashFromStr(block.PreviousHash)
+			if err != nil {
+				return fmt.Errorf("block %d %s follows no recorded block", height+1, block.Hash)
+			}
+			if prev, ok := heights[*next]; !ok || prev != height {
+				return fmt.Errorf("block %d %s follows no recorded block", height+1, block.Hash)
+			}
+		}
+		hashes[height] = next
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.modTime = info.ModTime()
+	s.hashes = hashes
+	s.heights = heights
+	s.count = count
+	return nil
+}
+
+// lastRecorded is the block of height written last.
+func (s *FileSource) lastRecorded(height int64, candidates []*chainhash.Hash) (*chainhash.Hash, error) {
+	var last *chainhash.Hash
+	var lastTime time.Time
+	for _, hash := range candidates {
+		info, err := os.Stat(blockPath(s.dir, height, hash.String()))
+		if err != nil {
+			return nil, err
+		}
+
+		if last == nil || info.ModTime().After(lastTime) {
+			last, lastTime = hash, info.ModTime()
+		}
+	}
+	return last, nil
+}
+
+// GetBlockCount returns the highest recorded height.
+func (s *FileSource) GetBlockCount() (int64, error) {
+	if err := s.load(); err != nil {
+		return 0, err
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if s.count < 0 {
+		return 0, errors.New("no blocks recorded")
+	}
+	return s.count, nil
+}
+
+func (s *FileSource) GetBlockHash(blockHeight int64) (*chainhash.Hash, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	hash, ok := s.hashes[blockHeight]
+	if !ok {
+		return nil, &btcjson.RPCError{Code: btcjson.ErrRPCOutOfRange, Message: fmt.Sprintf("block %d not recorded", blockHeight)}
+	}
+	return hash, nil
+}
+
+func (s *FileSource) GetBlockVerboseBool(blockHash *chainhash.Hash) (*btcjson.GetBlockVerboseResult, error) {
+	s.lock.RLock()
+	height, ok := s.heights[*blockHash]
+	s.lock.RUnlock()
+	if !ok {
+		return nil, &btcjson.RPCError{Code: btcjson.ErrRPCBlockNotFound, Message: fmt.Sprintf("block %s not recorded", blockHash)}
+	}
+
+	block := &btcjson.GetBlockVerboseResult{}
+	err := readJSON(blockPath(s.dir, height, blockHash.String()), block)
+	if err != nil {
+		return nil, fmt.Errorf("read block %d err: %s", height, err.Error())
+	}
+	return block, nil
+}
+
+// GetRawTransactionVerboseBool fails like the node does for a transaction that
+// was not recorded, so decoders treat it as missing rather than retry.
+func (s *FileSource) GetRawTransactionVerboseBool(txHash *chainhash.Hash) (*btcjson.TxRawResult, error) {
+	path := txPath(s.dir, txHash.String())
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil, &btcjson.RPCError{Code: btcjson.ErrRPCNoTxInfo, Message: fmt.Sprintf("tx %s not recorded", txHash)}
+	}
+
+	tx := &btcjson.TxRawResult{}
+	err := readJSON(path, tx)
+	if err != nil {
+		return nil, fmt.Errorf("read tx %s err: %s", txHash, err.Error())
+	}
+	return tx, nil
+}
+
+func (s *FileSource) SendRawTransaction(tx *wire.MsgTx, allowHighFees bool) (*chainhash.Hash, error) {
+	return nil, errors.New("recorded blocks cannot broadcast transactions")
+}
+
+func blockPath(dir string, height int64, hash string) string {
+	return filepath.Join(dir, "blocks", fmt.Sprintf("%d-%s.json", height, hash))
+}
+
+func txPath(dir string, txid string) string {
+	return filepath.Join(dir, "txs", txid[:2], txid+".json")
+}
+
+func readJSON(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// writeJSON writes v to path through a temporary file, a reader never sees a
+// half written record.
+func writeJSON(path string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	err = os.WriteFile(tmp, data, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,206 @@
+package chain
+
+import (
+	"errors"
+	"github.com/dogecoinw/doged/btcjson"
+	"github.com/dogecoinw/doged/chaincfg/chainhash"
+	"github.com/dogecoinw/doged/wire"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	testBlockHash = "2a1b5e5bbf8e4f3b7f0f8d3c8c4b9e1e6c1a0f0e9d8c7b6a5948372615043021"
+	testTxid      = "4f3b7f0f8d3c8c4b9e1e6c1a0f0e9d8c7b6a59483726150430212a1b5e5bbf8e"
+)
+
+// memorySource serves one block with one transaction.
+type memorySource struct{}
+
+func (memorySource) GetBlockCount() (int64, error) {
+	return 100, nil
+}
+
+func (memorySource) GetBlockHash(blockHeight int64) (*chainhash.Hash, error) {
+	return chainhash.NewHashFromStr(testBlockHash)
+}
+
+func (memorySource) GetBlockVerboseBool(blockHash *chainhash.Hash) (*btcjson.GetBlockVerboseResult, error) {
+	return &btcjson.GetBlockVerboseResult{Hash: testBlockHash, Height: 100, Time: 1700000000, Tx: []string{testTxid}}, nil
+}
+
+func (memorySource) GetRawTransactionVerboseBool(txHash *chainhash.Hash) (*btcjson.TxRawResult, error) {
+	return &btcjson.TxRawResult{Txid: testTxid, BlockHash: testBlockHash, Vout: []btcjson.Vout{{Value: 1.5, N: 0}}}, nil
+}
+
+func (memorySource) SendRawTransaction(tx *wire.MsgTx, allowHighFees bool) (*chainhash.Hash, error) {
+	return nil, errors.New("not supported")
+}
+
+func TestFileSourceReplaysRecording(t *testing.T) {
+	dir := t.TempDir()
+
+	recorder := NewRecorder(memorySource{}, dir)
+	hash, _ := chainhash.NewHashFromStr(testBlockHash)
+	txhash, _ := chainhash.NewHashFromStr(testTxid)
+	if _, err := recorder.GetBlockVerboseBool(hash); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := recorder.GetRawTransactionVerboseBool(txhash); err != nil {
+		t.Fatal(err)
+	}
+
+	source, err := NewFileSource(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count, err := source.GetBlockCount()
+	if err != nil || count != 100 {
+		t.Fatalf("GetBlockCount = %d, %v", count, err)
+	}
+
+	got, err := source.GetBlockHash(100)
+	if err != nil || got.String() != testBlockHash {
+		t.Fatalf("GetBlockHash = %v, %v", got, err)
+	}
+
+	block, err := source.GetBlockVerboseBool(got)
+	if err != nil || block.Time != 1700000000 || len(block.Tx) != 1 || block.Tx[0] != testTxid {
+		t.Fatalf("GetBlockVerboseBool = %+v, %v", block, err)
+	}
+
+	tx, err := source.GetRawTransactionVerboseBool(txhash)
+	if err != nil || tx.BlockHash != testBlockHash || tx.Vout[0].Value != 1.5 {
+		t.Fatalf("GetRawTransactionVerboseBool = %+v, %v", tx, err)
+	}
+
+	if _, err := source.GetBlockHash(101); err == nil {
+		t.Fatal("block 101 was not recorded")
+	}
+}
+
+func TestFileSourceMissingTx(t *testing.T) {
+	dir := t.TempDir()
+
+	hash, _ := chainhash.NewHashFromStr(testBlockHash)
+	if _, err := NewRecorder(memorySource{}, dir).GetBlockVerboseBool(hash); err != nil {
+		t.Fatal(err)
+	}
+
+	source, err := NewFileSource(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txhash, _ := chainhash.NewHashFromStr(testTxid)
+	_, err = source.GetRawTransactionVerboseBool(txhash)
+
+	var rpcErr *btcjson.RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != btcjson.ErrRPCNoTxInfo {
+		t.Fatalf("want ErrRPCNoTxInfo, got %v", err)
+	}
+}
+
+func TestFileSourcePicksUpNewBlocks(t *testing.T) {
+	dir := t.TempDir()
+
+	hash, _ := chainhash.NewHashFromStr(testBlockHash)
+	if _, err := NewRecorder(memorySource{}, dir).GetBlockVerboseBool(hash); err != nil {
+		t.Fatal(err)
+	}
+
+	source, err := NewFileSource(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	next := chainhash.DoubleHashH([]byte("next"))
+	if err := writeJSON(blockPath(dir, 101, next.String()), &btcjson.GetBlockVerboseResult{Hash: next.String(), Height: 101}); err != nil {
+		t.Fatal(err)
+	}
+
+	count, err := source.GetBlockCount()
+	if err != nil || count != 101 {
+		t.Fatalf("GetBlockCount = %d, %v", count, err)
+	}
+
+	got, err := source.GetBlockHash(100)
+	if err != nil || got.String() != testBlockHash {
+		t.Fatalf("GetBlockHash(100) = %v, %v", got, err)
+	}
+
+	got, err = source.GetBlockHash(101)
+	if err != nil || *got != next {
+		t.Fatalf("GetBlockHash(101) = %v, %v", got, err)
+	}
+
+	if err := os.RemoveAll(filepath.Join(dir, "blocks")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := source.GetBlockCount(); err == nil {
+		t.Fatal("a missing directory should fail GetBlockCount")
+	}
+	if _, err := source.GetBlockHash(100); err == nil {
+		t.Fatal("a missing directory should drop the index")
+	}
+}
+
+// recordBlock writes a block of height on top of prev the way a Recorder does.
+func recordBlock(t *testing.T, dir string, height int64, name string, prev *chainhash.Hash) *chainhash.Hash {
+	t.Helper()
+
+	hash := chainhash.DoubleHashH([]byte(name))
+	block := &btcjson.GetBlockVerboseResult{Hash: hash.String(), Height: height}
+	if prev != nil {
+		block.PreviousHash = prev.String()
+	}
+	if err := writeJSON(blockPath(dir, height, hash.String()), block); err != nil {
+		t.Fatal(err)
+	}
+	return &hash
+}
+
+func TestFileSourceFollowsReorg(t *testing.T) {
+	dir := t.TempDir()
+
+	a1 := recordBlock(t, dir, 1, "a1", nil)
+	a2 := recordBlock(t, dir, 2, "a2", a1)
+	recordBlock(t, dir, 3, "a3", a2)
+
+	source, err := NewFileSource(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the node replaced 2 and 3, the old branch stays recorded
+	b2 := recordBlock(t, dir, 2, "b2", a1)
+	b3 := recordBlock(t, dir, 3, "b3", b2)
+
+	count, err := source.GetBlockCount()
+	if err != nil || count != 3 {
+		t.Fatalf("GetBlockCount = %d, %v", count, err)
+	}
+
+	for height, want := range map[int64]*chainhash.Hash{1: a1, 2: b2, 3: b3} {
+		got, err := source.GetBlockHash(height)
+		if err != nil || *got != *want {
+			t.Fatalf("GetBlockHash(%d) = %v, %v, want %v", height, got, err, want)
+		}
+	}
+
+	// the old branch grows past the new one and wins again
+	a4 := recordBlock(t, dir, 4, "a4", recordBlock(t, dir, 3, "a3", a2))
+	if count, err := source.GetBlockCount(); err != nil || count != 4 {
+		t.Fatalf("GetBlockCount = %d, %v", count, err)
+	}
+
+	for height, want := range map[int64]*chainhash.Hash{2: a2, 4: a4} {
+		got, err := source.GetBlockHash(height)
+		if err != nil || *got != *want {
+			t.Fatalf("GetBlockHash(%d) = %v, %v, want %v", height, got, err, want)
+		}
+	}
+}
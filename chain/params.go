@@ -0,0 +1,35 @@
+package chain
+
+import (
+	"fmt"
+	"github.com/dogecoinw/doged/chaincfg"
+)
+
+// The chaincfg test networks carry bitcoin's address prefixes, dogecoin's
+// differ on testnet.
+var (
+	TestNetParams = dogeParams(chaincfg.TestNet3Params, "testnet", 0x71, 0xc4, 0xf1)
+	RegTestParams = dogeParams(chaincfg.RegressionNetParams, "regtest", 0x6f, 0xc4, 0xef)
+)
+
+func dogeParams(params chaincfg.Params, name string, pubKeyHash, scriptHash, privateKey byte) *chaincfg.Params {
+	params.Name = name
+	params.PubKeyHashAddrID = pubKeyHash
+	params.ScriptHashAddrID = scriptHash
+	params.PrivateKeyID = privateKey
+	return &params
+}
+
+// NetParams returns the network of chain.chain_name. An empty name is mainnet.
+func NetParams(name string) (*chaincfg.Params, error) {
+	switch name {
+	case "", "dogecoin", "mainnet":
+		return &chaincfg.MainNetParams, nil
+	case "testnet", "testnet3":
+		return TestNetParams, nil
+	case "regtest":
+		return RegTestParams, nil
+	}
+
+	return nil, fmt.Errorf("unknown chain_name %s", name)
+}
@@ -0,0 +1,41 @@
+package chain
+
+import (
+	"github.com/dogecoinw/doged/btcutil"
+	"strings"
+	"testing"
+)
+
+func TestNetParamsAddressPrefixes(t *testing.T) {
+	prefixes := map[string]string{
+		"dogecoin": "D",
+		"testnet":  "n",
+		"regtest":  "m",
+	}
+
+	hash := btcutil.Hash160([]byte("dogeuni"))
+	for name, prefix := range prefixes {
+		params, err := NetParams(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		addr, err := btcutil.NewAddressPubKeyHash(hash, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.HasPrefix(addr.String(), prefix) {
+			t.Fatalf("%s address %s should start with %s", name, addr.String(), prefix)
+		}
+
+		decoded, err := btcutil.DecodeAddress(addr.String(), params)
+		if err != nil || !decoded.IsForNet(params) {
+			t.Fatalf("%s address %s does not decode for its network", name, addr.String())
+		}
+	}
+
+	if _, err := NetParams("litecoin"); err == nil {
+		t.Fatal("unknown chain_name should fail")
+	}
+}
@@ -0,0 +1,58 @@
+package chain
+
+import (
+	"errors"
+	"fmt"
+	"github.com/dogecoinw/doged/btcjson"
+	"github.com/dogecoinw/doged/chaincfg/chainhash"
+)
+
+// Recorder passes calls through to a source and writes every block and
+// transaction it returns in the FileSource layout, building an archive while
+// the indexer syncs from a node.
+type Recorder struct {
+	ChainSource
+	dir string
+}
+
+func NewRecorder(src ChainSource, dir string) *Recorder {
+	return &Recorder{
+		ChainSource: src,
+		dir:         dir,
+	}
+}
+
+func (r *Recorder) GetBlockVerboseBool(blockHash *chainhash.Hash) (*btcjson.GetBlockVerboseResult, error) {
+	block, err := r.ChainSource.GetBlockVerboseBool(blockHash)
+	if err != nil {
+		return nil, err
+	}
+
+	err = writeJSON(blockPath(r.dir, block.Height, block.Hash), block)
+	if err != nil {
+		return nil, fmt.Errorf("record block %d err: %s", block.Height, err.Error())
+	}
+	return block, nil
+}
+
+func (r *Recorder) GetRawTransactionVerboseBool(txHash *chainhash.Hash) (*btcjson.TxRawResult, error) {
+	tx, err := r.ChainSource.GetRawTransactionVerboseBool(txHash)
+	if err != nil {
+		return nil, err
+	}
+
+	err = writeJSON(txPath(r.dir, txHash.String()), tx)
+	if err != nil {
+		return nil, fmt.Errorf("record tx %s err: %s", txHash, err.Error())
+	}
+	return tx, nil
+}
+
+// GetRawMempool is passed through unrecorded, an archive only holds blocks.
+func (r *Recorder) GetRawMempool() ([]*chainhash.Hash, error) {
+	mp, ok := r.ChainSource.(Mempool)
+	if !ok {
+		return nil, errors.New("source has no mempool")
+	}
+	return mp.GetRawMempool()
+}
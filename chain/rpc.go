@@ -0,0 +1,27 @@
+package chain
+
+import (
+	"dogeuni-indexer/utils"
+	"github.com/dogecoinw/doged/rpcclient"
+)
+
+var (
+	_ ChainSource = (*rpcclient.Client)(nil)
+	_ Mempool     = (*rpcclient.Client)(nil)
+)
+
+// NewRPCSource connects to the dogecoin node of cfg. Notifications are only
+// delivered over a websocket, passing handlers switches the client from HTTP
+// POST mode to one.
+func NewRPCSource(cfg utils.ChainConfig, handlers *rpcclient.NotificationHandlers) (*rpcclient.Client, error) {
+	connCfg := &rpcclient.ConnConfig{
+		Host:         cfg.Rpc,
+		Endpoint:     "ws",
+		User:         cfg.UserName,
+		Pass:         cfg.PassWord,
+		HTTPPostMode: handlers == nil, // Bitcoin core only supports HTTP POST mode
+		DisableTLS:   true,            // Bitcoin core does not provide TLS by default
+	}
+
+	return rpcclient.New(connCfg, handlers)
+}
@@ -0,0 +1,23 @@
+package chain
+
+import (
+	"github.com/dogecoinw/doged/btcjson"
+	"github.com/dogecoinw/doged/chaincfg/chainhash"
+	"github.com/dogecoinw/doged/wire"
+)
+
+// ChainSource is where the indexer reads blocks and transactions from: a
+// dogecoin node, or blocks recorded to disk.
+type ChainSource interface {
+	GetBlockCount() (int64, error)
+	GetBlockHash(blockHeight int64) (*chainhash.Hash, error)
+	GetBlockVerboseBool(blockHash *chainhash.Hash) (*btcjson.GetBlockVerboseResult, error)
+	GetRawTransactionVerboseBool(txHash *chainhash.Hash) (*btcjson.TxRawResult, error)
+	SendRawTransaction(tx *wire.MsgTx, allowHighFees bool) (*chainhash.Hash, error)
+}
+
+// Mempool is implemented by sources that see unconfirmed transactions. The
+// mempool scan is skipped for the others.
+type Mempool interface {
+	GetRawMempool() ([]*chainhash.Hash, error)
+}
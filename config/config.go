@@ -23,16 +23,14 @@ func LoadConfig(cfg *Config, filep string) {
 
 	// Default config.
 	configFileName := "config.json"
-	if len(os.Args) > 1 {
+	if filep != "" {
+		configFileName = filep
+	} else if len(os.Args) > 1 {
 		configFileName = os.Args[1]
 	}
 
 	configFileName, _ = filepath.Abs(configFileName)
 	log.Printf("Loading config: %v", configFileName)
-
-	if filep != "" {
-		configFileName = filep
-	}
 	configFile, err := os.Open(configFileName)
 	if err != nil {
 		log.Fatal("File error: ", err.Error())
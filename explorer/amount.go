@@ -0,0 +1,100 @@
+package explorer
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"github.com/dogecoinw/doged/btcjson"
+	"github.com/dogecoinw/doged/btcutil"
+	"github.com/dogecoinw/doged/wire"
+	"math"
+	"math/big"
+)
+
+// Amounts the decoders hold outputs to, in koinu.
+const (
+	// InscriptionValue is the 0.001 doge an inscription output carries.
+	InscriptionValue = 100000
+	// maxMintRepeat caps the drc-20 mints one output pays for.
+	maxMintRepeat = 30
+
+	nftDeployFee  = 1000 * btcutil.SatoshiPerBitcoin
+	nftMintFee    = 10 * btcutil.SatoshiPerBitcoin
+	minDepositFee = 50000000
+)
+
+// mintRepeat is the number of drc-20 mints value pays for. value has to be a
+// whole multiple of the inscription value, of at most maxMintRepeat of them.
+// Unless exact, the float rule blocks were indexed with before
+// exact_mint_value applies.
+func mintRepeat(value int64, exact bool) (int64, error) {
+	if !exact {
+		return floatMintRepeat(value)
+	}
+
+	repeat := value / InscriptionValue
+	if repeat > maxMintRepeat {
+		repeat = maxMintRepeat
+	}
+
+	if value != InscriptionValue*repeat {
+		return 0, errors.New("the amount of tokens exceeds the 0.0001")
+	}
+	return repeat, nil
+}
+
+// floatMintRepeat divides the doge value of the node's JSON by 0.001 and
+// compares the float product back.
+func floatMintRepeat(value int64) (int64, error) {
+	doge := float64(value) / btcutil.SatoshiPerBitcoin
+	repeat := int64(doge / 0.001)
+	if repeat > maxMintRepeat {
+		repeat = maxMintRepeat
+	}
+
+	if doge != 0.001*float64(repeat) {
+		return 0, errors.New("the amount of tokens exceeds the 0.0001")
+	}
+	return repeat, nil
+}
+
+// depositFee is the fee of a doge deposit of amt koinu, 0.3% and at least
+// minDepositFee.
+func depositFee(amt *big.Int) *big.Int {
+	fee := big.NewInt(0)
+	fee.Mul(amt, big.NewInt(3))
+	fee.Div(fee, big.NewInt(1000))
+	if fee.Cmp(big.NewInt(minDepositFee)) == -1 {
+		fee = big.NewInt(minDepositFee)
+	}
+	return fee
+}
+
+// koinu is the value of output index of tx. It is read from the serialized
+// transaction, the float of the node's JSON only stands in for transactions
+// without hex.
+func koinu(tx *btcjson.TxRawResult, index int) (int64, error) {
+	if tx.Hex == "" {
+		value := tx.Vout[index].Value
+		if math.IsNaN(value) || value < 0 || value >= math.MaxInt64/btcutil.SatoshiPerBitcoin {
+			return 0, errors.New("invalid amount")
+		}
+		return int64(math.Round(value * btcutil.SatoshiPerBitcoin)), nil
+	}
+
+	raw, err := hex.DecodeString(tx.Hex)
+	if err != nil {
+		return 0, err
+	}
+
+	msg := &wire.MsgTx{}
+	err = msg.Deserialize(bytes.NewReader(raw))
+	if err != nil {
+		return 0, err
+	}
+
+	if index >= len(msg.TxOut) || msg.TxOut[index].Value < 0 {
+		return 0, errors.New("invalid amount")
+	}
+	return msg.TxOut[index].Value, nil
+}
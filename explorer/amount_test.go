@@ -0,0 +1,138 @@
+package explorer
+
+import (
+	"bytes"
+	"dogeuni-indexer/params"
+	"encoding/hex"
+	"github.com/dogecoinw/doged/chaincfg"
+	"github.com/dogecoinw/doged/wire"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestKoinu(t *testing.T) {
+	msg := wire.NewMsgTx(1)
+	msg.AddTxIn(wire.NewTxIn(&wire.OutPoint{}, nil, nil))
+	msg.AddTxOut(wire.NewTxOut(110000000, nil))
+	msg.AddTxOut(wire.NewTxOut(InscriptionValue-1, nil))
+	buf := &bytes.Buffer{}
+	if err := msg.Serialize(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	// the serialized values win over the floats of the JSON
+	tx := resolveTestTx(pay(stakeCreator, 1.0999999), pay(stakeCreator, 0.001))
+	tx.Hex = hex.EncodeToString(buf.Bytes())
+	for i, want := range []int64{110000000, InscriptionValue - 1} {
+		value, err := OutputValue(tx, i)
+		if err != nil || value != want {
+			t.Fatalf("output %d: %d %v, want %d", i, value, err, want)
+		}
+	}
+
+	tx.Hex = ""
+	for value, want := range map[float64]int64{1.1: 110000000, 0.57: 57000000, 4.35: 435000000, 0.00000001: 1, 0: 0} {
+		got, err := OutputValue(resolveTestTx(pay(stakeCreator, value)), 0)
+		if err != nil || got != want {
+			t.Fatalf("%v doge: %d %v, want %d", value, got, err, want)
+		}
+	}
+
+	_, err := OutputValue(resolveTestTx(pay(stakeCreator, -0.001)), 0)
+	if err == nil {
+		t.Fatal("a negative value should not resolve")
+	}
+}
+
+func TestMintRepeat(t *testing.T) {
+	cases := []struct {
+		value  int64
+		repeat int64
+		ok     bool
+	}{
+		{0, 0, true},
+		{InscriptionValue - 1, 0, false},
+		{InscriptionValue, 1, true},
+		{InscriptionValue + 1, 0, false},
+		{InscriptionValue * 2, 2, true},
+		{InscriptionValue*maxMintRepeat - 1, 0, false},
+		{InscriptionValue * maxMintRepeat, maxMintRepeat, true},
+		{InscriptionValue * (maxMintRepeat + 1), 0, false},
+	}
+
+	for _, c := range cases {
+		repeat, err := mintRepeat(c.value, true)
+		if (err == nil) != c.ok || repeat != c.repeat {
+			t.Fatalf("value %d: repeat %d err %v", c.value, repeat, err)
+		}
+	}
+
+	// the float rule of the blocks before exact_mint_value rejects 0.009
+	// doge, from its height on it pays for 9 mints
+	path := filepath.Join(t.TempDir(), "params.json")
+	if err := os.WriteFile(path, []byte(`{"upgrades": [{"height": 1000, "exact_mint_value": true}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	schedule, err := params.Load(path, params.Mainnet, &chaincfg.MainNetParams, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for height, want := range map[int64]int64{999: 0, 1000: 9} {
+		repeat, err := mintRepeat(900000, schedule.At(height).ExactMintValue)
+		if repeat != want || (err == nil) != (want != 0) {
+			t.Fatalf("900000 koinu at %d: repeat %d err %v, want %d", height, repeat, err, want)
+		}
+	}
+
+	for _, value := range []int64{InscriptionValue, InscriptionValue * 2, InscriptionValue * maxMintRepeat} {
+		legacy, err := mintRepeat(value, false)
+		if err != nil || legacy != value/InscriptionValue {
+			t.Fatalf("value %d: float repeat %d err %v", value, legacy, err)
+		}
+	}
+}
+
+func TestDepositFee(t *testing.T) {
+	cases := map[int64]int64{
+		0:             minDepositFee,
+		16666666666:   minDepositFee,
+		16666666667:   minDepositFee,
+		16666667000:   minDepositFee + 1,
+		1000000000000: 3000000000,
+	}
+
+	for amt, want := range cases {
+		fee := depositFee(big.NewInt(amt))
+		if fee.Int64() != want {
+			t.Fatalf("amt %d: fee %s, want %d", amt, fee.String(), want)
+		}
+	}
+}
+
+func TestPaymentMinimum(t *testing.T) {
+	protocol := params.Mainnet
+	rules := []struct {
+		address string
+		min     int64
+	}{
+		{protocol.PumpCreateFeeAddress, protocol.PumpCreateFee},
+		{protocol.PumpTipAddress, protocol.PumpTipFee},
+		{protocol.NftFeeAddress, nftDeployFee},
+		{protocol.NftFeeAddress, nftMintFee},
+		{protocol.WDogeFeeAddress, minDepositFee},
+	}
+
+	for _, r := range rules {
+		for _, paid := range []int64{r.min - 1, r.min, r.min + 1} {
+			tx := resolveTestTx(pay(r.address, float64(paid)/1e8))
+			err := payment(tx, 0, r.address, big.NewInt(r.min))
+			if (err == nil) != (paid >= r.min) {
+				t.Fatalf("%s paid %d of %d: %v", r.address, paid, r.min, err)
+			}
+		}
+	}
+}
@@ -2,22 +2,21 @@ package explorer
 
 import (
 	"dogeuni-indexer/models"
+	"dogeuni-indexer/router"
 	"dogeuni-indexer/utils"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/dogecoinw/doged/btcjson"
 	"github.com/dogecoinw/doged/btcutil"
-	"github.com/dogecoinw/doged/chaincfg"
-	"github.com/dogecoinw/doged/chaincfg/chainhash"
 	"github.com/dogecoinw/go-dogecoin/log"
-	"github.com/google/uuid"
+	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func (e *Explorer) boxDecode(tx *btcjson.TxRawResult, pushedData []byte, number int64) (*models.BoxInfo, error) {
+func (e *Explorer) boxDecode(tx *btcjson.TxRawResult, txIndex int, pushedData []byte, number int64) (*models.BoxInfo, error) {
 
-	err := e.dbc.DB.Where("tx_hash = ?", tx.Hash).First(&models.BoxInfo{}).Error
+	err := e.dbc.DB.Where("tx_hash = ? and tx_index = ?", tx.Hash, txIndex).First(&models.BoxInfo{}).Error
 	if !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, fmt.Errorf("box already exist or err %s", tx.Hash)
 	}
@@ -33,9 +32,10 @@ func (e *Explorer) boxDecode(tx *btcjson.TxRawResult, pushedData []byte, number
 		return nil, fmt.Errorf("ConvertBox err: %s", err.Error())
 	}
 
-	box.OrderId = uuid.New().String()
 	box.FeeTxHash = tx.Vin[0].Txid
 	box.TxHash = tx.Hash
+	box.TxIndex = txIndex
+	box.OrderId = utils.OrderId(box.TxHash, box.TxIndex, utils.SubOpInscription)
 	box.BlockHash = tx.BlockHash
 	box.BlockNumber = number
 	box.OrderStatus = 1
@@ -44,23 +44,27 @@ func (e *Explorer) boxDecode(tx *btcjson.TxRawResult, pushedData []byte, number
 		return nil, fmt.Errorf("vout length is not enough")
 	}
 
-	box.HolderAddress = tx.Vout[0].ScriptPubKey.Addresses[0]
+	box.HolderAddress, err = OutputAddress(tx, 0)
+	if err != nil {
+		return nil, err
+	}
 
-	txHashIn, _ := chainhash.NewHashFromStr(tx.Vin[0].Txid)
-	txRawResult0, err := e.node.GetRawTransactionVerboseBool(txHashIn)
+	_, txRawResult0, err := e.SpentOutput(tx, 0)
 	if err != nil {
-		return nil, CHAIN_NETWORK_ERR
+		return nil, err
 	}
 
-	box.FeeAddress = txRawResult0.Vout[tx.Vin[0].Vout].ScriptPubKey.Addresses[0]
+	box.FeeAddress, err = OutputAddress(txRawResult0, int(tx.Vin[0].Vout))
+	if err != nil {
+		return nil, err
+	}
 
-	txHashIn1, _ := chainhash.NewHashFromStr(txRawResult0.Vin[0].Txid)
-	txRawResult1, err := e.node.GetRawTransactionVerboseBool(txHashIn1)
+	previous, _, err := e.InputAddress(txRawResult0, 0)
 	if err != nil {
-		return nil, CHAIN_NETWORK_ERR
+		return nil, err
 	}
 
-	if box.HolderAddress != txRawResult1.Vout[txRawResult0.Vin[0].Vout].ScriptPubKey.Addresses[0] {
+	if box.HolderAddress != previous {
 		return nil, fmt.Errorf("the address is not the same as the previous transaction")
 	}
 
@@ -74,7 +78,7 @@ func (e *Explorer) boxDecode(tx *btcjson.TxRawResult, pushedData []byte, number
 
 func (e *Explorer) boxDeploy(box *models.BoxInfo) error {
 
-	reservesAddress, _ := btcutil.NewAddressScriptHash([]byte(box.Tick0+"--BOX"), &chaincfg.MainNetParams)
+	reservesAddress, _ := btcutil.NewAddressScriptHash([]byte(box.Tick0+"--BOX"), e.dbc.NetParams())
 
 	tx := e.dbc.DB.Begin()
 	err := e.dbc.BoxDeploy(tx, box, reservesAddress.String())
@@ -83,7 +87,7 @@ func (e *Explorer) boxDeploy(box *models.BoxInfo) error {
 		return err
 	}
 
-	err = tx.Model(&models.BoxInfo{}).Where("tx_hash = ?", box.TxHash).Update("order_status", 0).Error
+	err = tx.Model(&models.BoxInfo{}).Where("tx_hash = ? and tx_index = ?", box.TxHash, box.TxIndex).Update("order_status", 0).Error
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -100,7 +104,7 @@ func (e *Explorer) boxDeploy(box *models.BoxInfo) error {
 
 func (e *Explorer) boxMint(box *models.BoxInfo) error {
 
-	reservesAddress, _ := btcutil.NewAddressScriptHash([]byte(box.Tick0+"--BOX"), &chaincfg.MainNetParams)
+	reservesAddress, _ := btcutil.NewAddressScriptHash([]byte(box.Tick0+"--BOX"), e.dbc.NetParams())
 	tx := e.dbc.DB.Begin()
 	err := e.dbc.BoxMint(tx, box, reservesAddress.String())
 	if err != nil {
@@ -115,7 +119,7 @@ func (e *Explorer) boxMint(box *models.BoxInfo) error {
 		"order_status": 0,
 	}
 
-	err = tx.Model(&models.BoxInfo{}).Where("tx_hash = ?", box.TxHash).Updates(updates).Error
+	err = tx.Model(&models.BoxInfo{}).Where("tx_hash = ? and tx_index = ?", box.TxHash, box.TxIndex).Updates(updates).Error
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -176,23 +180,16 @@ func (e *Explorer) boxFork(tx *gorm.DB, height int64) error {
 			if err != nil {
 				return fmt.Errorf("delete swap_info error: %v", err)
 			}
-		}
 
-		if revert.Op == "refund-drc20" {
-
-			drc20c := &models.Drc20Collect{
-				Tick:          revert.Tick0,
-				Max:           revert.Max,
-				Dec:           8,
-				HolderAddress: revert.HolderAddress,
-				TxHash:        revert.TxHash,
-			}
-
-			err := tx.Create(drc20c).Error
+			// the revert row holds the pair sorted, the box matches it either way
+			err = tx.Model(&models.BoxCollect{}).
+				Where("(tick0 = ? and tick1 = ?) or (tick0 = ? and tick1 = ?)", revert.Tick0, revert.Tick1, revert.Tick1, revert.Tick0).
+				Update("amt0_finish", "0").Error
 			if err != nil {
-				return fmt.Errorf("create drc20_collect error: %v", err)
+				return fmt.Errorf("update box_collect error: %v", err)
 			}
 		}
+
 	}
 
 	err = tx.Model(&models.BoxCollect{}).
@@ -205,3 +202,82 @@ func (e *Explorer) boxFork(tx *gorm.DB, height int64) error {
 	return nil
 
 }
+
+// boxRestoreTicks brings back the drc-20 ticks refunds above height deleted,
+// the drc-20 fork mints the refunded boxes back into them.
+func (e *Explorer) boxRestoreTicks(tx *gorm.DB, height int64) error {
+	var boxReverts []*models.BoxRevert
+	err := tx.Model(&models.BoxRevert{}).
+		Where("block_number > ? and op = ?", height, "refund-drc20").
+		Order("id desc").
+		Find(&boxReverts).Error
+
+	if err != nil {
+		return fmt.Errorf("box revert error: %v", err)
+	}
+
+	for _, revert := range boxReverts {
+		drc20c := &models.Drc20Collect{
+			Tick:          revert.Tick0,
+			Max:           revert.Max,
+			Dec:           8,
+			HolderAddress: revert.HolderAddress,
+			TxHash:        revert.TxHash,
+		}
+
+		err := tx.Create(drc20c).Error
+		if err != nil {
+			return fmt.Errorf("create drc20_collect error: %v", err)
+		}
+	}
+
+	return nil
+}
+
+type boxHandler struct{}
+
+func (boxHandler) Name() string {
+	return "box-v1"
+}
+
+func (boxHandler) Decode(e *Explorer, tx *btcjson.TxRawResult, txIndex int, pushedData []byte, height int64) (interface{}, error) {
+	return e.boxDecode(tx, txIndex, pushedData, height)
+}
+
+func (boxHandler) Verify(e *Explorer, inscription interface{}) error {
+	err := e.verify.VerifyBox(inscription.(*models.BoxInfo))
+	if err != nil {
+		return fmt.Errorf("VerifyBox err: %s", err.Error())
+	}
+	return nil
+}
+
+func (boxHandler) Execute(e *Explorer, inscription interface{}) error {
+	return e.executeBoxV1(inscription.(*models.BoxInfo))
+}
+
+func (boxHandler) Fork(e *Explorer, tx *gorm.DB, height int64) error {
+	return e.boxFork(tx, height)
+}
+
+func (boxHandler) PreFork(e *Explorer, tx *gorm.DB, height int64) error {
+	return e.boxRestoreTicks(tx, height)
+}
+
+func (boxHandler) InfoModel() interface{} {
+	return &models.BoxInfo{}
+}
+
+func (boxHandler) InfoTables() []interface{} {
+	return []interface{}{&models.BoxInfo{}, &models.BoxCollectAddress{}}
+}
+
+func (boxHandler) RevertTables() []interface{} {
+	return []interface{}{&models.BoxRevert{}}
+}
+
+func (boxHandler) Routes(rg *gin.RouterGroup, deps *RouteDeps) {
+	boxRouter := router.NewBoxRouter(deps.DBC, deps.Node)
+	rg.POST("/box/order", deps.Pending.Order("box-v1", boxRouter.Order))
+	rg.POST("/box/collect", boxRouter.Collect)
+}
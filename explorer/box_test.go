@@ -0,0 +1,113 @@
+package explorer
+
+import (
+	"dogeuni-indexer/models"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// boxState captures what the liquidity block of a box changes.
+func boxState(t *testing.T, e *Explorer) map[string]string {
+	t.Helper()
+
+	state := make(map[string]string)
+
+	balances := make([]*models.Drc20CollectAddress, 0)
+	if err := e.dbc.DB.Find(&balances).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range balances {
+		if b.AmtSum.Int().Sign() != 0 {
+			state["drc20/"+b.Tick+"/"+b.HolderAddress] = b.AmtSum.String()
+		}
+	}
+
+	ticks := make([]*models.Drc20Collect, 0)
+	if err := e.dbc.DB.Find(&ticks).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range ticks {
+		state["tick/"+c.Tick] += "+"
+	}
+
+	boxes := make([]*models.BoxCollect, 0)
+	if err := e.dbc.DB.Find(&boxes).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range boxes {
+		state["box/"+b.Tick0] = fmt.Sprintf("amt0_finish=%s liqamt_finish=%s is_del=%d", b.Amt0Finish, b.LiqAmtFinish, b.IsDel)
+	}
+
+	pairs := int64(0)
+	e.dbc.DB.Model(&models.SwapLiquidity{}).Count(&pairs)
+	state["pairs"] = fmt.Sprint(pairs)
+
+	return state
+}
+
+func TestBoxScheduledRecovery(t *testing.T) {
+	e := newIndexTestExplorer(t)
+
+	const (
+		pay      = "BOXPAY"
+		reserveA = "DBoxReservesAxxxxxxxxxxxxxxxxxxxx"
+		reserveB = "DBoxReservesBxxxxxxxxxxxxxxxxxxxx"
+	)
+
+	if err := e.dbc.DB.Create(&models.Drc20Collect{Tick: pay, Max: models.NewNumber(1e12), Lim: models.NewNumber(1e12)}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// BOXA sold out and is listed at its liquidity block, nobody bought BOXB
+	// and it is refunded
+	for _, box := range []struct {
+		tick, reserves string
+	}{{"BOXA", reserveA}, {"BOXB", reserveB}} {
+		info := &models.BoxInfo{Op: "deploy", Tick0: box.tick, Tick1: pay, Max: models.NewNumber(1000000), Amt0: models.NewNumber(400000), LiqAmt: models.NewNumber(3000), LiqBlock: 200, Amt1: models.NewNumber(1), HolderAddress: stakeCreator, TxHash: "deploy-" + box.tick, BlockNumber: 100}
+		if err := e.dbc.BoxDeploy(e.dbc.DB, info, box.reserves); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	buyers := []*models.BoxCollectAddress{
+		{Tick: "BOXA", HolderAddress: stakeHolder, Amt: models.NewNumber(1000), BlockNumber: 150},
+		{Tick: "BOXA", HolderAddress: stakeCreator, Amt: models.NewNumber(2000), BlockNumber: 150},
+	}
+	for _, b := range buyers {
+		if err := e.dbc.DB.Create(b).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := e.dbc.DB.Create(&models.Drc20CollectAddress{Tick: pay, HolderAddress: reserveA, AmtSum: models.NewNumber(3000)}).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = e.dbc.DB.Model(&models.BoxCollect{}).Where("tick0 = ?", "BOXA").Update("liqamt_finish", "3000").Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before := boxState(t, e)
+
+	// the scheduled tasks of 200 commit on their own, a crash before the
+	// block ends leaves them to the recovery rollback, twice in a row
+	for i := 0; i < 2; i++ {
+		if err := e.dbc.ScheduledTasks(200); err != nil {
+			t.Fatal(err)
+		}
+
+		after := boxState(t, e)
+		if after["box/BOXA"] == before["box/BOXA"] || after["box/BOXB"] == before["box/BOXB"] || after["pairs"] != "1" {
+			t.Fatalf("liquidity block did not run: %v", after)
+		}
+
+		if err := e.Rollback(199); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := boxState(t, e); !reflect.DeepEqual(got, before) {
+			t.Fatalf("round %d state after recovery\n%v\nwant\n%v", i, got, before)
+		}
+	}
+}
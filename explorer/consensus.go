@@ -6,19 +6,18 @@ import (
 	"fmt"
 
 	"dogeuni-indexer/models"
+	"dogeuni-indexer/router"
 	"dogeuni-indexer/utils"
 
 	"github.com/dogecoinw/doged/btcjson"
 	"github.com/dogecoinw/doged/btcutil"
-	"github.com/dogecoinw/doged/chaincfg"
-	"github.com/dogecoinw/doged/chaincfg/chainhash"
-	"github.com/google/uuid"
+	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 // consensusDecode parses consensus protocol transactions
-func (e *Explorer) consensusDecode(tx *btcjson.TxRawResult, pushedData []byte, number int64) (*models.ConsensusInfo, error) {
-	err := e.dbc.DB.Where("tx_hash = ?", tx.Txid).First(&models.ConsensusInfo{}).Error
+func (e *Explorer) consensusDecode(tx *btcjson.TxRawResult, txIndex int, pushedData []byte, number int64) (*models.ConsensusInfo, error) {
+	err := e.dbc.DB.Where("tx_hash = ? and tx_index = ?", tx.Txid, txIndex).First(&models.ConsensusInfo{}).Error
 	if !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, fmt.Errorf("consensus already exist or err %s", tx.Txid)
 	}
@@ -38,28 +37,30 @@ func (e *Explorer) consensusDecode(tx *btcjson.TxRawResult, pushedData []byte, n
 		return nil, fmt.Errorf("op error, vout length is not 0")
 	}
 
-	consensus.OrderId = uuid.New().String()
 	consensus.FeeTxHash = tx.Vin[0].Txid
 	consensus.TxHash = tx.Txid
+	consensus.TxIndex = txIndex
+	consensus.OrderId = utils.OrderId(consensus.TxHash, consensus.TxIndex, utils.SubOpInscription)
 	consensus.BlockHash = tx.BlockHash
 	consensus.BlockNumber = number
 	consensus.OrderStatus = 1
 
-	consensus.HolderAddress = tx.Vout[0].ScriptPubKey.Addresses[0]
+	consensus.HolderAddress, err = OutputAddress(tx, 0)
+	if err != nil {
+		return nil, err
+	}
 
-	txhash0, _ := chainhash.NewHashFromStr(tx.Vin[0].Txid)
-	txRawResult0, err := e.node.GetRawTransactionVerboseBool(txhash0)
+	_, txRawResult0, err := e.SpentOutput(tx, 0)
 	if err != nil {
-		return nil, CHAIN_NETWORK_ERR
+		return nil, err
 	}
 
-	txhash1, _ := chainhash.NewHashFromStr(txRawResult0.Vin[0].Txid)
-	txRawResult1, err := e.node.GetRawTransactionVerboseBool(txhash1)
+	previous, _, err := e.InputAddress(txRawResult0, 0)
 	if err != nil {
-		return nil, CHAIN_NETWORK_ERR
+		return nil, err
 	}
 
-	if consensus.HolderAddress != txRawResult1.Vout[txRawResult0.Vin[0].Vout].ScriptPubKey.Addresses[0] {
+	if consensus.HolderAddress != previous {
 		return nil, fmt.Errorf("the address is not the same as the previous transaction")
 	}
 
@@ -67,7 +68,7 @@ func (e *Explorer) consensusDecode(tx *btcjson.TxRawResult, pushedData []byte, n
 	// - stake: use current txhash as unique stake_id (for precise unstake reference)
 	// - unstake: inscription must carry stake_id
 	if consensus.Op == "stake" {
-		consensus.StakeId = consensus.TxHash
+		consensus.StakeId = utils.InscriptionId(consensus.TxHash, consensus.TxIndex)
 	}
 	if consensus.Op == "unstake" && consensus.StakeId == "" {
 		return nil, fmt.Errorf("unstake requires stake_id")
@@ -84,7 +85,7 @@ func (e *Explorer) consensusDecode(tx *btcjson.TxRawResult, pushedData []byte, n
 // consensusStake handles stake operations
 func (e *Explorer) consensusStake(consensus *models.ConsensusInfo) error {
 	// Build special address based on transaction hash
-	reservesAddress, _ := btcutil.NewAddressScriptHash([]byte(consensus.TxHash+"--CONSENSUS"), &chaincfg.MainNetParams)
+	reservesAddress, _ := btcutil.NewAddressScriptHash([]byte(utils.InscriptionId(consensus.TxHash, consensus.TxIndex)+"--CONSENSUS"), e.dbc.NetParams())
 
 	tx := e.dbc.DB.Begin()
 	err := e.dbc.ConsensusStake(tx, consensus, reservesAddress.String())
@@ -94,7 +95,7 @@ func (e *Explorer) consensusStake(consensus *models.ConsensusInfo) error {
 	}
 
 	// Update status
-	err = tx.Model(&models.ConsensusInfo{}).Where("tx_hash = ?", consensus.TxHash).Update("order_status", 0).Error
+	err = tx.Model(&models.ConsensusInfo{}).Where("tx_hash = ? and tx_index = ?", consensus.TxHash, consensus.TxIndex).Update("order_status", 0).Error
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -128,7 +129,7 @@ func (e *Explorer) consensusUnstake(consensus *models.ConsensusInfo) error {
 	}
 
 	// Update status and amount (use amount from original stake record)
-	err = tx.Model(&models.ConsensusInfo{}).Where("tx_hash = ?", consensus.TxHash).Updates(map[string]interface{}{
+	err = tx.Model(&models.ConsensusInfo{}).Where("tx_hash = ? and tx_index = ?", consensus.TxHash, consensus.TxIndex).Updates(map[string]interface{}{
 		"order_status": 0,
 		"amt":          record.Amt,
 	}).Error
@@ -174,3 +175,48 @@ func (e *Explorer) consensusFork(tx *gorm.DB, height int64) error {
 
 	return nil
 }
+
+type consensusHandler struct{}
+
+func (consensusHandler) Name() string {
+	return "consensus"
+}
+
+func (consensusHandler) Decode(e *Explorer, tx *btcjson.TxRawResult, txIndex int, pushedData []byte, height int64) (interface{}, error) {
+	return e.consensusDecode(tx, txIndex, pushedData, height)
+}
+
+func (consensusHandler) Verify(e *Explorer, inscription interface{}) error {
+	err := e.verify.VerifyConsensus(inscription.(*models.ConsensusInfo))
+	if err != nil {
+		return fmt.Errorf("VerifyConsensus err: %s", err.Error())
+	}
+	return nil
+}
+
+func (consensusHandler) Execute(e *Explorer, inscription interface{}) error {
+	return e.executeConsensus(inscription.(*models.ConsensusInfo))
+}
+
+func (consensusHandler) Fork(e *Explorer, tx *gorm.DB, height int64) error {
+	return e.consensusFork(tx, height)
+}
+
+func (consensusHandler) InfoModel() interface{} {
+	return &models.ConsensusInfo{}
+}
+
+func (consensusHandler) InfoTables() []interface{} {
+	return nil
+}
+
+func (consensusHandler) RevertTables() []interface{} {
+	return []interface{}{&models.ConsensusRevert{}}
+}
+
+func (consensusHandler) Routes(rg *gin.RouterGroup, deps *RouteDeps) {
+	consensusRouter := router.NewConsensusRouter(deps.DBC, deps.Node)
+	rg.POST("/consensus/order", deps.Pending.Order("consensus", consensusRouter.Order))
+	rg.POST("/consensus/records", consensusRouter.Records)
+	rg.POST("/consensus/score", consensusRouter.Score)
+}
@@ -2,20 +2,20 @@ package explorer
 
 import (
 	"dogeuni-indexer/models"
+	"dogeuni-indexer/router"
 	"dogeuni-indexer/utils"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/dogecoinw/doged/btcjson"
-	"github.com/dogecoinw/doged/chaincfg/chainhash"
 	"github.com/dogecoinw/go-dogecoin/log"
-	"github.com/google/uuid"
+	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func (e *Explorer) crossDecode(tx *btcjson.TxRawResult, pushedData []byte, number int64) (*models.CrossInfo, error) {
+func (e *Explorer) crossDecode(tx *btcjson.TxRawResult, txIndex int, pushedData []byte, number int64) (*models.CrossInfo, error) {
 
-	err := e.dbc.DB.Where("tx_hash = ?", tx.Txid).First(&models.CrossInfo{}).Error
+	err := e.dbc.DB.Where("tx_hash = ? and tx_index = ?", tx.Txid, txIndex).First(&models.CrossInfo{}).Error
 	if !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, fmt.Errorf("cross already exist or err %s", tx.Txid)
 	}
@@ -31,38 +31,34 @@ func (e *Explorer) crossDecode(tx *btcjson.TxRawResult, pushedData []byte, numbe
 		return nil, fmt.Errorf("ConvertCross err: %s", err.Error())
 	}
 
-	cross.OrderId = uuid.New().String()
 	cross.FeeTxHash = tx.Vin[0].Txid
 	cross.TxHash = tx.Hash
+	cross.TxIndex = txIndex
+	cross.OrderId = utils.OrderId(cross.TxHash, cross.TxIndex, utils.SubOpInscription)
 	cross.BlockHash = tx.BlockHash
 	cross.BlockNumber = number
 	cross.OrderStatus = 1
-	cross.HolderAddress = tx.Vout[0].ScriptPubKey.Addresses[0]
+	cross.HolderAddress, err = OutputAddress(tx, 0)
+	if err != nil {
+		return nil, err
+	}
 
-	txhash0, _ := chainhash.NewHashFromStr(tx.Vin[0].Txid)
-	txRawResult0, err := e.node.GetRawTransactionVerboseBool(txhash0)
+	_, txRawResult0, err := e.SpentOutput(tx, 0)
 	if err != nil {
-		return nil, CHAIN_NETWORK_ERR
+		return nil, err
 	}
 
-	txhash1, _ := chainhash.NewHashFromStr(txRawResult0.Vin[0].Txid)
-	txRawResult1, err := e.node.GetRawTransactionVerboseBool(txhash1)
+	previous, _, err := e.InputAddress(txRawResult0, 0)
 	if err != nil {
-		return nil, CHAIN_NETWORK_ERR
+		return nil, err
 	}
 
 	if cross.Op == "mint" {
 
-		txhash1, _ := chainhash.NewHashFromStr(txRawResult0.Vin[0].Txid)
-		txRawResult1, err := e.node.GetRawTransactionVerboseBool(txhash1)
-		if err != nil {
-			return nil, fmt.Errorf("GetRawTransactionVerboseBool err: %s", err.Error())
-		}
-
-		cross.HolderAddress = txRawResult1.Vout[txRawResult0.Vin[0].Vout].ScriptPubKey.Addresses[0]
+		cross.HolderAddress = previous
 
 	} else {
-		if cross.HolderAddress != txRawResult1.Vout[txRawResult0.Vin[0].Vout].ScriptPubKey.Addresses[0] {
+		if cross.HolderAddress != previous {
 			return nil, fmt.Errorf("the address is not the same as the previous transaction")
 		}
 
@@ -85,7 +81,7 @@ func (e *Explorer) crossDeploy(cross *models.CrossInfo) error {
 		return err
 	}
 
-	err = tx.Model(&models.CrossInfo{}).Where("tx_hash = ?", cross.TxHash).Update("order_status", 0).Error
+	err = tx.Model(&models.CrossInfo{}).Where("tx_hash = ? and tx_index = ?", cross.TxHash, cross.TxIndex).Update("order_status", 0).Error
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -108,7 +104,7 @@ func (e *Explorer) crossMint(cross *models.CrossInfo) error {
 		return err
 	}
 
-	err = tx.Model(&models.CrossInfo{}).Where("tx_hash = ?", cross.TxHash).Update("order_status", 0).Error
+	err = tx.Model(&models.CrossInfo{}).Where("tx_hash = ? and tx_index = ?", cross.TxHash, cross.TxIndex).Update("order_status", 0).Error
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -131,7 +127,7 @@ func (e *Explorer) crossBurn(cross *models.CrossInfo) error {
 		return err
 	}
 
-	err = tx.Model(&models.CrossInfo{}).Where("tx_hash = ?", cross.TxHash).Update("order_status", 0).Error
+	err = tx.Model(&models.CrossInfo{}).Where("tx_hash = ? and tx_index = ?", cross.TxHash, cross.TxIndex).Update("order_status", 0).Error
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -174,3 +170,47 @@ func (e *Explorer) crossFork(tx *gorm.DB, height int64) error {
 
 	return nil
 }
+
+type crossHandler struct{}
+
+func (crossHandler) Name() string {
+	return "cross"
+}
+
+func (crossHandler) Decode(e *Explorer, tx *btcjson.TxRawResult, txIndex int, pushedData []byte, height int64) (interface{}, error) {
+	return e.crossDecode(tx, txIndex, pushedData, height)
+}
+
+func (crossHandler) Verify(e *Explorer, inscription interface{}) error {
+	err := e.verify.VerifyCross(inscription.(*models.CrossInfo))
+	if err != nil {
+		return fmt.Errorf("VerifyCross err: %s", err.Error())
+	}
+	return nil
+}
+
+func (crossHandler) Execute(e *Explorer, inscription interface{}) error {
+	return e.executeCross(inscription.(*models.CrossInfo))
+}
+
+func (crossHandler) Fork(e *Explorer, tx *gorm.DB, height int64) error {
+	return e.crossFork(tx, height)
+}
+
+func (crossHandler) InfoModel() interface{} {
+	return &models.CrossInfo{}
+}
+
+func (crossHandler) InfoTables() []interface{} {
+	return []interface{}{&models.CrossInfo{}}
+}
+
+func (crossHandler) RevertTables() []interface{} {
+	return []interface{}{&models.CrossRevert{}}
+}
+
+func (crossHandler) Routes(rg *gin.RouterGroup, deps *RouteDeps) {
+	crossRouter := router.NewCrossRouter(deps.DBC, deps.Node)
+	rg.POST("/cross/order", deps.Pending.Order("cross", crossRouter.Order))
+	rg.POST("/cross/collect", crossRouter.Collect)
+}
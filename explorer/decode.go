@@ -9,8 +9,73 @@ import (
 	"fmt"
 	"github.com/dogecoinw/doged/btcjson"
 	"github.com/dogecoinw/doged/txscript"
+	"github.com/dogecoinw/go-dogecoin/log"
 )
 
+// inscriptionInput is one inscription carried by a transaction.
+type inscriptionInput struct {
+	index      int
+	handler    ProtocolHandler
+	tx         *btcjson.TxRawResult
+	pushedData []byte
+}
+
+// inscriptions returns the inscriptions of txv in input order. Only the first
+// input carries one until multi_inscription activates at height. An
+// inscription alone at the first input is decoded from txv as is. Otherwise
+// each is decoded from a copy of txv holding only its own input and the output
+// at the same position, so no output is read by two inscriptions and the fee
+// outputs some protocols expect after the holder output are missing.
+func (e *Explorer) inscriptions(txv *btcjson.TxRawResult, height int64) []*inscriptionInput {
+	multi := e.dbc.ProtocolParams(height).MultiInscription
+
+	list := make([]*inscriptionInput, 0)
+	for i, in := range txv.Vin {
+		if i > 0 && !multi {
+			break
+		}
+
+		decode, pushedData, err := e.reDecode(in)
+		if err != nil {
+			log.Trace("scanning", "verifyReDecode", err, "txhash", txv.Txid, "tx_index", i)
+			continue
+		}
+
+		h, ok := findProtocol(decode.P)
+		if !ok {
+			log.Error("scanning", "op", "not found", "txhash", txv.Txid, "tx_index", i)
+			continue
+		}
+
+		if _, ok := h.(WholeTxHandler); ok {
+			if i != 0 {
+				log.Error("scanning", "p", h.Name(), "err", "must start at the first input", "txhash", txv.Txid, "tx_index", i)
+				continue
+			}
+
+			list = append(list, &inscriptionInput{index: 0, handler: h, tx: txv, pushedData: pushedData})
+			break
+		}
+
+		list = append(list, &inscriptionInput{index: i, handler: h, tx: txv, pushedData: pushedData})
+	}
+
+	if len(list) == 1 && list[0].index == 0 {
+		return list
+	}
+
+	for _, ins := range list {
+		view := *txv
+		view.Vin = []btcjson.Vin{txv.Vin[ins.index]}
+		view.Vout = nil
+		if ins.index < len(txv.Vout) {
+			view.Vout = []btcjson.Vout{txv.Vout[ins.index]}
+		}
+		ins.tx = &view
+	}
+	return list
+}
+
 func (e *Explorer) reDecode(vin btcjson.Vin) (*models.BaseInscription, []byte, error) {
 
 	in := vin
@@ -0,0 +1,60 @@
+package explorer
+
+import (
+	"dogeuni-indexer/params"
+	"dogeuni-indexer/utils"
+	"github.com/dogecoinw/doged/btcjson"
+	"github.com/dogecoinw/doged/chaincfg"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInscriptionsPerInput(t *testing.T) {
+	e := newIndexTestExplorer(t)
+
+	path := filepath.Join(t.TempDir(), "params.json")
+	err := os.WriteFile(path, []byte(`{"upgrades": [{"height": 200, "multi_inscription": true}]}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schedule, err := params.Load(path, params.Mainnet, &chaincfg.MainNetParams, ProtocolNames())
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.dbc.SetProtocolParams(schedule)
+
+	data := `{"p":"drc-20","op":"mint","tick":"MULTI","amt":"1"}`
+	tx := &btcjson.TxRawResult{
+		Txid: "multi",
+		Hash: "multi",
+		Vin: []btcjson.Vin{
+			{ScriptSig: inscriptionSig(t, data)},
+			{ScriptSig: inscriptionSig(t, data)},
+		},
+		Vout: []btcjson.Vout{pay(stakeHolder, 0.001), pay(stakeCreator, 0.001), pay(stakeCreator, 1)},
+	}
+
+	if list := e.inscriptions(tx, 199); len(list) != 1 || list[0].tx != tx {
+		t.Fatalf("before the activation only the first input is read, got %d", len(list))
+	}
+
+	list := e.inscriptions(tx, 200)
+	if len(list) != 2 {
+		t.Fatalf("got %d inscriptions", len(list))
+	}
+
+	for i, ins := range list {
+		if ins.index != i || len(ins.tx.Vin) != 1 || len(ins.tx.Vout) != 1 {
+			t.Fatalf("inscription %d reads %d inputs and %d outputs", i, len(ins.tx.Vin), len(ins.tx.Vout))
+		}
+		if ins.tx.Vout[0].ScriptPubKey.Addresses[0] != tx.Vout[i].ScriptPubKey.Addresses[0] {
+			t.Fatalf("inscription %d should own output %d", i, i)
+		}
+	}
+
+	if utils.InscriptionId("multi", 0) != "multi" || utils.InscriptionId("multi", 1) == "multi" {
+		t.Fatal("only the ids of later inputs carry the index")
+	}
+}
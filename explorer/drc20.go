@@ -2,22 +2,22 @@ package explorer
 
 import (
 	"dogeuni-indexer/models"
+	"dogeuni-indexer/router"
 	"dogeuni-indexer/utils"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/dogecoinw/doged/btcjson"
-	"github.com/dogecoinw/doged/chaincfg/chainhash"
 	"github.com/dogecoinw/go-dogecoin/log"
-	"github.com/google/uuid"
+	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 	"math/big"
 	"strings"
 )
 
-func (e *Explorer) drc20Decode(tx *btcjson.TxRawResult, pushedData []byte, number int64) (*models.Drc20Info, error) {
+func (e *Explorer) drc20Decode(tx *btcjson.TxRawResult, txIndex int, pushedData []byte, number int64) (*models.Drc20Info, error) {
 
-	err := e.dbc.DB.Where("tx_hash = ?", tx.Hash).First(&models.Drc20Info{}).Error
+	err := e.dbc.DB.Where("tx_hash = ? and tx_index = ?", tx.Hash, txIndex).First(&models.Drc20Info{}).Error
 	if !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, fmt.Errorf("drc20 already exist or err %s", tx.Hash)
 	}
@@ -33,60 +33,83 @@ func (e *Explorer) drc20Decode(tx *btcjson.TxRawResult, pushedData []byte, numbe
 		return nil, fmt.Errorf("ConvetCard err: %s", err.Error())
 	}
 
-	card.OrderId = uuid.New().String()
 	card.FeeTxHash = tx.Vin[0].Txid
 
 	card.TxHash = tx.Hash
+	card.TxIndex = txIndex
+	card.OrderId = utils.OrderId(card.TxHash, card.TxIndex, utils.SubOpInscription)
 	card.BlockHash = tx.BlockHash
 	card.BlockNumber = number
 	card.Repeat = 1
 	card.OrderStatus = 1
 
 	if card.Op == "deploy" {
-		card.HolderAddress = tx.Vout[0].ScriptPubKey.Addresses[0]
-		if tx.Vout[0].Value != 0.001 {
+		card.HolderAddress, err = OutputAddress(tx, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := OutputValue(tx, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		if value != InscriptionValue {
 			return nil, fmt.Errorf("the amount of tokens exceeds the 0.0001")
 		}
 	}
 
 	if card.Op == "mint" {
 
-		card.HolderAddress = tx.Vout[0].ScriptPubKey.Addresses[0]
-		card.Repeat = int64(tx.Vout[0].Value / 0.001)
-		if card.Repeat > 30 {
-			card.Repeat = 30
+		card.HolderAddress, err = OutputAddress(tx, 0)
+		if err != nil {
+			return nil, err
 		}
 
-		if tx.Vout[0].Value != 0.001*float64(card.Repeat) {
-			return nil, fmt.Errorf("the amount of tokens exceeds the 0.0001")
+		value, err := OutputValue(tx, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		card.Repeat, err = mintRepeat(value, e.dbc.ProtocolParams(number).ExactMintValue)
+		if err != nil {
+			return nil, err
 		}
 
 	}
 
-	txhash0, _ := chainhash.NewHashFromStr(tx.Vin[0].Txid)
-	txRawResult0, err := e.node.GetRawTransactionVerboseBool(txhash0)
+	_, txRawResult0, err := e.SpentOutput(tx, 0)
 	if err != nil {
-		return nil, fmt.Errorf("GetRawTransactionVerboseBool err: %s", err.Error())
+		return nil, err
 	}
 
 	if card.Op == "transfer" {
 
-		txhash1, _ := chainhash.NewHashFromStr(txRawResult0.Vin[0].Txid)
-		txRawResult1, err := e.node.GetRawTransactionVerboseBool(txhash1)
+		card.HolderAddress, _, err = e.InputAddress(txRawResult0, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		card.ToAddress, err = OutputAddress(tx, 0)
 		if err != nil {
-			return nil, fmt.Errorf("GetRawTransactionVerboseBool err: %s", err.Error())
+			return nil, err
 		}
 
-		card.HolderAddress = txRawResult1.Vout[txRawResult0.Vin[0].Vout].ScriptPubKey.Addresses[0]
-		card.ToAddress = tx.Vout[0].ScriptPubKey.Addresses[0]
 		if len(tx.Vout) > 2 {
 			for i := 1; i < len(tx.Vout)-1; i++ {
-				card.ToAddress += ("," + tx.Vout[i].ScriptPubKey.Addresses[0])
+				to, err := OutputAddress(tx, i)
+				if err != nil {
+					return nil, err
+				}
+				card.ToAddress += ("," + to)
 			}
 		}
 	}
 
-	card.FeeAddress = txRawResult0.Vout[tx.Vin[0].Vout].ScriptPubKey.Addresses[0]
+	card.FeeAddress, err = OutputAddress(txRawResult0, int(tx.Vin[0].Vout))
+	if err != nil {
+		return nil, err
+	}
 
 	for _, v := range strings.Split(card.ToAddress, ",") {
 		if card.HolderAddress == v {
@@ -121,7 +144,7 @@ func (e *Explorer) drc20Deploy(drc20 *models.Drc20Info) error {
 		return fmt.Errorf("Save err: %s", err.Error())
 	}
 
-	err = tx.Model(&models.Drc20Info{}).Where("tx_hash = ?", drc20.TxHash).Update("order_status", 0).Error
+	err = tx.Model(&models.Drc20Info{}).Where("tx_hash = ? and tx_index = ?", drc20.TxHash, drc20.TxIndex).Update("order_status", 0).Error
 	if err != nil {
 		tx.Rollback()
 		return fmt.Errorf("update err: %s", err.Error())
@@ -145,7 +168,7 @@ func (e *Explorer) drc20Mint(drc20 *models.Drc20Info) error {
 		return err
 	}
 
-	err = tx.Model(&models.Drc20Info{}).Where("tx_hash = ?", drc20.TxHash).Update("order_status", 0).Error
+	err = tx.Model(&models.Drc20Info{}).Where("tx_hash = ? and tx_index = ?", drc20.TxHash, drc20.TxIndex).Update("order_status", 0).Error
 	if err != nil {
 		tx.Rollback()
 		return fmt.Errorf("update err: %s", err.Error())
@@ -168,7 +191,7 @@ func (e *Explorer) drc20Transfer(drc20 *models.Drc20Info) error {
 		return err
 	}
 
-	err = tx.Model(&models.Drc20Info{}).Where("tx_hash = ?", drc20.TxHash).Update("order_status", 0).Error
+	err = tx.Model(&models.Drc20Info{}).Where("tx_hash = ? and tx_index = ?", drc20.TxHash, drc20.TxIndex).Update("order_status", 0).Error
 	if err != nil {
 		tx.Rollback()
 		return fmt.Errorf("update err: %s", err.Error())
@@ -217,3 +240,49 @@ func (e *Explorer) drc20Fork(tx *gorm.DB, height int64) error {
 
 	return nil
 }
+
+type drc20Handler struct{}
+
+func (drc20Handler) Name() string {
+	return "drc-20"
+}
+
+func (drc20Handler) Decode(e *Explorer, tx *btcjson.TxRawResult, txIndex int, pushedData []byte, height int64) (interface{}, error) {
+	return e.drc20Decode(tx, txIndex, pushedData, height)
+}
+
+func (drc20Handler) Verify(e *Explorer, inscription interface{}) error {
+	err := e.verify.VerifyDrc20(inscription.(*models.Drc20Info))
+	if err != nil {
+		return fmt.Errorf("VerifyDrc20 err: %s", err.Error())
+	}
+	return nil
+}
+
+func (drc20Handler) Execute(e *Explorer, inscription interface{}) error {
+	return e.executeDrc20(inscription.(*models.Drc20Info))
+}
+
+func (drc20Handler) Fork(e *Explorer, tx *gorm.DB, height int64) error {
+	return e.drc20Fork(tx, height)
+}
+
+func (drc20Handler) InfoModel() interface{} {
+	return &models.Drc20Info{}
+}
+
+func (drc20Handler) InfoTables() []interface{} {
+	return []interface{}{&models.Drc20Info{}}
+}
+
+func (drc20Handler) RevertTables() []interface{} {
+	return []interface{}{&models.Drc20Revert{}}
+}
+
+func (drc20Handler) Routes(rg *gin.RouterGroup, deps *RouteDeps) {
+	drc20Router := router.NewDrc20Router(deps.DBC, deps.Node, deps.Level, deps.Ipfs)
+	rg.POST("/drc20/order", deps.Pending.Order("drc-20", drc20Router.Order))
+	rg.POST("/drc20/collect", drc20Router.Collect)
+	rg.POST("/drc20/collect-address", drc20Router.CollectAddress)
+	rg.POST("/drc20/history", drc20Router.History)
+}
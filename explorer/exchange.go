@@ -2,23 +2,22 @@ package explorer
 
 import (
 	"dogeuni-indexer/models"
+	"dogeuni-indexer/router"
 	"dogeuni-indexer/utils"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/dogecoinw/doged/btcjson"
 	"github.com/dogecoinw/doged/btcutil"
-	"github.com/dogecoinw/doged/chaincfg"
-	"github.com/dogecoinw/doged/chaincfg/chainhash"
 	"github.com/dogecoinw/go-dogecoin/log"
-	"github.com/google/uuid"
+	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 	"math/big"
 )
 
-func (e *Explorer) exchangeDecode(tx *btcjson.TxRawResult, pushedData []byte, number int64) (*models.ExchangeInfo, error) {
+func (e *Explorer) exchangeDecode(tx *btcjson.TxRawResult, txIndex int, pushedData []byte, number int64) (*models.ExchangeInfo, error) {
 
-	err := e.dbc.DB.Where("tx_hash = ?", tx.Hash).First(&models.ExchangeInfo{}).Error
+	err := e.dbc.DB.Where("tx_hash = ? and tx_index = ?", tx.Hash, txIndex).First(&models.ExchangeInfo{}).Error
 	if !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, fmt.Errorf("exchange already exist or err %s", tx.Hash)
 	}
@@ -34,31 +33,37 @@ func (e *Explorer) exchangeDecode(tx *btcjson.TxRawResult, pushedData []byte, nu
 		return nil, fmt.Errorf("exchange err: %s", err.Error())
 	}
 
-	ex.OrderId = uuid.New().String()
 	ex.FeeTxHash = tx.Vin[0].Txid
 	ex.TxHash = tx.Hash
+	ex.TxIndex = txIndex
+	ex.OrderId = utils.OrderId(ex.TxHash, ex.TxIndex, utils.SubOpInscription)
 	ex.BlockHash = tx.BlockHash
 	ex.BlockNumber = number
-	ex.HolderAddress = tx.Vout[0].ScriptPubKey.Addresses[0]
+	ex.HolderAddress, err = OutputAddress(tx, 0)
+	if err != nil {
+		return nil, err
+	}
+
 	if ex.Op == "create" {
-		ex.ExId = tx.Hash
+		ex.ExId = utils.InscriptionId(tx.Hash, txIndex)
 	}
 
-	txhash0, _ := chainhash.NewHashFromStr(tx.Vin[0].Txid)
-	txRawResult0, err := e.node.GetRawTransactionVerboseBool(txhash0)
+	_, txRawResult0, err := e.SpentOutput(tx, 0)
 	if err != nil {
-		return nil, CHAIN_NETWORK_ERR
+		return nil, err
 	}
 
-	ex.FeeAddress = txRawResult0.Vout[tx.Vin[0].Vout].ScriptPubKey.Addresses[0]
+	ex.FeeAddress, err = OutputAddress(txRawResult0, int(tx.Vin[0].Vout))
+	if err != nil {
+		return nil, err
+	}
 
-	txhash1, _ := chainhash.NewHashFromStr(txRawResult0.Vin[0].Txid)
-	txRawResult1, err := e.node.GetRawTransactionVerboseBool(txhash1)
+	previous, _, err := e.InputAddress(txRawResult0, 0)
 	if err != nil {
-		return nil, CHAIN_NETWORK_ERR
+		return nil, err
 	}
 
-	if ex.HolderAddress != txRawResult1.Vout[txRawResult0.Vin[0].Vout].ScriptPubKey.Addresses[0] {
+	if ex.HolderAddress != previous {
 		return nil, fmt.Errorf("The address is not the same as the previous transaction")
 	}
 
@@ -72,7 +77,7 @@ func (e *Explorer) exchangeDecode(tx *btcjson.TxRawResult, pushedData []byte, nu
 
 func (e *Explorer) exchangeCreate(ex *models.ExchangeInfo) error {
 	log.Info("explorer", "p", "exchange", "op", "create", "tx_hash", ex.TxHash)
-	reservesAddress, _ := btcutil.NewAddressScriptHash([]byte(ex.ExId), &chaincfg.MainNetParams)
+	reservesAddress, _ := btcutil.NewAddressScriptHash([]byte(ex.ExId), e.dbc.NetParams())
 
 	tx := e.dbc.DB.Begin()
 	err := e.dbc.ExchangeCreate(tx, ex, reservesAddress.String())
@@ -81,7 +86,7 @@ func (e *Explorer) exchangeCreate(ex *models.ExchangeInfo) error {
 		return err
 	}
 
-	err = tx.Model(&models.ExchangeInfo{}).Where("tx_hash = ?", ex.TxHash).Update("order_status", 0).Error
+	err = tx.Model(&models.ExchangeInfo{}).Where("tx_hash = ? and tx_index = ?", ex.TxHash, ex.TxIndex).Update("order_status", 0).Error
 	if err != nil {
 		tx.Rollback()
 		return fmt.Errorf("update status err: %s", err.Error())
@@ -106,7 +111,7 @@ func (e *Explorer) exchangeTrade(ex *models.ExchangeInfo) error {
 	}
 
 
-	err = tx.Model(&models.ExchangeInfo{}).Where("tx_hash = ?", ex.TxHash).Updates(map[string]interface{}{"order_status": 0, "tick0": ex.Tick0, "tick1": ex.Tick1, "amt1": ex.Amt1.String()}).Error
+	err = tx.Model(&models.ExchangeInfo{}).Where("tx_hash = ? and tx_index = ?", ex.TxHash, ex.TxIndex).Updates(map[string]interface{}{"order_status": 0, "tick0": ex.Tick0, "tick1": ex.Tick1, "amt1": ex.Amt1.String()}).Error
 	if err != nil {
 		tx.Rollback()
 		return fmt.Errorf("update status err: %s", err.Error())
@@ -129,7 +134,7 @@ func (e *Explorer) exchangeCancel(ex *models.ExchangeInfo) error {
 		return nil
 	}
 
-	err = tx.Model(&models.ExchangeInfo{}).Where("tx_hash = ?", ex.TxHash).Updates(map[string]interface{}{"order_status": 0, "tick0": ex.Tick0, "tick1": ex.Tick1}).Error
+	err = tx.Model(&models.ExchangeInfo{}).Where("tx_hash = ? and tx_index = ?", ex.TxHash, ex.TxIndex).Updates(map[string]interface{}{"order_status": 0, "tick0": ex.Tick0, "tick1": ex.Tick1}).Error
 	if err != nil {
 		tx.Rollback()
 		return fmt.Errorf("update status err: %s", err.Error())
@@ -209,3 +214,50 @@ func (e *Explorer) exchangeFork(tx *gorm.DB, height int64) error {
 
 	return nil
 }
+
+type exchangeHandler struct{}
+
+func (exchangeHandler) Name() string {
+	return "order-v1"
+}
+
+func (exchangeHandler) Decode(e *Explorer, tx *btcjson.TxRawResult, txIndex int, pushedData []byte, height int64) (interface{}, error) {
+	return e.exchangeDecode(tx, txIndex, pushedData, height)
+}
+
+func (exchangeHandler) Verify(e *Explorer, inscription interface{}) error {
+	err := e.verify.VerifyExchange(inscription.(*models.ExchangeInfo))
+	if err != nil {
+		return fmt.Errorf("VerifyExchange err: %s", err.Error())
+	}
+	return nil
+}
+
+func (exchangeHandler) Execute(e *Explorer, inscription interface{}) error {
+	return e.executeOrderV1(inscription.(*models.ExchangeInfo))
+}
+
+func (exchangeHandler) Fork(e *Explorer, tx *gorm.DB, height int64) error {
+	return e.exchangeFork(tx, height)
+}
+
+func (exchangeHandler) InfoModel() interface{} {
+	return &models.ExchangeInfo{}
+}
+
+func (exchangeHandler) InfoTables() []interface{} {
+	return []interface{}{&models.ExchangeInfo{}}
+}
+
+func (exchangeHandler) RevertTables() []interface{} {
+	return []interface{}{&models.ExchangeRevert{}}
+}
+
+func (exchangeHandler) Routes(rg *gin.RouterGroup, deps *RouteDeps) {
+	exchangeRouter := router.NewExchangeRouter(deps.DBC, deps.Node)
+	rg.POST("/exchange/order", deps.Pending.Order("order-v1", exchangeRouter.Order))
+	rg.POST("/exchange/collect", exchangeRouter.Collect)
+	rg.POST("/exchange/summary", exchangeRouter.Summary)
+	rg.POST("/exchange/summary/total", exchangeRouter.SummaryTotal)
+	rg.POST("/exchange/k", exchangeRouter.SummaryK)
+}
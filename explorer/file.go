@@ -3,15 +3,14 @@ package explorer
 import (
 	"bytes"
 	"dogeuni-indexer/models"
+	"dogeuni-indexer/router"
 	"dogeuni-indexer/utils"
 	"errors"
 	"fmt"
 	"github.com/dogecoinw/doged/btcjson"
-	"github.com/dogecoinw/doged/chaincfg/chainhash"
 	"github.com/dogecoinw/go-dogecoin/log"
-	"github.com/google/uuid"
+	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
-	"time"
 )
 
 func (e *Explorer) fileDecode(tx *btcjson.TxRawResult, number int64) (*models.FileInfo, error) {
@@ -31,40 +30,49 @@ func (e *Explorer) fileDecode(tx *btcjson.TxRawResult, number int64) (*models.Fi
 		return nil, fmt.Errorf("ConvertNft err: %s", err.Error())
 	}
 
-	file.OrderId = uuid.New().String()
 	file.FeeTxHash = tx.Vin[0].Txid
 
 	file.TxHash = tx.Hash
+	file.OrderId = utils.OrderId(file.TxHash, 0, utils.SubOpInscription)
 	file.BlockHash = tx.BlockHash
 	file.BlockNumber = number
 	file.OrderStatus = 1
-	file.UpdateDate = models.LocalTime(time.Now().Unix())
-	file.CreateDate = models.LocalTime(time.Now().Unix())
+	file.UpdateDate = models.LocalTime(tx.Blocktime)
+	file.CreateDate = models.LocalTime(tx.Blocktime)
 
 	if file.Op == "deploy" {
 		file.FileId = tx.Hash
-		file.HolderAddress = tx.Vout[0].ScriptPubKey.Addresses[0]
-		if tx.Vout[0].Value != 0.001 {
+		file.HolderAddress, err = OutputAddress(tx, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := OutputValue(tx, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		if value != InscriptionValue {
 			return nil, fmt.Errorf("The amount of tokens exceeds the 0.0001")
 		}
 	}
 
-	txHash0, _ := chainhash.NewHashFromStr(tx.Vin[0].Txid)
-	txRawResult0, err := e.node.GetRawTransactionVerboseBool(txHash0)
+	_, txRawResult0, err := e.SpentOutput(tx, 0)
 	if err != nil {
-		return nil, fmt.Errorf("GetRawTransactionVerboseBool err: %s", err.Error())
+		return nil, err
 	}
 
 	if file.Op == "transfer" {
 
-		txhash1, _ := chainhash.NewHashFromStr(txRawResult0.Vin[0].Txid)
-		txRawResult1, err := e.node.GetRawTransactionVerboseBool(txhash1)
+		file.HolderAddress, _, err = e.InputAddress(txRawResult0, 0)
 		if err != nil {
-			return nil, fmt.Errorf("getRawTransactionVerboseBool err: %s", err.Error())
+			return nil, err
 		}
 
-		file.HolderAddress = txRawResult1.Vout[txRawResult0.Vin[0].Vout].ScriptPubKey.Addresses[0]
-		file.ToAddress = tx.Vout[0].ScriptPubKey.Addresses[0]
+		file.ToAddress, err = OutputAddress(tx, 0)
+		if err != nil {
+			return nil, err
+		}
 
 		if file.HolderAddress == file.ToAddress {
 			return nil, errors.New("the address is the same")
@@ -98,7 +106,7 @@ func (e *Explorer) fileDeploy(model *models.FileInfo) error {
 		return fmt.Errorf("deploy err: %s order_id: %s", err, model.OrderId)
 	}
 
-	err = tx.Model(&models.FileInfo{}).Where("tx_hash = ?", model.TxHash).Update("order_status", 0).Error
+	err = tx.Model(&models.FileInfo{}).Where("tx_hash = ? and tx_index = ?", model.TxHash, model.TxIndex).Update("order_status", 0).Error
 	if err != nil {
 		tx.Rollback()
 		return fmt.Errorf("fileDeploy update status err: %s order_id: %s", err, model.OrderId)
@@ -124,7 +132,7 @@ func (e *Explorer) fileTransfer(model *models.FileInfo) error {
 		return fmt.Errorf("transfer err: %s order_id: %s", err, model.OrderId)
 	}
 
-	err = tx.Model(&models.FileInfo{}).Where("tx_hash = ?", model.TxHash).Update("order_status", 0).Error
+	err = tx.Model(&models.FileInfo{}).Where("tx_hash = ? and tx_index = ?", model.TxHash, model.TxIndex).Update("order_status", 0).Error
 	if err != nil {
 		tx.Rollback()
 		return fmt.Errorf("fileTransfer update status err: %s order_id: %s", err, model.OrderId)
@@ -168,3 +176,56 @@ func (e *Explorer) fileFork(tx *gorm.DB, height int64) error {
 
 	return nil
 }
+
+type fileHandler struct{}
+
+func (fileHandler) Name() string {
+	return "file"
+}
+
+func (fileHandler) Decode(e *Explorer, tx *btcjson.TxRawResult, txIndex int, pushedData []byte, height int64) (interface{}, error) {
+	return e.fileDecode(tx, height)
+}
+
+func (fileHandler) Verify(e *Explorer, inscription interface{}) error {
+	err := e.verify.VerifyFile(inscription.(*models.FileInfo))
+	if err != nil {
+		return fmt.Errorf("VerifyFile err: %s", err.Error())
+	}
+	return nil
+}
+
+func (fileHandler) Execute(e *Explorer, inscription interface{}) error {
+	return e.executeFile(inscription.(*models.FileInfo))
+}
+
+func (fileHandler) Fork(e *Explorer, tx *gorm.DB, height int64) error {
+	return e.fileFork(tx, height)
+}
+
+func (fileHandler) WholeTx() {}
+
+func (fileHandler) InfoModel() interface{} {
+	return &models.FileInfo{}
+}
+
+func (fileHandler) InfoTables() []interface{} {
+	return []interface{}{&models.FileInfo{}}
+}
+
+func (fileHandler) RevertTables() []interface{} {
+	return []interface{}{&models.FileRevert{}}
+}
+
+func (fileHandler) Routes(rg *gin.RouterGroup, deps *RouteDeps) {
+	fileRouter := router.NewFileRouter(deps.DBC, deps.Node, deps.Ipfs)
+	rg.POST("/file/order", deps.Pending.Order("file", fileRouter.Order))
+	rg.POST("/file/collect-address", fileRouter.CollectAddress)
+
+	rg.POST("/file/upload/meta", fileRouter.UploadMeta)
+	rg.POST("/file/upload/inscriptions/meta", fileRouter.UploadInscriptionsMeta)
+
+	rg.POST("/file/collections", fileRouter.Collections)
+	rg.POST("/file/collections/inscriptions", fileRouter.CollectionsInscriptions)
+	rg.POST("/file/collections/attributes", fileRouter.CollectionsAttributes)
+}
@@ -2,23 +2,21 @@ package explorer
 
 import (
 	"dogeuni-indexer/models"
+	"dogeuni-indexer/router"
 	"dogeuni-indexer/utils"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/dogecoinw/doged/btcjson"
 	"github.com/dogecoinw/doged/btcutil"
-	"github.com/dogecoinw/doged/chaincfg"
-	"github.com/dogecoinw/doged/chaincfg/chainhash"
 	"github.com/dogecoinw/go-dogecoin/log"
-	"github.com/google/uuid"
+	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
-	"time"
 )
 
-func (e *Explorer) fileExchangeDecode(tx *btcjson.TxRawResult, pushedData []byte, number int64) (*models.FileExchangeInfo, error) {
+func (e *Explorer) fileExchangeDecode(tx *btcjson.TxRawResult, txIndex int, pushedData []byte, number int64) (*models.FileExchangeInfo, error) {
 
-	err := e.dbc.DB.Where("tx_hash = ?", tx.Hash).First(&models.FileExchangeInfo{}).Error
+	err := e.dbc.DB.Where("tx_hash = ? and tx_index = ?", tx.Hash, txIndex).First(&models.FileExchangeInfo{}).Error
 	if !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, fmt.Errorf("file-exchange already exist or err %s", tx.Hash)
 	}
@@ -34,18 +32,23 @@ func (e *Explorer) fileExchangeDecode(tx *btcjson.TxRawResult, pushedData []byte
 		return nil, fmt.Errorf("exchange err: %s", err.Error())
 	}
 
-	ex.OrderId = uuid.New().String()
 	ex.FeeTxHash = tx.Vin[0].Txid
 	ex.TxHash = tx.Hash
+	ex.TxIndex = txIndex
+	ex.OrderId = utils.OrderId(ex.TxHash, ex.TxIndex, utils.SubOpInscription)
 	ex.BlockHash = tx.BlockHash
 	ex.BlockNumber = number
 	ex.OrderStatus = 1
-	ex.UpdateDate = models.LocalTime(time.Now().Unix())
-	ex.CreateDate = models.LocalTime(time.Now().Unix())
+	ex.UpdateDate = models.LocalTime(tx.Blocktime)
+	ex.CreateDate = models.LocalTime(tx.Blocktime)
+
+	ex.HolderAddress, err = OutputAddress(tx, 0)
+	if err != nil {
+		return nil, err
+	}
 
-	ex.HolderAddress = tx.Vout[0].ScriptPubKey.Addresses[0]
 	if ex.Op == "create" {
-		ex.ExId = tx.Hash
+		ex.ExId = utils.InscriptionId(tx.Hash, txIndex)
 	}
 
 	if ex.Op == "trade" {
@@ -72,21 +75,22 @@ func (e *Explorer) fileExchangeDecode(tx *btcjson.TxRawResult, pushedData []byte
 
 	}
 
-	txhash0, _ := chainhash.NewHashFromStr(tx.Vin[0].Txid)
-	txRawResult0, err := e.node.GetRawTransactionVerboseBool(txhash0)
+	_, txRawResult0, err := e.SpentOutput(tx, 0)
 	if err != nil {
-		return nil, CHAIN_NETWORK_ERR
+		return nil, err
 	}
 
-	ex.FeeAddress = txRawResult0.Vout[tx.Vin[0].Vout].ScriptPubKey.Addresses[0]
+	ex.FeeAddress, err = OutputAddress(txRawResult0, int(tx.Vin[0].Vout))
+	if err != nil {
+		return nil, err
+	}
 
-	txhash1, _ := chainhash.NewHashFromStr(txRawResult0.Vin[0].Txid)
-	txRawResult1, err := e.node.GetRawTransactionVerboseBool(txhash1)
+	previous, _, err := e.InputAddress(txRawResult0, 0)
 	if err != nil {
-		return nil, CHAIN_NETWORK_ERR
+		return nil, err
 	}
 
-	if ex.HolderAddress != txRawResult1.Vout[txRawResult0.Vin[0].Vout].ScriptPubKey.Addresses[0] {
+	if ex.HolderAddress != previous {
 		return nil, fmt.Errorf("the address is not the same as the previous transaction")
 	}
 
@@ -99,7 +103,7 @@ func (e *Explorer) fileExchangeDecode(tx *btcjson.TxRawResult, pushedData []byte
 }
 
 func (e *Explorer) fileExchangeCreate(ex *models.FileExchangeInfo) error {
-	reservesAddress, _ := btcutil.NewAddressScriptHash([]byte(ex.ExId), &chaincfg.MainNetParams)
+	reservesAddress, _ := btcutil.NewAddressScriptHash([]byte(ex.ExId), e.dbc.NetParams())
 	tx := e.dbc.DB.Begin()
 
 	err := e.dbc.FileExchangeCreate(tx, ex, reservesAddress.String())
@@ -108,7 +112,7 @@ func (e *Explorer) fileExchangeCreate(ex *models.FileExchangeInfo) error {
 		return err
 	}
 
-	err = tx.Model(&models.FileExchangeInfo{}).Where("tx_hash = ?", ex.TxHash).Update("order_status", 0).Error
+	err = tx.Model(&models.FileExchangeInfo{}).Where("tx_hash = ? and tx_index = ?", ex.TxHash, ex.TxIndex).Update("order_status", 0).Error
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -132,7 +136,7 @@ func (e *Explorer) fileExchangeTrade(ex *models.FileExchangeInfo) error {
 		return err
 	}
 
-	err = tx.Model(&models.FileExchangeInfo{}).Where("tx_hash = ?", ex.TxHash).Update("order_status", 0).Error
+	err = tx.Model(&models.FileExchangeInfo{}).Where("tx_hash = ? and tx_index = ?", ex.TxHash, ex.TxIndex).Update("order_status", 0).Error
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -154,7 +158,7 @@ func (e *Explorer) fileExchangeCancel(ex *models.FileExchangeInfo) error {
 		return nil
 	}
 
-	err = tx.Model(&models.FileExchangeInfo{}).Where("tx_hash = ?", ex.TxHash).Update("order_status", 0).Error
+	err = tx.Model(&models.FileExchangeInfo{}).Where("tx_hash = ? and tx_index = ?", ex.TxHash, ex.TxIndex).Update("order_status", 0).Error
 	if err != nil {
 		tx.Rollback()
 		return nil
@@ -227,3 +231,51 @@ func (e *Explorer) fileExchangeFork(tx *gorm.DB, height int64) error {
 	return nil
 
 }
+
+type fileExchangeHandler struct{}
+
+func (fileExchangeHandler) Name() string {
+	return "order-v2"
+}
+
+func (fileExchangeHandler) Decode(e *Explorer, tx *btcjson.TxRawResult, txIndex int, pushedData []byte, height int64) (interface{}, error) {
+	return e.fileExchangeDecode(tx, txIndex, pushedData, height)
+}
+
+func (fileExchangeHandler) Verify(e *Explorer, inscription interface{}) error {
+	err := e.verify.VerifyFileExchange(inscription.(*models.FileExchangeInfo))
+	if err != nil {
+		return fmt.Errorf("VerifyFileExchange err: %s", err.Error())
+	}
+	return nil
+}
+
+func (fileExchangeHandler) Execute(e *Explorer, inscription interface{}) error {
+	return e.executeOrderV2(inscription.(*models.FileExchangeInfo))
+}
+
+func (fileExchangeHandler) Fork(e *Explorer, tx *gorm.DB, height int64) error {
+	return e.fileExchangeFork(tx, height)
+}
+
+func (fileExchangeHandler) InfoModel() interface{} {
+	return &models.FileExchangeInfo{}
+}
+
+func (fileExchangeHandler) InfoTables() []interface{} {
+	return []interface{}{&models.FileExchangeInfo{}}
+}
+
+func (fileExchangeHandler) RevertTables() []interface{} {
+	return []interface{}{&models.FileExchangeRevert{}}
+}
+
+func (fileExchangeHandler) Routes(rg *gin.RouterGroup, deps *RouteDeps) {
+	fileExchangeRouter := router.NewFileExchangeRouter(deps.DBC, deps.Node, deps.Ipfs)
+	rg.POST("/file-exchange/order", deps.Pending.Order("order-v2", fileExchangeRouter.Order))
+	rg.POST("/file-exchange/activity", fileExchangeRouter.Activity)
+	rg.POST("/file-exchange/collect", fileExchangeRouter.Collect)
+	rg.POST("/file-exchange/summary/all", fileExchangeRouter.SummaryAll)
+	rg.POST("/file-exchange/summary/nft/all", fileExchangeRouter.SummaryAll)
+	rg.POST("/file-exchange/inscriptions", fileExchangeRouter.Inscriptions)
+}
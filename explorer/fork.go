@@ -1,29 +1,29 @@
 package explorer
 
 import (
+	"dogeuni-indexer/metrics"
 	"dogeuni-indexer/models"
 	"errors"
 	"fmt"
+	"github.com/dogecoinw/doged/btcjson"
 	"github.com/dogecoinw/go-dogecoin/log"
 	"gorm.io/gorm"
+	"sync/atomic"
+	"time"
 )
 
-func (e *Explorer) forkBack() error {
+// defaultMaxReorgDepth bounds the fork walk-back when the config sets no limit.
+const defaultMaxReorgDepth = 100
 
-	height := e.currentHeight
-
-	blockHash, err := e.node.GetBlockHash(e.currentHeight)
-	if err != nil {
-		return err
-	}
+// forkBack compares block, the next block to apply, with the last indexed
+// block and rolls the state back to the common ancestor when they diverge.
+// It reports whether a rollback happened.
+func (e *Explorer) forkBack(block *btcjson.GetBlockVerboseResult) (bool, error) {
 
-	block, err := e.node.GetBlockVerboseBool(blockHash)
-	if err != nil {
-		return err
-	}
+	height := e.currentHeight
 
 	localHash := ""
-	err = e.dbc.DB.Model(&models.Block{}).Where("block_number = ?", height-1).Select("block_hash").First(&localHash).Error
+	err := e.dbc.DB.Model(&models.Block{}).Where("block_number = ?", height-1).Select("block_hash").First(&localHash).Error
 	if err != nil {
 		block0 := &models.Block{
 			BlockNumber: height - 1,
@@ -31,293 +31,328 @@ func (e *Explorer) forkBack() error {
 		}
 		err = e.dbc.DB.Create(block0).Error
 		if err != nil {
-			return err
+			return false, err
 		}
-		return errors.New("localHash is nil")
+		return false, errors.New("localHash is nil")
 	}
 
-	if localHash != block.PreviousHash {
-		log.Warn("forkBack Begin", "height", height)
-		for blockHash.String() != localHash {
-			height--
-			blockHash, err = e.node.GetBlockHash(height)
-			if err != nil {
-				return fmt.Errorf("GetBlockHash error: %v", err)
-			}
+	if localHash == block.PreviousHash {
+		return false, nil
+	}
 
-			err = e.dbc.DB.Model(&models.Block{}).Where("block_number = ?", height).Select("block_hash").First(&localHash).Error
-			if localHash == "" {
-				return errors.New("localHash is nil")
-			}
-		}
+	log.Warn("forkBack Begin", "height", height)
+	atomic.StoreInt32(&e.forking, 1)
+	defer atomic.StoreInt32(&e.forking, 0)
 
-		tx := e.dbc.DB.Begin()
-		err := e.fork(tx, height)
-		if err != nil {
-			log.Error("fork error", "err", err)
-			tx.Rollback()
-			return err
+	maxDepth := e.maxReorgDepth()
+
+	tip := height - 1
+	oldTip := localHash
+	blockHash := block.Hash
+	for blockHash != localHash {
+		height--
+		if tip-height > maxDepth {
+			return false, e.haltReorg(&models.ReorgEvent{
+				Height: height,
+				Depth:  tip - height,
+				OldTip: oldTip,
+				NewTip: block.Hash,
+				Halted: true,
+			})
 		}
 
-		err = tx.Commit().Error
+		hash, err := e.node.GetBlockHash(height)
 		if err != nil {
-			return err
+			return false, fmt.Errorf("GetBlockHash error: %v", err)
 		}
+		blockHash = hash.String()
 
-		e.currentHeight = height
-		log.Warn("forkBack End", "height", height)
+		localHash = ""
+		err = e.dbc.DB.Model(&models.Block{}).Where("block_number = ?", height).Select("block_hash").First(&localHash).Error
+		if localHash == "" {
+			return false, errors.New("localHash is nil")
+		}
 	}
 
-	return nil
-}
-
-func (e *Explorer) fork(tx *gorm.DB, height int64) error {
-
-	err := e.delInfo(tx, height)
+	tx := e.dbc.DB.Begin()
+	affected, err := e.dbc.ReorgAffected(tx, height)
 	if err != nil {
-		return err
+		tx.Rollback()
+		return false, fmt.Errorf("ReorgAffected error: %v", err)
 	}
 
-	err = e.drc20Fork(tx, height)
+	err = e.dbc.ReorgEventCreate(tx, &models.ReorgEvent{
+		Height:     height,
+		Depth:      tip - height,
+		OldTip:     oldTip,
+		NewTip:     block.Hash,
+		Affected:   affected,
+		CreateDate: models.LocalTime(time.Now().Unix()),
+	})
 	if err != nil {
-		return err
+		tx.Rollback()
+		return false, fmt.Errorf("ReorgEventCreate error: %v", err)
 	}
 
-	err = e.meme20Fork(tx, height)
+	err = e.fork(tx, height)
 	if err != nil {
-		return err
+		log.Error("fork error", "err", err)
+		tx.Rollback()
+		return false, err
 	}
 
-	err = e.pumpFork(tx, height)
+	err = tx.Where("block_number > ?", height).Delete(&models.Block{}).Error
 	if err != nil {
-		return err
+		tx.Rollback()
+		return false, fmt.Errorf("Delete block error: %v", err)
 	}
 
-	err = e.swapFork(tx, height)
+	err = tx.Commit().Error
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	err = e.swapV2Fork(tx, height)
-	if err != nil {
-		return err
-	}
+	// the common ancestor stays applied, the scan resumes after it
+	e.currentHeight = height + 1
+	metrics.Forks.Inc()
+	log.Warn("forkBack End", "height", height)
+	return true, nil
+}
 
-	err = e.fileFork(tx, height)
-	if err != nil {
-		return err
+func (e *Explorer) maxReorgDepth() int64 {
+	if e.config.Explorer.MaxReorgDepth <= 0 {
+		return defaultMaxReorgDepth
 	}
+	return e.config.Explorer.MaxReorgDepth
+}
 
-	err = e.exchangeFork(tx, height)
+// haltReorg records a reorg deeper than the configured limit and stops the
+// scanner. Nothing is rolled back, the operator checks the node and the block
+// table, then raises explorer.max_reorg_depth or restores a snapshot.
+func (e *Explorer) haltReorg(event *models.ReorgEvent) error {
+	event.CreateDate = models.LocalTime(time.Now().Unix())
+	err := e.dbc.ReorgEventCreate(e.dbc.DB, event)
 	if err != nil {
-		return err
+		return fmt.Errorf("ReorgEventCreate error: %v", err)
 	}
 
-	err = e.stakeFork(tx, height)
+	return e.halt(event)
+}
+
+func (e *Explorer) halt(event *models.ReorgEvent) error {
+	halted := fmt.Errorf("reorg deeper than %d blocks, tip %s replaced by %s", e.maxReorgDepth(), event.OldTip, event.NewTip)
+	e.halted.Store(&haltReason{err: halted})
+	return halted
+}
+
+// restoreHalt halts a restarted scanner again when the last reorg halted it
+// and the index still ends at the tip it could not roll back. Raising
+// explorer.max_reorg_depth to the depth of that reorg or restoring a snapshot
+// lets the scanner go on.
+func (e *Explorer) restoreHalt() error {
+	event, err := e.dbc.ReorgEventLast()
 	if err != nil {
-		return err
+		return fmt.Errorf("ReorgEventLast error: %v", err)
 	}
 
-	err = e.boxFork(tx, height)
-	if err != nil {
-		return err
+	if event == nil || !event.Halted || event.Depth <= e.maxReorgDepth() {
+		return nil
 	}
 
-	err = e.fileExchangeFork(tx, height)
+	tip := ""
+	err = e.dbc.DB.Model(&models.Block{}).Where("block_number = ?", e.currentHeight-1).Select("block_hash").Scan(&tip).Error
 	if err != nil {
-		return err
+		return fmt.Errorf("tip block error: %v", err)
 	}
 
-	err = e.stakeV2Fork(tx, height)
-	if err != nil {
-		return err
+	if tip == event.OldTip {
+		e.halt(event)
 	}
+	return nil
+}
 
-	err = e.crossFork(tx, height)
+// recoverBlock rolls back the block an error or a crash cut short, through the
+// same revert rows a fork uses, and resumes the scan at it.
+func (e *Explorer) recoverBlock() error {
+	height, ok, err := e.dbc.JournalUnfinished()
 	if err != nil {
-		return err
+		return fmt.Errorf("JournalUnfinished error: %v", err)
 	}
 
-	err = e.inviteFork(tx, height)
-	if err != nil {
-		return err
+	if !ok {
+		return nil
 	}
 
-    // consensus
-    err = e.consensusFork(tx, height)
-    if err != nil {
-        return err
-    }
+	log.Warn("recoverBlock Begin", "height", height)
+	atomic.StoreInt32(&e.forking, 1)
+	defer atomic.StoreInt32(&e.forking, 0)
 
-	err = e.delRevert(tx, height)
+	err = e.Rollback(height - 1)
 	if err != nil {
 		return err
 	}
 
+	log.Warn("recoverBlock End", "height", height)
 	return nil
-
 }
 
-func (e *Explorer) delInfo(tx *gorm.DB, height int64) error {
-
-	log.Info("delInfo", "height", height)
-
-	err := tx.Where("block_number > ?", height).Delete(&models.Drc20Info{}).Error
+// Rollback rolls the whole index back to height, dropping the blocks above it
+// as if they were never scanned. Snapshots use it to cut a copy at a height.
+func (e *Explorer) Rollback(height int64) error {
+	tx := e.dbc.DB.Begin()
+	err := e.fork(tx, height)
 	if err != nil {
-		return fmt.Errorf("DeleteDrc20Info error: %v", err)
+		tx.Rollback()
+		return err
 	}
 
-	err = tx.Where("block_number > ?", height).Delete(&models.SwapInfo{}).Error
+	err = tx.Where("block_number > ?", height).Delete(&models.Block{}).Error
 	if err != nil {
-		return fmt.Errorf("DeleteDrc20Collect error: %v", err)
+		tx.Rollback()
+		return fmt.Errorf("Delete block error: %v", err)
 	}
 
-	err = tx.Where("block_number > ?", height).Delete(&models.WDogeInfo{}).Error
+	err = e.dbc.JournalClear(tx, height+1)
 	if err != nil {
-		return fmt.Errorf("DeleteDrc20Revert error: %v", err)
+		tx.Rollback()
+		return fmt.Errorf("JournalClear error: %v", err)
 	}
 
-	err = tx.Where("block_number > ?", height).Delete(&models.NftInfo{}).Error
+	err = tx.Commit().Error
 	if err != nil {
-		return fmt.Errorf("DeleteNftInfo error: %v", err)
+		return err
 	}
 
-	err = tx.Where("block_number > ?", height).Delete(&models.BoxInfo{}).Error
-	if err != nil {
-		return fmt.Errorf("DeleteBoxInfo error: %v", err)
-	}
+	e.currentHeight = height + 1
+	return nil
+}
 
-	err = tx.Where("block_number > ?", height).Delete(&models.ExchangeInfo{}).Error
-	if err != nil {
-		return fmt.Errorf("DeleteExchangeInfo error: %v", err)
-	}
+func (e *Explorer) fork(tx *gorm.DB, height int64) error {
 
-	err = tx.Where("block_number > ?", height).Delete(&models.StakeInfo{}).Error
+	err := e.delInfo(tx, height)
 	if err != nil {
-		return fmt.Errorf("DeleteStakeInfo error: %v", err)
+		return err
 	}
 
-	err = tx.Where("block_number > ?", height).Delete(&models.StakeRewardInfo{}).Error
+	err = e.forkProtocols(tx, height)
 	if err != nil {
-		return fmt.Errorf("DeleteStakeRewardInfo error: %v", err)
+		return err
 	}
 
-	err = tx.Where("block_number > ?", height).Delete(&models.BoxCollectAddress{}).Error
+	err = e.delRevert(tx, height)
 	if err != nil {
-		return fmt.Errorf("DeleteBoxAddress error: %v", err)
+		return err
 	}
 
-	err = tx.Where("block_number > ?", height).Delete(&models.FileInfo{}).Error
+	err = e.forkCandles(tx, height)
 	if err != nil {
-		return fmt.Errorf("DeleteFileInfo error: %v", err)
+		return err
 	}
 
-	err = tx.Where("block_number > ?", height).Delete(&models.FileExchangeInfo{}).Error
+	err = tx.Where("block_number > ?", height).Delete(&models.DecodeFailure{}).Error
 	if err != nil {
-		return fmt.Errorf("DeleteFileExchangeInfo error: %v", err)
+		return fmt.Errorf("Delete decode failure error: %v", err)
 	}
 
-	err = tx.Where("block_number > ?", height).Delete(&models.CrossInfo{}).Error
+	err = tx.Where("block_number > ?", height).Delete(&models.BlockInscription{}).Error
 	if err != nil {
-		return fmt.Errorf("CrossInfo error: %v", err)
+		return fmt.Errorf("Delete block inscription error: %v", err)
 	}
 
-	// meme20
-	err = tx.Where("block_number > ?", height).Delete(&models.Meme20Info{}).Error
-	if err != nil {
-		return fmt.Errorf("DeleteMeme20Info error: %v", err)
-	}
+	return nil
 
-	// swap-v2
-	err = tx.Where("block_number > ?", height).Delete(&models.SwapV2Info{}).Error
-	if err != nil {
-		return fmt.Errorf("DeleteSwapV2Info error: %v", err)
-	}
+}
 
-	// pump
-	err = tx.Where("block_number > ?", height).Delete(&models.PumpInfo{}).Error
+// forkCandles rebuilds the candles that contain the blocks above height from
+// the trades logged up to height.
+func (e *Explorer) forkCandles(tx *gorm.DB, height int64) error {
+	since := int64(0)
+	err := tx.Model(&models.Block{}).Select("coalesce(min(block_time), 0)").Where("block_number > ? and block_time > 0", height).Scan(&since).Error
 	if err != nil {
-		return fmt.Errorf("DeletePumpInfo error: %v", err)
+		return fmt.Errorf("Candle block time error: %v", err)
 	}
 
-	// invite
-	err = tx.Where("block_number > ?", height).Delete(&models.InviteInfo{}).Error
+	traded := int64(0)
+	err = tx.Model(&models.SummaryTrade{}).Select("coalesce(min(block_time), 0)").Where("block_number > ?", height).Scan(&traded).Error
 	if err != nil {
-		return fmt.Errorf("DeleteInviteInfo error: %v", err)
+		return fmt.Errorf("Candle trade time error: %v", err)
 	}
-	return nil
-}
 
-func (e *Explorer) delRevert(tx *gorm.DB, height int64) error {
-
-	err := tx.Where("block_number > ?", height).Delete(&models.Drc20Revert{}).Error
-	if err != nil {
-		return fmt.Errorf("DeleteDrc20Revert error: %v", err)
+	if since == 0 || traded != 0 && traded < since {
+		since = traded
 	}
 
-	err = tx.Where("block_number > ?", height).Delete(&models.SwapRevert{}).Error
-	if err != nil {
-		return fmt.Errorf("DeleteSwapRevert error: %v", err)
+	if since == 0 {
+		return nil
 	}
 
-	err = tx.Where("block_number > ?", height).Delete(&models.FileRevert{}).Error
+	logged, err := e.dbc.SummaryLogged(tx, height, since)
 	if err != nil {
-		return fmt.Errorf("DeleteFileRevert error: %v", err)
+		return fmt.Errorf("SummaryLogged error: %v", err)
 	}
 
-	err = tx.Where("block_number > ?", height).Delete(&models.ExchangeRevert{}).Error
-	if err != nil {
-		return fmt.Errorf("DeleteExchangeRevert error: %v", err)
+	if !logged {
+		log.Warn("fork", "candles", "trades indexed before the trade log are dropped", "height", height, "fix", "reindex --widen")
 	}
 
-	err = tx.Where("block_number > ?", height).Delete(&models.StakeRevert{}).Error
+	err = e.dbc.SummaryRebuild(tx, height, since)
 	if err != nil {
-		return fmt.Errorf("DeleteStakeRevert error: %v", err)
+		return fmt.Errorf("Rebuild candles error: %v", err)
 	}
+	return nil
+}
 
-	err = tx.Where("block_number > ?", height).Delete(&models.StakeRewardRevert{}).Error
-	if err != nil {
-		return fmt.Errorf("DeleteStakeRewardRevert error: %v", err)
+// forkProtocols rolls the state of every protocol back to height, running
+// the PreFork hooks first.
+func (e *Explorer) forkProtocols(tx *gorm.DB, height int64) error {
+	for _, h := range Protocols() {
+		if pf, ok := h.(PreForker); ok {
+			err := pf.PreFork(e, tx, height)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
-	err = tx.Where("block_number > ?", height).Delete(&models.FileExchangeRevert{}).Error
-	if err != nil {
-		return fmt.Errorf("DeleteFileExchangeRevert error: %v", err)
+	for _, h := range Protocols() {
+		err := h.Fork(e, tx, height)
+		if err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	err = tx.Where("block_number > ?", height).Delete(&models.CrossRevert{}).Error
-	if err != nil {
-		return fmt.Errorf("CrossRevert error: %v", err)
-	}
+func (e *Explorer) delInfo(tx *gorm.DB, height int64) error {
 
-	err = tx.Where("block_number > ?", height).Delete(&models.Meme20Revert{}).Error
-	if err != nil {
-		return fmt.Errorf("DeleteMeme20Revert error: %v", err)
-	}
+	log.Info("delInfo", "height", height)
 
-	err = tx.Where("block_number > ?", height).Delete(&models.SwapV2Revert{}).Error
-	if err != nil {
-		return fmt.Errorf("DeleteSwapV2Revert error: %v", err)
+	for _, h := range Protocols() {
+		for _, table := range h.InfoTables() {
+			err := tx.Where("block_number > ?", height).Delete(table).Error
+			if err != nil {
+				return fmt.Errorf("Delete %s info error: %v", h.Name(), err)
+			}
+		}
 	}
 
-	err = tx.Where("block_number > ?", height).Delete(&models.PumpRevert{}).Error
-	if err != nil {
-		return fmt.Errorf("DeletePumpRevert error: %v", err)
-	}
+	return nil
+}
 
-	err = tx.Where("block_number > ?", height).Delete(&models.InviteRevert{}).Error
-	if err != nil {
-		return fmt.Errorf("DeleteInviteRevert error: %v", err)
-	}
+func (e *Explorer) delRevert(tx *gorm.DB, height int64) error {
 
-    err = tx.Where("block_number > ?", height).Delete(&models.ConsensusRevert{}).Error
-    if err != nil {
-        return fmt.Errorf("DeleteConsensusRevert error: %v", err)
-    }
+	for _, h := range Protocols() {
+		for _, table := range h.RevertTables() {
+			err := tx.Where("block_number > ?", height).Delete(table).Error
+			if err != nil {
+				return fmt.Errorf("Delete %s revert error: %v", h.Name(), err)
+			}
+		}
+	}
 
-	err = tx.Where("block_number > ?", height).Delete(&models.PumpInviteRewardRevert{}).Error
+	err := tx.Where("block_number > ?", height).Delete(&models.StateChange{}).Error
 	if err != nil {
-		return fmt.Errorf("DeletePumpInviteRewardRevert error: %v", err)
+		return fmt.Errorf("Delete state change error: %v", err)
 	}
 
 	return nil
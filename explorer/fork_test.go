@@ -0,0 +1,296 @@
+package explorer
+
+import (
+	"dogeuni-indexer/chain"
+	"dogeuni-indexer/config"
+	"dogeuni-indexer/models"
+	"dogeuni-indexer/storage"
+	"dogeuni-indexer/utils"
+	"github.com/dogecoinw/doged/btcjson"
+	"github.com/dogecoinw/doged/chaincfg/chainhash"
+	"math/big"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newIndexTestExplorer is an explorer on an empty database with the tables of
+// every protocol, so a whole Rollback can run against it.
+func newIndexTestExplorer(t *testing.T) *Explorer {
+	t.Helper()
+
+	dbc := storage.NewSqliteClient(utils.SqliteConfig{
+		Switch:   true,
+		Database: filepath.Join(t.TempDir(), "index.db"),
+	})
+	t.Cleanup(dbc.Stop)
+
+	tables := []interface{}{
+		&models.Drc20Collect{}, &models.Drc20CollectAddress{},
+		&models.Meme20Collect{}, &models.Meme20CollectAddress{},
+		&models.BoxCollect{}, &models.ConsensusStakeRecord{}, &models.CrossCollect{},
+		&models.ExchangeCollect{}, &models.FileCollectAddress{}, &models.FileExchangeCollect{},
+		&models.InviteCollect{}, &models.NftCollect{}, &models.NftCollectAddress{},
+		&models.PumpLiquidity{}, &models.PumpInviteReward{},
+		&models.StakeCollect{}, &models.StakeCollectAddress{}, &models.StakeCollectReward{},
+		&models.StakeV2Collect{}, &models.StakeV2CollectAddress{},
+		&models.SwapLiquidity{}, &models.SwapV2Liquidity{},
+	}
+	for _, h := range Protocols() {
+		tables = append(tables, h.InfoTables()...)
+		tables = append(tables, h.RevertTables()...)
+	}
+
+	if err := dbc.DB.AutoMigrate(tables...); err != nil {
+		t.Fatalf("AutoMigrate err: %s", err.Error())
+	}
+
+	// the candles are created outside the indexer
+	if err := dbc.DB.Table("swap_v2_summary").AutoMigrate(&models.Summary{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// these revert models have no primary key, forks read them by id
+	for _, table := range []string{"stake_revert", "stake_reward_revert"} {
+		if err := dbc.DB.Exec("ALTER TABLE " + table + " ADD COLUMN id integer").Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return &Explorer{dbc: dbc}
+}
+
+// reorgSource is a node that shares the indexed blocks up to common and
+// replaced all later ones.
+type reorgSource struct {
+	chain.ChainSource
+	common int64
+}
+
+func (s reorgSource) GetBlockHash(height int64) (*chainhash.Hash, error) {
+	if height <= s.common {
+		hash := localBlockHash(height)
+		return &hash, nil
+	}
+	hash := chainhash.DoubleHashH([]byte{byte(height)})
+	return &hash, nil
+}
+
+func localBlockHash(height int64) chainhash.Hash {
+	return chainhash.DoubleHashH([]byte{byte(height), 1})
+}
+
+// reorgScenario indexes the blocks 100 to 110 with a nft inscription at 103,
+// drc-20 ones at 105 and 108 and a pump one at 106.
+func reorgScenario(t *testing.T, e *Explorer) {
+	t.Helper()
+
+	for height := int64(100); height <= 110; height++ {
+		hash := localBlockHash(height)
+		if err := e.dbc.DB.Create(&models.Block{BlockNumber: height, BlockHash: hash.String()}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	inscriptions := []*models.BlockInscription{
+		{BlockNumber: 103, P: "nft", TxHash: "nft-103"},
+		{BlockNumber: 105, P: "drc-20", TxHash: "drc-105"},
+		{BlockNumber: 105, Seq: 1, P: "drc-20", TxHash: "drc-105-1"},
+		{BlockNumber: 106, P: "pump", TxHash: "pump-106"},
+		{BlockNumber: 108, P: "drc-20", TxHash: "drc-108"},
+	}
+	if err := e.dbc.DB.Create(inscriptions).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	e.currentHeight = 111
+}
+
+// newTipBlock is the block the node offers on top of its own chain.
+func newTipBlock() *btcjson.GetBlockVerboseResult {
+	return &btcjson.GetBlockVerboseResult{
+		Hash:         chainhash.DoubleHashH([]byte{111}).String(),
+		PreviousHash: chainhash.DoubleHashH([]byte{110}).String(),
+	}
+}
+
+func TestForkBackGroupsAffected(t *testing.T) {
+	e := newIndexTestExplorer(t)
+	e.node = reorgSource{common: 104}
+	e.config = &config.Config{}
+	reorgScenario(t, e)
+
+	forked, err := e.forkBack(newTipBlock())
+	if err != nil || !forked {
+		t.Fatalf("forkBack should roll back, forked %v err %v", forked, err)
+	}
+
+	if e.currentHeight != 105 || e.Halted() != nil {
+		t.Fatalf("explorer at %d halted %v", e.currentHeight, e.Halted())
+	}
+
+	event := &models.ReorgEvent{}
+	if err := e.dbc.DB.Last(event).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	want := models.ReorgAffected{
+		"drc-20": {"drc-105", "drc-105-1", "drc-108"},
+		"pump":   {"pump-106"},
+	}
+	if event.Halted || event.Height != 104 || event.Depth != 6 || !reflect.DeepEqual(event.Affected, want) {
+		t.Fatalf("unexpected event %+v", event)
+	}
+
+	left := make([]string, 0)
+	e.dbc.DB.Model(&models.BlockInscription{}).Pluck("tx_hash", &left)
+	if len(left) != 1 || left[0] != "nft-103" {
+		t.Fatalf("inscriptions left %v", left)
+	}
+
+	// the common ancestor is not scanned again
+	var top int64
+	e.dbc.DB.Model(&models.Block{}).Select("max(block_number)").Scan(&top)
+	if top != 104 {
+		t.Fatalf("last block %d, want the common ancestor 104", top)
+	}
+}
+
+func TestForkBackHaltsPastMaxDepth(t *testing.T) {
+	e := newIndexTestExplorer(t)
+	e.node = reorgSource{}
+	e.config = &config.Config{Explorer: utils.ExplorerConfig{MaxReorgDepth: 3}}
+	reorgScenario(t, e)
+
+	forked, err := e.forkBack(newTipBlock())
+	if err == nil || forked {
+		t.Fatalf("forkBack should halt, forked %v err %v", forked, err)
+	}
+
+	if e.Halted() == nil || e.Forking() {
+		t.Fatal("explorer should be halted")
+	}
+
+	event := &models.ReorgEvent{}
+	if err := e.dbc.DB.Last(event).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if !event.Halted || event.NewTip != newTipBlock().Hash || event.Depth != 4 || len(event.Affected) != 0 {
+		t.Fatalf("unexpected event %+v", event)
+	}
+
+	blocks, inscriptions := int64(0), int64(0)
+	e.dbc.DB.Model(&models.Block{}).Count(&blocks)
+	e.dbc.DB.Model(&models.BlockInscription{}).Count(&inscriptions)
+	if blocks != 11 || inscriptions != 5 {
+		t.Fatalf("a halted reorg should keep the index, %d blocks and %d inscriptions left", blocks, inscriptions)
+	}
+
+	// a restart on the same index halts again
+	restarted := &Explorer{dbc: e.dbc, config: e.config, currentHeight: 111}
+	if err := restarted.restoreHalt(); err != nil {
+		t.Fatal(err)
+	}
+	if restarted.Halted() == nil {
+		t.Fatal("a restart should keep the halt")
+	}
+
+	// until the limit covers the reorg
+	raised := &Explorer{dbc: e.dbc, config: &config.Config{Explorer: utils.ExplorerConfig{MaxReorgDepth: 4}}, currentHeight: 111}
+	if err := raised.restoreHalt(); err != nil {
+		t.Fatal(err)
+	}
+	if raised.Halted() != nil {
+		t.Fatal("a raised limit should clear the halt")
+	}
+}
+
+// candleScenario indexes blocks 100-102 a minute apart in one hour, each with
+// one trade of tick T.
+func candleScenario(t *testing.T, e *Explorer) int64 {
+	t.Helper()
+
+	hour := int64(1700000000) - int64(1700000000)%3600
+	for i, height := range []int64{100, 101, 102} {
+		blockTime := hour + int64(i)*60
+		if err := e.dbc.DB.Create(&models.Block{BlockNumber: height, BlockTime: blockTime}).Error; err != nil {
+			t.Fatal(err)
+		}
+
+		price := float64(i + 1)
+		if err := e.dbc.SummaryCandles(e.dbc.DB, "T", price, big.NewInt(int64(i+1)*10), height, blockTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return hour
+}
+
+func TestRollbackRebuildsCandles(t *testing.T) {
+	e := newIndexTestExplorer(t)
+	hour := candleScenario(t, e)
+
+	if err := e.Rollback(100); err != nil {
+		t.Fatal(err)
+	}
+
+	candle := &models.Summary{}
+	err := e.dbc.DB.Table("swap_v2_summary").Where("tick_id = ? and date_interval = ? and time_stamp = ?", "T", "1h", hour).First(candle).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	if candle.BaseVolume.Int64() != 10 || candle.ClosePrice != 1 || candle.HighestBid != 1 {
+		t.Fatalf("1h candle = volume %s close %v high %v, want the trade of block 100 only", candle.BaseVolume.String(), candle.ClosePrice, candle.HighestBid)
+	}
+
+	var minutes int64
+	e.dbc.DB.Table("swap_v2_summary").Where("date_interval = ?", "1m").Count(&minutes)
+	if minutes != 1 {
+		t.Fatalf("1m candles = %d, want 1", minutes)
+	}
+}
+
+func TestReindexRefusesUnloggedCandles(t *testing.T) {
+	e := newIndexTestExplorer(t)
+	candleScenario(t, e)
+
+	// blocks 100 and 101 were indexed before trades were logged
+	if err := e.dbc.DB.Where("block_number < ?", 102).Delete(&models.SummaryTrade{}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	err := e.Reindex(102, false)
+	if err == nil || !strings.Contains(err.Error(), "reindex from 100") {
+		t.Fatalf("Reindex err = %v, want a refusal naming block 100", err)
+	}
+}
+
+func TestRescanFromUnknownInput(t *testing.T) {
+	e := newIndexTestExplorer(t)
+	candleScenario(t, e)
+
+	err := e.dbc.DecodeFailureCreate(&models.DecodeFailure{
+		P:           "drc-20",
+		TxHash:      "drc-101",
+		BlockNumber: 101,
+		ErrInfo:     "GetRawTransactionVerboseBool err: -5: No such mempool or blockchain transaction",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	from, err := e.Rescan(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from != 101 || e.currentHeight != 101 {
+		t.Fatalf("Rescan from %d, currentHeight %d, want 101", from, e.currentHeight)
+	}
+
+	var blocks int64
+	e.dbc.DB.Model(&models.Block{}).Where("block_number > ?", 100).Count(&blocks)
+	if blocks != 0 {
+		t.Fatalf("%d blocks above 100 left", blocks)
+	}
+}
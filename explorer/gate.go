@@ -0,0 +1,45 @@
+package explorer
+
+import (
+	"reflect"
+)
+
+// gate checks the ops of inscription against the protocol gates at the
+// current height. Pair routers decode a whole tx into several rows, one gated
+// op rejects the tx.
+func (e *Explorer) gate(h ProtocolHandler, inscription interface{}) error {
+	for _, op := range inscriptionOps(inscription) {
+		err := e.dbc.ProtocolGate(h.Name(), op, e.currentHeight)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// inscriptionOps reads the Op of a decoded *_info row, or of each row of a
+// slice of them.
+func inscriptionOps(inscription interface{}) []string {
+	v := reflect.Indirect(reflect.ValueOf(inscription))
+	if v.Kind() != reflect.Slice {
+		return []string{inscriptionOp(v)}
+	}
+
+	ops := make([]string, v.Len())
+	for i := range ops {
+		ops[i] = inscriptionOp(reflect.Indirect(v.Index(i)))
+	}
+	return ops
+}
+
+func inscriptionOp(v reflect.Value) string {
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+
+	op := v.FieldByName("Op")
+	if op.Kind() != reflect.String {
+		return ""
+	}
+	return op.String()
+}
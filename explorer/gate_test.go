@@ -0,0 +1,51 @@
+package explorer
+
+import (
+	"dogeuni-indexer/models"
+	"dogeuni-indexer/params"
+	"github.com/dogecoinw/doged/chaincfg"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGatedInscriptionErrInfo(t *testing.T) {
+	e := newIndexTestExplorer(t)
+
+	path := filepath.Join(t.TempDir(), "params.json")
+	err := os.WriteFile(path, []byte(`{"gates": [{"p": "stake-v2", "op": "create", "until": 101}]}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schedule, err := params.Load(path, params.Mainnet, &chaincfg.MainNetParams, ProtocolNames())
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.dbc.SetProtocolParams(schedule)
+
+	h, _ := findProtocol("stake-v2")
+	stake := &models.StakeV2Info{Op: "create", StakeId: "pool-101", Tick0: stakeTick0, Tick1: stakeTick1, Reward: models.NewNumber(100000), EachReward: models.NewNumber(1000), HolderAddress: stakeCreator, TxHash: "create-101", BlockNumber: 101}
+	if err := e.dbc.DB.Create(stake).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	e.currentHeight = 101
+	e.apply(h, stake, stake.TxHash, 0)
+
+	row := &models.StakeV2Info{}
+	if err := e.dbc.DB.Where("tx_hash = ?", stake.TxHash).First(row).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if row.ErrInfo == nil || !strings.HasPrefix(*row.ErrInfo, "gated at height 101") {
+		t.Fatalf("err_info %v", row.ErrInfo)
+	}
+
+	count := int64(0)
+	e.dbc.DB.Model(&models.StakeV2Collect{}).Count(&count)
+	if count != 0 {
+		t.Fatal("a gated create should not open a pool")
+	}
+}
@@ -0,0 +1,238 @@
+// Package invite indexes the invite protocol. It registers itself with the
+// explorer from outside the package, the way a private protocol would.
+package invite
+
+import (
+	"dogeuni-indexer/explorer"
+	"dogeuni-indexer/models"
+	"dogeuni-indexer/router"
+	"dogeuni-indexer/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/dogecoinw/doged/btcjson"
+	"github.com/dogecoinw/go-dogecoin/log"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func init() {
+	explorer.RegisterProtocol(Handler{})
+}
+
+func decode(e *explorer.Explorer, tx *btcjson.TxRawResult, txIndex int, pushedData []byte, number int64) (*models.InviteInfo, error) {
+
+	err := e.DBClient().DB.Where("tx_hash = ? and tx_index = ?", tx.Hash, txIndex).First(&models.InviteInfo{}).Error
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("InviteInfo already exist or err %s", tx.Hash)
+	}
+
+	param := &models.InviteInscription{}
+	err = json.Unmarshal(pushedData, param)
+	if err != nil {
+		return nil, fmt.Errorf("json Unmarshal err: %s", err.Error())
+	}
+
+	invite, err := utils.ConvertInvite(param)
+	if err != nil {
+		return nil, fmt.Errorf("ConvertInvite err: %s", err.Error())
+	}
+
+	invite.FeeTxHash = tx.Vin[0].Txid
+
+	invite.TxHash = tx.Hash
+	invite.TxIndex = txIndex
+	invite.OrderId = utils.OrderId(invite.TxHash, invite.TxIndex, utils.SubOpInscription)
+	invite.BlockHash = tx.BlockHash
+	invite.BlockNumber = number
+	invite.OrderStatus = 1
+
+	_, txRawResult0, err := e.SpentOutput(tx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, _, err := e.InputAddress(txRawResult0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	invite.HolderAddress, err = explorer.OutputAddress(tx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := explorer.OutputValue(tx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if value != explorer.InscriptionValue {
+		return nil, fmt.Errorf("the amount of tokens exceeds the 0.0001")
+	}
+
+	if invite.HolderAddress != previous {
+		return nil, fmt.Errorf("the address is not the same as the previous transaction")
+	}
+
+	invite.FeeAddress, err = explorer.OutputAddress(txRawResult0, int(tx.Vin[0].Vout))
+	if err != nil {
+		return nil, err
+	}
+
+	err = e.DBClient().DB.Save(invite).Error
+	if err != nil {
+		return nil, fmt.Errorf("save err: %s", err.Error())
+	}
+
+	return invite, nil
+}
+
+func verify(e *explorer.Explorer, invite *models.InviteInfo) error {
+	switch invite.Op {
+	case "deploy":
+		return verifyDeploy(e, invite)
+	default:
+		return fmt.Errorf("do not support the type of tokens")
+	}
+}
+
+func verifyDeploy(e *explorer.Explorer, invite *models.InviteInfo) error {
+
+	if invite.InviteAddress == invite.HolderAddress {
+		return fmt.Errorf("the same address cannot be invited")
+	}
+
+	invitec := &models.InviteCollect{}
+	err := e.DBClient().DB.Where("holder_address = ? ", invite.HolderAddress).First(invitec).Error
+	if err == nil {
+		return fmt.Errorf("already invited")
+	}
+
+	return nil
+}
+
+func execute(e *explorer.Explorer, invite *models.InviteInfo) error {
+
+	var err error
+
+	if invite.Op == "deploy" {
+		err = deploy(e, invite)
+		if err != nil {
+			return fmt.Errorf("inviteInvite err: %s", err.Error())
+		}
+	}
+
+	return nil
+}
+
+func deploy(e *explorer.Explorer, invite *models.InviteInfo) error {
+
+	tx := e.DBClient().DB.Begin()
+
+	invitec := &models.InviteCollect{
+		InviteAddress: invite.InviteAddress,
+		HolderAddress: invite.HolderAddress,
+	}
+
+	err := tx.Create(invitec).Error
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("save err: %s", err.Error())
+	}
+
+	inviteRevert := &models.InviteRevert{
+		HolderAddress: invite.HolderAddress,
+		InviteAddress: invite.InviteAddress,
+		BlockNumber:   invite.BlockNumber,
+	}
+
+	err = tx.Create(inviteRevert).Error
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("save err: %s", err.Error())
+	}
+
+	err = tx.Model(&models.InviteInfo{}).Where("tx_hash = ? and tx_index = ?", invite.TxHash, invite.TxIndex).Update("order_status", 0).Error
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("update err: %s", err.Error())
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("commit err: %s", err.Error())
+	}
+
+	return nil
+}
+
+func fork(tx *gorm.DB, height int64) error {
+
+	log.Info("fork", "invite", height)
+	var inviteReverts []*models.InviteRevert
+	err := tx.Model(&models.InviteRevert{}).
+		Where("block_number > ?", height).
+		Order("id desc").
+		Find(&inviteReverts).Error
+
+	if err != nil {
+		return fmt.Errorf("meme20 revert error: %v", err)
+	}
+
+	for _, revert := range inviteReverts {
+		err = tx.Where("invite_address = ? and holder_address = ?", revert.InviteAddress, revert.HolderAddress).Delete(&models.InviteCollect{}).Error
+		if err != nil {
+			return fmt.Errorf("delete err: %s", err.Error())
+		}
+	}
+
+	return nil
+}
+
+// Handler is the invite protocol handler.
+type Handler struct{}
+
+func (Handler) Name() string {
+	return "invite"
+}
+
+func (Handler) Decode(e *explorer.Explorer, tx *btcjson.TxRawResult, txIndex int, pushedData []byte, height int64) (interface{}, error) {
+	return decode(e, tx, txIndex, pushedData, height)
+}
+
+func (Handler) Verify(e *explorer.Explorer, inscription interface{}) error {
+	err := verify(e, inscription.(*models.InviteInfo))
+	if err != nil {
+		return fmt.Errorf("VerifyInvite err: %s", err.Error())
+	}
+	return nil
+}
+
+func (Handler) Execute(e *explorer.Explorer, inscription interface{}) error {
+	return execute(e, inscription.(*models.InviteInfo))
+}
+
+func (Handler) Fork(e *explorer.Explorer, tx *gorm.DB, height int64) error {
+	return fork(tx, height)
+}
+
+func (Handler) InfoModel() interface{} {
+	return &models.InviteInfo{}
+}
+
+func (Handler) InfoTables() []interface{} {
+	return []interface{}{&models.InviteInfo{}}
+}
+
+func (Handler) RevertTables() []interface{} {
+	return []interface{}{&models.InviteRevert{}}
+}
+
+func (Handler) Routes(rg *gin.RouterGroup, deps *explorer.RouteDeps) {
+	inviteRouter := router.NewInviteRouter(deps.DBC, deps.Node, deps.Level)
+	rg.POST("/invite/order", deps.Pending.Order("invite", inviteRouter.Order))
+	rg.POST("/invite/collect", inviteRouter.Collect)
+	rg.POST("/invite/pump-reword", inviteRouter.PumpReward)
+	rg.POST("/invite/pump-reword-total", inviteRouter.PumpRewardTotal)
+}
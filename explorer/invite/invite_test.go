@@ -0,0 +1,64 @@
+package invite
+
+import (
+	"context"
+	"dogeuni-indexer/config"
+	"dogeuni-indexer/explorer"
+	"dogeuni-indexer/models"
+	"dogeuni-indexer/storage"
+	"dogeuni-indexer/utils"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestHandlerOutsideExplorer(t *testing.T) {
+	registered := false
+	for _, name := range explorer.ProtocolNames() {
+		registered = registered || name == "invite"
+	}
+	if !registered {
+		t.Fatal("invite should register itself")
+	}
+
+	dbc := storage.NewSqliteClient(utils.SqliteConfig{
+		Switch:   true,
+		Database: filepath.Join(t.TempDir(), "invite.db"),
+	})
+	t.Cleanup(dbc.Stop)
+
+	h := Handler{}
+	if err := dbc.DB.AutoMigrate(append(h.InfoTables(), h.RevertTables()...)...); err != nil {
+		t.Fatal(err)
+	}
+	if err := dbc.DB.AutoMigrate(&models.InviteCollect{}); err != nil {
+		t.Fatal(err)
+	}
+
+	e := explorer.NewExplorer(context.Background(), &sync.WaitGroup{}, &config.Config{}, nil, dbc, nil)
+
+	invite := &models.InviteInfo{Op: "deploy", InviteAddress: "inviter", HolderAddress: "holder", TxHash: "deploy", BlockNumber: 100, OrderStatus: 1}
+	if err := dbc.DB.Create(invite).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := h.Verify(e, invite); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Execute(e, invite); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Verify(e, invite); err == nil {
+		t.Fatal("a holder is invited once")
+	}
+
+	if err := h.Fork(e, dbc.DB, 99); err != nil {
+		t.Fatal(err)
+	}
+
+	count := int64(0)
+	dbc.DB.Model(&models.InviteCollect{}).Count(&count)
+	if count != 0 {
+		t.Fatal("the fork should drop the invite")
+	}
+}
@@ -2,20 +2,20 @@ package explorer
 
 import (
 	"dogeuni-indexer/models"
+	"dogeuni-indexer/router"
 	"dogeuni-indexer/utils"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/dogecoinw/doged/btcjson"
-	"github.com/dogecoinw/doged/chaincfg/chainhash"
 	"github.com/dogecoinw/go-dogecoin/log"
-	"github.com/google/uuid"
+	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func (e *Explorer) meme20Decode(tx *btcjson.TxRawResult, pushedData []byte, number int64) (*models.Meme20Info, error) {
+func (e *Explorer) meme20Decode(tx *btcjson.TxRawResult, txIndex int, pushedData []byte, number int64) (*models.Meme20Info, error) {
 
-	err := e.dbc.DB.Where("tx_hash = ?", tx.Hash).First(&models.Meme20Info{}).Error
+	err := e.dbc.DB.Where("tx_hash = ? and tx_index = ?", tx.Hash, txIndex).First(&models.Meme20Info{}).Error
 	if !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, fmt.Errorf("meme20 already exist or err %s", tx.Hash)
 	}
@@ -31,44 +31,58 @@ func (e *Explorer) meme20Decode(tx *btcjson.TxRawResult, pushedData []byte, numb
 		return nil, fmt.Errorf("ConvetMeme err: %s", err.Error())
 	}
 
-	meme.OrderId = uuid.New().String()
 	meme.FeeTxHash = tx.Vin[0].Txid
 
 	meme.TxHash = tx.Hash
+	meme.TxIndex = txIndex
+	meme.OrderId = utils.OrderId(meme.TxHash, meme.TxIndex, utils.SubOpInscription)
 	meme.BlockHash = tx.BlockHash
 	meme.BlockNumber = number
 	meme.OrderStatus = 1
 
-	txhash0, _ := chainhash.NewHashFromStr(tx.Vin[0].Txid)
-	txRawResult0, err := e.node.GetRawTransactionVerboseBool(txhash0)
+	_, txRawResult0, err := e.SpentOutput(tx, 0)
 	if err != nil {
-		return nil, fmt.Errorf("GetRawTransactionVerboseBool err: %s", err.Error())
+		return nil, err
 	}
 
-	txhash1, _ := chainhash.NewHashFromStr(txRawResult0.Vin[0].Txid)
-	txRawResult1, err := e.node.GetRawTransactionVerboseBool(txhash1)
+	previous, _, err := e.InputAddress(txRawResult0, 0)
 	if err != nil {
-		return nil, fmt.Errorf("GetRawTransactionVerboseBool err: %s", err.Error())
+		return nil, err
 	}
 
 	if meme.Op == "deploy" {
-		meme.HolderAddress = tx.Vout[0].ScriptPubKey.Addresses[0]
-		meme.TickId = tx.Hash
-		if tx.Vout[0].Value != 0.001 {
+		meme.HolderAddress, err = OutputAddress(tx, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		meme.TickId = utils.InscriptionId(tx.Hash, txIndex)
+		value, err := OutputValue(tx, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		if value != InscriptionValue {
 			return nil, fmt.Errorf("the amount of tokens exceeds the 0.0001")
 		}
 
-		if meme.HolderAddress != txRawResult1.Vout[txRawResult0.Vin[0].Vout].ScriptPubKey.Addresses[0] {
+		if meme.HolderAddress != previous {
 			return nil, fmt.Errorf("the address is not the same as the previous transaction")
 		}
 	}
 
 	if meme.Op == "transfer" {
-		meme.HolderAddress = txRawResult1.Vout[txRawResult0.Vin[0].Vout].ScriptPubKey.Addresses[0]
-		meme.ToAddress = tx.Vout[0].ScriptPubKey.Addresses[0]
+		meme.HolderAddress = previous
+		meme.ToAddress, err = OutputAddress(tx, 0)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	meme.FeeAddress = txRawResult0.Vout[tx.Vin[0].Vout].ScriptPubKey.Addresses[0]
+	meme.FeeAddress, err = OutputAddress(txRawResult0, int(tx.Vin[0].Vout))
+	if err != nil {
+		return nil, err
+	}
 
 	err = e.dbc.DB.Save(meme).Error
 	if err != nil {
@@ -125,7 +139,13 @@ func (e *Explorer) memeDeploy(meme *models.Meme20Info) error {
 		return fmt.Errorf("save err: %s", err.Error())
 	}
 
-	err = tx.Model(&models.Meme20Info{}).Where("tx_hash = ?", meme.TxHash).Update("order_status", 0).Error
+	err = e.dbc.StateChange(tx, meme.BlockNumber, "meme20", meme20ca.TickId, meme20ca.HolderAddress, meme20ca.Amt.String())
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("StateChange err: %s", err.Error())
+	}
+
+	err = tx.Model(&models.Meme20Info{}).Where("tx_hash = ? and tx_index = ?", meme.TxHash, meme.TxIndex).Update("order_status", 0).Error
 	if err != nil {
 		tx.Rollback()
 		return fmt.Errorf("update err: %s", err.Error())
@@ -148,7 +168,7 @@ func (e *Explorer) meme20Transfer(meme20 *models.Meme20Info) error {
 		return err
 	}
 
-	err = tx.Model(&models.Meme20Info{}).Where("tx_hash = ?", meme20.TxHash).Update("order_status", 0).Error
+	err = tx.Model(&models.Meme20Info{}).Where("tx_hash = ? and tx_index = ?", meme20.TxHash, meme20.TxIndex).Update("order_status", 0).Error
 	if err != nil {
 		tx.Rollback()
 		return fmt.Errorf("Update err: %s", err.Error())
@@ -197,3 +217,49 @@ func (e *Explorer) meme20Fork(tx *gorm.DB, height int64) error {
 
 	return nil
 }
+
+type meme20Handler struct{}
+
+func (meme20Handler) Name() string {
+	return "meme-20"
+}
+
+func (meme20Handler) Decode(e *Explorer, tx *btcjson.TxRawResult, txIndex int, pushedData []byte, height int64) (interface{}, error) {
+	return e.meme20Decode(tx, txIndex, pushedData, height)
+}
+
+func (meme20Handler) Verify(e *Explorer, inscription interface{}) error {
+	err := e.verify.VerifyMeme20(inscription.(*models.Meme20Info))
+	if err != nil {
+		return fmt.Errorf("VerifyMeme20 err: %s", err.Error())
+	}
+	return nil
+}
+
+func (meme20Handler) Execute(e *Explorer, inscription interface{}) error {
+	return e.executeMeme20(inscription.(*models.Meme20Info))
+}
+
+func (meme20Handler) Fork(e *Explorer, tx *gorm.DB, height int64) error {
+	return e.meme20Fork(tx, height)
+}
+
+func (meme20Handler) InfoModel() interface{} {
+	return &models.Meme20Info{}
+}
+
+func (meme20Handler) InfoTables() []interface{} {
+	return []interface{}{&models.Meme20Info{}}
+}
+
+func (meme20Handler) RevertTables() []interface{} {
+	return []interface{}{&models.Meme20Revert{}}
+}
+
+func (meme20Handler) Routes(rg *gin.RouterGroup, deps *RouteDeps) {
+	meme20Router := router.NewMeme20Router(deps.DBC, deps.Node, deps.Level)
+	rg.POST("/meme20/order", deps.Pending.Order("meme-20", meme20Router.Order))
+	rg.POST("/meme20/collect", meme20Router.Collect)
+	rg.POST("/meme20/collect-address", meme20Router.CollectAddress)
+	rg.POST("/meme20/history", meme20Router.History)
+}
@@ -0,0 +1,159 @@
+package explorer
+
+import (
+	"dogeuni-indexer/chain"
+	"dogeuni-indexer/models"
+	"encoding/json"
+	"fmt"
+	"github.com/dogecoinw/doged/btcjson"
+	"github.com/dogecoinw/go-dogecoin/log"
+	"time"
+)
+
+// mempoolSkip lists the protocols whose decoders write outside the database,
+// they are not decoded before they are mined.
+var mempoolSkip = map[string]bool{
+	"file": true,
+	"nft":  true,
+}
+
+// scanMempool decodes the inscriptions waiting in the mempool into the pending
+// store and drops the entries that left it. Mined entries are promoted by the
+// scanner, which indexes them into the *_info tables.
+func (e *Explorer) scanMempool() error {
+	mp, ok := e.node.(chain.Mempool)
+	if !ok {
+		return nil
+	}
+
+	hashes, err := mp.GetRawMempool()
+	if err != nil {
+		return fmt.Errorf("scanMempool GetRawMempool err: %s", err.Error())
+	}
+
+	inPool := make(map[string]bool, len(hashes))
+	for _, hash := range hashes {
+		inPool[hash.String()] = true
+	}
+
+	known, err := e.dbc.PendingTxHashes()
+	if err != nil {
+		return fmt.Errorf("scanMempool PendingTxHashes err: %s", err.Error())
+	}
+
+	pending := make(map[string]bool, len(known))
+	evicted := make([]string, 0)
+	for _, txid := range known {
+		if inPool[txid] {
+			pending[txid] = true
+		} else {
+			evicted = append(evicted, txid)
+		}
+	}
+
+	err = e.dbc.PendingDelete(evicted)
+	if err != nil {
+		return fmt.Errorf("scanMempool PendingDelete err: %s", err.Error())
+	}
+
+	for txid := range e.mempoolSeen {
+		if !inPool[txid] {
+			delete(e.mempoolSeen, txid)
+		}
+	}
+
+	for _, hash := range hashes {
+		txid := hash.String()
+		if pending[txid] || e.mempoolSeen[txid] {
+			continue
+		}
+
+		txv, err := e.node.GetRawTransactionVerboseBool(hash)
+		if err != nil {
+			log.Trace("mempool", "GetRawTransactionVerboseBool", err, "txhash", txid)
+			continue
+		}
+
+		// every tx is decoded once while it stays in the mempool
+		e.mempoolSeen[txid] = true
+
+		infos, err := e.pendingDecode(txv)
+		if err != nil {
+			log.Trace("mempool", "decode", err, "txhash", txid)
+			continue
+		}
+
+		for _, info := range infos {
+			err = e.dbc.PendingCreate(info)
+			if err != nil {
+				return fmt.Errorf("scanMempool PendingCreate err: %s", err.Error())
+			}
+		}
+	}
+
+	return nil
+}
+
+// pendingDecode runs the protocol decoders against the indexed state inside a
+// transaction that is always rolled back, so no *_info row is kept. It returns
+// one row per inscription carried by txv.
+func (e *Explorer) pendingDecode(txv *btcjson.TxRawResult) ([]*models.PendingInfo, error) {
+	inscriptions := e.inscriptions(txv, e.currentHeight)
+	if len(inscriptions) == 0 {
+		return nil, nil
+	}
+
+	tx := e.dbc.DB.Begin()
+	defer tx.Rollback()
+
+	pe := *e
+	pe.dbc = e.dbc.WithTx(tx)
+	pe.resolved = nil
+
+	infos := make([]*models.PendingInfo, 0, len(inscriptions))
+	for _, ins := range inscriptions {
+		h := ins.handler
+		if mempoolSkip[h.Name()] {
+			continue
+		}
+
+		inscription, err := h.Decode(&pe, ins.tx, ins.index, ins.pushedData, e.currentHeight)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := json.Marshal(inscription)
+		if err != nil {
+			return nil, fmt.Errorf("json.Marshal err: %s", err.Error())
+		}
+
+		head := struct {
+			Op            string `json:"op"`
+			Tick          string `json:"tick"`
+			HolderAddress string `json:"holder_address"`
+			ToAddress     string `json:"to_address"`
+		}{}
+
+		// pair routers decode into several rows, the first one describes the tx
+		rows := make([]json.RawMessage, 0)
+		if json.Unmarshal(data, &rows) == nil && len(rows) > 0 {
+			_ = json.Unmarshal(rows[0], &head)
+		} else {
+			_ = json.Unmarshal(data, &head)
+		}
+
+		infos = append(infos, &models.PendingInfo{
+			P:             h.Name(),
+			Op:            head.Op,
+			Tick:          head.Tick,
+			TxHash:        txv.Txid,
+			TxIndex:       ins.index,
+			HolderAddress: head.HolderAddress,
+			ToAddress:     head.ToAddress,
+			Data:          string(data),
+			CreateDate:    models.LocalTime(time.Now().Unix()),
+		})
+	}
+
+	return infos, nil
+}
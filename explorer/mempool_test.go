@@ -0,0 +1,99 @@
+package explorer
+
+import (
+	"dogeuni-indexer/chain"
+	"dogeuni-indexer/models"
+	"dogeuni-indexer/params"
+	"encoding/hex"
+	"github.com/dogecoinw/doged/btcjson"
+	"github.com/dogecoinw/doged/chaincfg/chainhash"
+	"github.com/dogecoinw/doged/txscript"
+	"testing"
+)
+
+// mempoolSource serves txs from memory and reports them all as unconfirmed.
+type mempoolSource struct {
+	chain.ChainSource
+	txs map[string]*btcjson.TxRawResult
+}
+
+func (s mempoolSource) GetRawMempool() ([]*chainhash.Hash, error) {
+	hashes := make([]*chainhash.Hash, 0)
+	for txid := range s.txs {
+		hash, _ := chainhash.NewHashFromStr(txid)
+		hashes = append(hashes, hash)
+	}
+	return hashes, nil
+}
+
+func (s mempoolSource) GetRawTransactionVerboseBool(txHash *chainhash.Hash) (*btcjson.TxRawResult, error) {
+	txv, ok := s.txs[txHash.String()]
+	if !ok {
+		return nil, &btcjson.RPCError{Code: btcjson.ErrRPCNoTxInfo, Message: "No such mempool or blockchain transaction"}
+	}
+	return txv, nil
+}
+
+// inscriptionSig is a scriptSig that carries data the way the decoders read it.
+func inscriptionSig(t *testing.T, data string) *btcjson.ScriptSig {
+	t.Helper()
+
+	inner, err := txscript.NewScriptBuilder().AddData([]byte("ord")).AddData([]byte("ab")).AddData([]byte("text/plain")).AddData([]byte(data)).Script()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	script, err := txscript.NewScriptBuilder().AddData([]byte("signature")).AddData([]byte("pubkey")).AddData(inner).Script()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &btcjson.ScriptSig{Hex: hex.EncodeToString(script)}
+}
+
+func TestMempoolDecodesPump(t *testing.T) {
+	e := newIndexTestExplorer(t)
+	e.mempoolSeen = make(map[string]bool)
+
+	grandparent := chainhash.DoubleHashH([]byte("grandparent")).String()
+	parent := chainhash.DoubleHashH([]byte("parent")).String()
+	trade := chainhash.DoubleHashH([]byte("trade")).String()
+
+	data := `{"p":"pump","op":"trade","pair_id":"pair","tick0_id":"tick0","amt0":"100","amt1_min":"1","doge":0}`
+	e.node = mempoolSource{txs: map[string]*btcjson.TxRawResult{
+		grandparent: {Txid: grandparent, Hash: grandparent, Vout: []btcjson.Vout{pay(stakeHolder, 1)}},
+		parent: {
+			Txid: parent, Hash: parent,
+			Vin:  []btcjson.Vin{{Txid: grandparent}},
+			Vout: []btcjson.Vout{pay(stakeCreator, 0.1)},
+		},
+		trade: {
+			Txid: trade, Hash: trade,
+			Vin:  []btcjson.Vin{{Txid: parent, ScriptSig: inscriptionSig(t, data)}},
+			Vout: []btcjson.Vout{pay(stakeHolder, 0.001), pay(params.Mainnet.PumpTipAddress, 0.1)},
+		},
+	}}
+
+	// the decoders read the protocol params and the chain params through
+	// the client of the pending transaction
+	if err := e.scanMempool(); err != nil {
+		t.Fatal(err)
+	}
+
+	pending := make([]*models.PendingInfo, 0)
+	if err := e.dbc.DB.Find(&pending).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].P != "pump" || pending[0].TxHash != trade || pending[0].HolderAddress != stakeHolder {
+		t.Fatalf("pending %+v", pending)
+	}
+
+	count := int64(0)
+	e.dbc.DB.Model(&models.PumpInfo{}).Count(&count)
+	if count != 0 {
+		t.Fatal("a pending decode should not keep the pump row")
+	}
+
+	if e.dbc.WithTx(e.dbc.DB).NetParams() == nil {
+		t.Fatal("a pending client should keep the chain params")
+	}
+}
@@ -3,18 +3,20 @@ package explorer
 import (
 	"bytes"
 	"dogeuni-indexer/models"
+	"dogeuni-indexer/router"
 	"dogeuni-indexer/utils"
 	"errors"
 	"fmt"
 	"github.com/dogecoinw/doged/btcjson"
-	"github.com/dogecoinw/doged/chaincfg/chainhash"
 	"github.com/dogecoinw/go-dogecoin/log"
-	"github.com/google/uuid"
+	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 func (e *Explorer) nftDecode(tx *btcjson.TxRawResult, number int64) (*models.NftInfo, error) {
 
+	protocol := e.dbc.ProtocolParams(number)
+
 	err := e.dbc.DB.Where("tx_hash = ?", tx.Hash).First(&models.NftInfo{}).Error
 	if !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, fmt.Errorf("nft already exist or err %s", tx.Hash)
@@ -30,12 +32,13 @@ func (e *Explorer) nftDecode(tx *btcjson.TxRawResult, number int64) (*models.Nft
 		return nil, fmt.Errorf("ConvertNft err: %s", err.Error())
 	}
 
-	nft.OrderId = uuid.New().String()
 	nft.FeeTxHash = tx.Vin[0].Txid
 
 	nft.TxHash = tx.Hash
+	nft.OrderId = utils.OrderId(nft.TxHash, 0, utils.SubOpInscription)
 	nft.BlockHash = tx.BlockHash
 	nft.BlockNumber = number
+	nft.OrderStatus = 1
 
 	if nft.Op == "deploy" {
 
@@ -43,17 +46,30 @@ func (e *Explorer) nftDecode(tx *btcjson.TxRawResult, number int64) (*models.Nft
 			return nil, errors.New("deploy op error, vout length is not 2")
 		}
 
-		nft.HolderAddress = tx.Vout[0].ScriptPubKey.Addresses[0]
+		nft.HolderAddress, err = OutputAddress(tx, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := OutputValue(tx, 0)
+		if err != nil {
+			return nil, err
+		}
 
-		if tx.Vout[0].Value != 0.001 {
+		if value != InscriptionValue {
 			return nil, fmt.Errorf("The amount of tokens exceeds the 0.0001")
 		}
 
-		if tx.Vout[1].Value < 1000 {
+		fee, err := output(tx, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		if fee.Value < nftDeployFee {
 			return nil, fmt.Errorf("The balance is insufficient")
 		}
 
-		if tx.Vout[1].ScriptPubKey.Addresses[0] != nftFeeAddress {
+		if fee.Address != protocol.NftFeeAddress {
 			return nil, fmt.Errorf("The address is incorrect")
 		}
 	}
@@ -64,44 +80,60 @@ func (e *Explorer) nftDecode(tx *btcjson.TxRawResult, number int64) (*models.Nft
 			return nil, errors.New("mint op error, vout length is not 2")
 		}
 
-		nft.HolderAddress = tx.Vout[0].ScriptPubKey.Addresses[0]
+		nft.HolderAddress, err = OutputAddress(tx, 0)
+		if err != nil {
+			return nil, err
+		}
 
-		if tx.Vout[0].Value != 0.001 {
+		value, err := OutputValue(tx, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		if value != InscriptionValue {
 			return nil, fmt.Errorf("The amount of tokens exceeds the 0.0001")
 		}
 
-		if tx.Vout[1].Value < 10 {
+		fee, err := output(tx, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		if fee.Value < nftMintFee {
 			return nil, fmt.Errorf("The balance is insufficient")
 		}
 
-		if tx.Vout[1].ScriptPubKey.Addresses[0] != nftFeeAddress {
+		if fee.Address != protocol.NftFeeAddress {
 			return nil, fmt.Errorf("The address is incorrect")
 		}
 	}
 
-	txHash0, _ := chainhash.NewHashFromStr(tx.Vin[0].Txid)
-	txRawResult0, err := e.node.GetRawTransactionVerboseBool(txHash0)
+	_, txRawResult0, err := e.SpentOutput(tx, 0)
 	if err != nil {
-		return nil, fmt.Errorf("GetRawTransactionVerboseBool err: %s", err.Error())
+		return nil, err
 	}
 
 	if nft.Op == "transfer" {
 
-		txhash1, _ := chainhash.NewHashFromStr(txRawResult0.Vin[0].Txid)
-		txRawResult1, err := e.node.GetRawTransactionVerboseBool(txhash1)
+		nft.HolderAddress, _, err = e.InputAddress(txRawResult0, 0)
 		if err != nil {
-			return nil, fmt.Errorf("GetRawTransactionVerboseBool err: %s", err.Error())
+			return nil, err
 		}
 
-		nft.HolderAddress = txRawResult1.Vout[txRawResult0.Vin[0].Vout].ScriptPubKey.Addresses[0]
-		nft.ToAddress = tx.Vout[0].ScriptPubKey.Addresses[0]
+		nft.ToAddress, err = OutputAddress(tx, 0)
+		if err != nil {
+			return nil, err
+		}
 
 		if nft.HolderAddress == nft.ToAddress {
 			return nil, errors.New("The address is the same")
 		}
 	}
 
-	nft.FeeAddress = txRawResult0.Vout[tx.Vin[0].Vout].ScriptPubKey.Addresses[0]
+	nft.FeeAddress, err = OutputAddress(txRawResult0, int(tx.Vin[0].Vout))
+	if err != nil {
+		return nil, err
+	}
 
 	reader := bytes.NewReader(nft.ImageData)
 	hash, _ := e.ipfs.Add(reader)
@@ -125,7 +157,7 @@ func (e *Explorer) nftDeploy(nft *models.NftInfo) error {
 		return err
 	}
 
-	err = tx.Model(&models.NftInfo{}).Where("tx_hash = ?", nft.TxHash).Update("order_status", 0).Error
+	err = tx.Model(&models.NftInfo{}).Where("tx_hash = ? and tx_index = ?", nft.TxHash, nft.TxIndex).Update("order_status", 0).Error
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -150,7 +182,7 @@ func (e *Explorer) nftMint(nft *models.NftInfo) error {
 		return err
 	}
 
-	err = tx.Model(&models.NftInfo{}).Where("tx_hash = ?", nft.TxHash).Update("order_status", 0).Error
+	err = tx.Model(&models.NftInfo{}).Where("tx_hash = ? and tx_index = ?", nft.TxHash, nft.TxIndex).Update("order_status", 0).Error
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -175,7 +207,7 @@ func (e *Explorer) nftTransfer(nft *models.NftInfo) error {
 		return err
 	}
 
-	err = tx.Model(&models.NftInfo{}).Where("tx_hash = ?", nft.TxHash).Update("order_status", 0).Error
+	err = tx.Model(&models.NftInfo{}).Where("tx_hash = ? and tx_index = ?", nft.TxHash, nft.TxIndex).Update("order_status", 0).Error
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -188,3 +220,94 @@ func (e *Explorer) nftTransfer(nft *models.NftInfo) error {
 
 	return nil
 }
+
+func (e *Explorer) nftFork(tx *gorm.DB, height int64) error {
+	log.Info("fork", "nft", height)
+	// nft
+	var nftReverts []*models.NftRevert
+	err := tx.Model(&models.NftRevert{}).
+		Where("block_number > ?", height).
+		Order("id desc").
+		Find(&nftReverts).Error
+
+	if err != nil {
+		return fmt.Errorf("FindNftRevert error: %v", err)
+	}
+
+	for _, revert := range nftReverts {
+		if revert.FromAddress == "" && revert.ToAddress == "" {
+			err = tx.Where("tick = ?", revert.Tick).Delete(&models.NftCollect{}).Error
+			if err != nil {
+				return fmt.Errorf("nftFork deploy error: %v", err)
+			}
+
+			err = tx.Where("tick = ?", revert.Tick).Delete(&models.NftCollectAddress{}).Error
+			if err != nil {
+				return fmt.Errorf("nftFork deploy error: %v", err)
+			}
+			continue
+		}
+
+		if revert.FromAddress == "" {
+			err = e.dbc.BurnNft(tx, revert.Tick, revert.ToAddress, revert.TickId)
+			if err != nil {
+				return fmt.Errorf("nftFork BurnNft error: %v", err)
+			}
+			continue
+		}
+
+		err = e.dbc.TransferNft(tx, revert.Tick, revert.ToAddress, revert.FromAddress, revert.TickId, height, true)
+		if err != nil {
+			return fmt.Errorf("nftFork TransferNft error: %v", err)
+		}
+	}
+
+	return nil
+}
+
+type nftHandler struct{}
+
+func (nftHandler) Name() string {
+	return "nft"
+}
+
+func (nftHandler) Decode(e *Explorer, tx *btcjson.TxRawResult, txIndex int, pushedData []byte, height int64) (interface{}, error) {
+	return e.nftDecode(tx, height)
+}
+
+func (nftHandler) Verify(e *Explorer, inscription interface{}) error {
+	err := e.verify.VerifyNFT(inscription.(*models.NftInfo))
+	if err != nil {
+		return fmt.Errorf("VerifyNFT err: %s", err.Error())
+	}
+	return nil
+}
+
+func (nftHandler) Execute(e *Explorer, inscription interface{}) error {
+	return e.executeNft(inscription.(*models.NftInfo))
+}
+
+func (nftHandler) Fork(e *Explorer, tx *gorm.DB, height int64) error {
+	return e.nftFork(tx, height)
+}
+
+func (nftHandler) WholeTx() {}
+
+func (nftHandler) InfoModel() interface{} {
+	return &models.NftInfo{}
+}
+
+func (nftHandler) InfoTables() []interface{} {
+	return []interface{}{&models.NftInfo{}}
+}
+
+func (nftHandler) RevertTables() []interface{} {
+	return []interface{}{&models.NftRevert{}}
+}
+
+func (nftHandler) Routes(rg *gin.RouterGroup, deps *RouteDeps) {
+	nftRouter := router.NewNftRouter(deps.DBC, deps.Node)
+	rg.POST("/nft/order", deps.Pending.Order("nft", nftRouter.Order))
+	rg.POST("/nft/collect", nftRouter.Collect)
+	rg.POST("/nft/collect-address", nftRouter.CollectAddress)
+}
@@ -0,0 +1,72 @@
+package explorer
+
+import (
+	"encoding/hex"
+	"github.com/dogecoinw/go-dogecoin/log"
+	"github.com/go-zeromq/zmq4"
+	"time"
+)
+
+const (
+	notifyTopic        = "hashblock"
+	notifyRetryInitial = time.Second
+	notifyRetryMax     = time.Minute
+)
+
+// subscribeBlocks listens to the node's zmqpubhashblock endpoint and wakes the
+// scanner on every new block. The connection is retried with backoff until the
+// explorer stops; polling keeps the index moving in the meantime.
+func (e *Explorer) subscribeBlocks(endpoint string) {
+	if endpoint == "" {
+		log.Warn("explorer", "subscribeBlocks", "zmq_block is empty, polling only")
+		return
+	}
+
+	retry := notifyRetryInitial
+	for {
+		err := e.recvBlocks(endpoint)
+		if e.ctx.Err() != nil {
+			return
+		}
+
+		log.Error("explorer", "subscribeBlocks", err.Error(), "retry", retry)
+		select {
+		case <-time.After(retry):
+		case <-e.ctx.Done():
+			return
+		}
+
+		retry *= 2
+		if retry > notifyRetryMax {
+			retry = notifyRetryMax
+		}
+	}
+}
+
+func (e *Explorer) recvBlocks(endpoint string) error {
+	sub := zmq4.NewSub(e.ctx)
+	defer sub.Close()
+
+	err := sub.Dial(endpoint)
+	if err != nil {
+		return err
+	}
+
+	err = sub.SetOption(zmq4.OptionSubscribe, notifyTopic)
+	if err != nil {
+		return err
+	}
+
+	log.Info("explorer", "subscribeBlocks", endpoint)
+	for {
+		msg, err := sub.Recv()
+		if err != nil {
+			return err
+		}
+
+		if len(msg.Frames) > 1 {
+			log.Trace("explorer", "hashblock", hex.EncodeToString(msg.Frames[1]))
+		}
+		e.Notify()
+	}
+}
@@ -0,0 +1,283 @@
+package explorer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/dogecoinw/doged/btcjson"
+	"github.com/dogecoinw/doged/chaincfg/chainhash"
+	"github.com/dogecoinw/go-dogecoin/log"
+	"sync"
+)
+
+const (
+	defaultPrefetchBlocks  = 10
+	defaultPrefetchWorkers = 16
+
+	// parentHops is how far decoders walk Vin[0] back to find the holder.
+	parentHops = 2
+)
+
+// fetchedBlock is a block with all of its transactions, and the parents of
+// its inscription inputs, already resolved from the node.
+type fetchedBlock struct {
+	height int64
+	hash   *chainhash.Hash
+	block  *btcjson.GetBlockVerboseResult
+	txList []*btcjson.TxRawResult
+	txs    map[string]*btcjson.TxRawResult
+	err    error
+}
+
+// prefetcher fetches blocks ahead of the state applier with a bounded pool of
+// RPC workers and hands them back in height order.
+type prefetcher struct {
+	e       *Explorer
+	ctx     context.Context
+	cancel  context.CancelFunc
+	results chan chan *fetchedBlock
+	sem     chan struct{}
+}
+
+func newPrefetcher(e *Explorer, from, to int64) *prefetcher {
+	blocks := e.config.Explorer.PrefetchBlocks
+	if blocks <= 0 {
+		blocks = defaultPrefetchBlocks
+	}
+
+	workers := e.config.Explorer.PrefetchWorkers
+	if workers <= 0 {
+		workers = defaultPrefetchWorkers
+	}
+
+	ctx, cancel := context.WithCancel(e.ctx)
+	p := &prefetcher{
+		e:       e,
+		ctx:     ctx,
+		cancel:  cancel,
+		results: make(chan chan *fetchedBlock, blocks),
+		sem:     make(chan struct{}, workers),
+	}
+
+	go p.run(from, to)
+	return p
+}
+
+func (p *prefetcher) run(from, to int64) {
+	defer close(p.results)
+
+	for height := from; height < to; height++ {
+		ch := make(chan *fetchedBlock, 1)
+		select {
+		case p.results <- ch:
+		case <-p.ctx.Done():
+			return
+		}
+
+		go func(height int64) {
+			ch <- p.fetch(height)
+		}(height)
+	}
+}
+
+// next returns the next block in height order.
+func (p *prefetcher) next() (*fetchedBlock, error) {
+	var ch chan *fetchedBlock
+	select {
+	case c, ok := <-p.results:
+		if !ok {
+			return nil, fmt.Errorf("prefetcher closed")
+		}
+		ch = c
+	case <-p.ctx.Done():
+		return nil, p.ctx.Err()
+	}
+
+	select {
+	case fb := <-ch:
+		if fb.err != nil {
+			return nil, fb.err
+		}
+		return fb, nil
+	case <-p.ctx.Done():
+		return nil, p.ctx.Err()
+	}
+}
+
+func (p *prefetcher) stop() {
+	p.cancel()
+}
+
+// call runs one RPC request on a worker slot.
+func (p *prefetcher) call(f func() error) error {
+	select {
+	case p.sem <- struct{}{}:
+	case <-p.ctx.Done():
+		return p.ctx.Err()
+	}
+	defer func() { <-p.sem }()
+
+	return f()
+}
+
+// each runs f for 0..n-1 concurrently and returns the first error.
+func (p *prefetcher) each(n int, f func(i int) error) error {
+	wg := &sync.WaitGroup{}
+	errs := make([]error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = f(i)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *prefetcher) fetch(height int64) *fetchedBlock {
+	fb := &fetchedBlock{
+		height: height,
+		txs:    make(map[string]*btcjson.TxRawResult),
+	}
+
+	err := p.call(func() (err error) {
+		fb.hash, err = p.e.node.GetBlockHash(height)
+		return
+	})
+	if err != nil {
+		fb.err = fmt.Errorf("prefetch GetBlockHash err: %s", err.Error())
+		return fb
+	}
+
+	err = p.call(func() (err error) {
+		fb.block, err = p.e.node.GetBlockVerboseBool(fb.hash)
+		return
+	})
+	if err != nil {
+		fb.err = fmt.Errorf("prefetch GetBlockVerboseBool err: %s", err.Error())
+		return fb
+	}
+
+	fb.txList = make([]*btcjson.TxRawResult, len(fb.block.Tx))
+	err = p.each(len(fb.block.Tx), func(i int) error {
+		txhash, _ := chainhash.NewHashFromStr(fb.block.Tx[i])
+		return p.call(func() (err error) {
+			fb.txList[i], err = p.e.node.GetRawTransactionVerboseBool(txhash)
+			return
+		})
+	})
+	if err != nil {
+		fb.err = fmt.Errorf("prefetch GetRawtxvBool err: %s", err.Error())
+		return fb
+	}
+
+	for _, txv := range fb.txList {
+		fb.txs[txv.Txid] = txv
+	}
+
+	p.resolveParents(fb)
+	return fb
+}
+
+// resolveParents fetches the inputs of every inscription in the block, and
+// their first inputs in turn, so decoders can find the holder without RPC.
+// Failures are left to the decoder, which will ask the node again.
+func (p *prefetcher) resolveParents(fb *fetchedBlock) {
+	frontier := make([]string, 0)
+	for _, txv := range fb.txList {
+		for _, in := range txv.Vin {
+			if _, _, err := p.e.reDecode(in); err == nil {
+				frontier = append(frontier, in.Txid)
+			}
+		}
+	}
+
+	for hop := 0; hop < parentHops && len(frontier) > 0; hop++ {
+		missing := make([]string, 0)
+		seen := make(map[string]bool)
+		for _, txid := range frontier {
+			if txid == "" || seen[txid] {
+				continue
+			}
+			if _, ok := fb.txs[txid]; !ok {
+				seen[txid] = true
+				missing = append(missing, txid)
+			}
+		}
+
+		fetched := make([]*btcjson.TxRawResult, len(missing))
+		p.each(len(missing), func(i int) error {
+			txhash, err := chainhash.NewHashFromStr(missing[i])
+			if err != nil {
+				return nil
+			}
+
+			err = p.call(func() (err error) {
+				fetched[i], err = p.e.node.GetRawTransactionVerboseBool(txhash)
+				return
+			})
+			if err != nil {
+				log.Trace("prefetch", "parent", missing[i], "err", err)
+			}
+			return nil
+		})
+
+		next := make([]string, 0)
+		for _, txid := range frontier {
+			if txv, ok := fb.txs[txid]; ok && len(txv.Vin) > 0 {
+				next = append(next, txv.Vin[0].Txid)
+			}
+		}
+
+		for _, txv := range fetched {
+			if txv == nil {
+				continue
+			}
+			fb.txs[txv.Txid] = txv
+			if len(txv.Vin) > 0 {
+				next = append(next, txv.Vin[0].Txid)
+			}
+		}
+
+		frontier = next
+	}
+}
+
+// getRawTransaction serves a transaction from the block being applied when it
+// was prefetched, and from the node otherwise.
+func (e *Explorer) getRawTransaction(txhash *chainhash.Hash) (*btcjson.TxRawResult, error) {
+	if e.resolved != nil {
+		if txv, ok := e.resolved[txhash.String()]; ok {
+			return txv, nil
+		}
+	}
+
+	txv, err := e.node.GetRawTransactionVerboseBool(txhash)
+	if err != nil {
+		return nil, nodeError(err, e.resolved != nil)
+	}
+	return txv, nil
+}
+
+// nodeError tells the answers of the node apart from failures to reach it. A
+// transaction the node does not know is final for the mempool, anything else
+// is transient and wraps CHAIN_NETWORK_ERR so the block is retried. The inputs
+// of a confirmed transaction always exist, so there an unknown one means the
+// node runs without -txindex and the scan stops instead of leaving a gap.
+func nodeError(err error, confirmed bool) error {
+	var rpcErr *btcjson.RPCError
+	if errors.As(err, &rpcErr) && rpcErr.Code == btcjson.ErrRPCNoTxInfo {
+		if !confirmed {
+			return err
+		}
+		return fmt.Errorf("%w: %s, run the node with -txindex", CHAIN_NETWORK_ERR, err.Error())
+	}
+	return fmt.Errorf("%w: %s", CHAIN_NETWORK_ERR, err.Error())
+}
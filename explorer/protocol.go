@@ -0,0 +1,139 @@
+package explorer
+
+import (
+	"dogeuni-indexer/chain"
+	"dogeuni-indexer/router"
+	"dogeuni-indexer/storage"
+	"fmt"
+	"github.com/dogecoinw/doged/btcjson"
+	"github.com/gin-gonic/gin"
+	shell "github.com/ipfs/go-ipfs-api"
+	"gorm.io/gorm"
+	"sync"
+)
+
+// ProtocolHandler is implemented by every inscription protocol the explorer indexes.
+// Handlers are stateless; the explorer they operate on is passed to every call.
+type ProtocolHandler interface {
+	// Name is the "p" field of the inscription, e.g. "drc-20".
+	Name() string
+	// Decode parses the inscription carried by tx.Vin[0] and stores its *_info
+	// row under txIndex, the position of that input in the transaction.
+	Decode(e *Explorer, tx *btcjson.TxRawResult, txIndex int, pushedData []byte, height int64) (interface{}, error)
+	// Verify checks the decoded inscription against the current state.
+	Verify(e *Explorer, inscription interface{}) error
+	// Execute applies the decoded inscription to the state.
+	Execute(e *Explorer, inscription interface{}) error
+	// Fork rolls the protocol state back to height using its revert rows.
+	Fork(e *Explorer, tx *gorm.DB, height int64) error
+	// InfoModel is the *_info model whose err_info is set when Verify or Execute fails.
+	InfoModel() interface{}
+	// InfoTables are pruned above the fork height before Fork runs.
+	InfoTables() []interface{}
+	// RevertTables are pruned above the fork height after Fork runs.
+	RevertTables() []interface{}
+	// Routes mounts the protocol's API under the /v4 group.
+	Routes(rg *gin.RouterGroup, deps *RouteDeps)
+}
+
+// WholeTxHandler is implemented by protocols whose decoders read every input
+// of the transaction themselves, like pair routers and multi-input nft/file
+// envelopes. They are dispatched once per transaction, from its first input.
+type WholeTxHandler interface {
+	WholeTx()
+}
+
+// PreForker is implemented by protocols that restore rows the Fork of an
+// earlier registered protocol reads, like drc-20 ticks a box refund deleted.
+// PreFork runs for every protocol before the first Fork.
+type PreForker interface {
+	PreFork(e *Explorer, tx *gorm.DB, height int64) error
+}
+
+// RouteDeps are the clients handed to protocol routers.
+type RouteDeps struct {
+	DBC     *storage.DBClient
+	Node    chain.ChainSource
+	Level   *storage.LevelDB
+	Ipfs    *shell.Shell
+	Pending *router.PendingRouter
+}
+
+var (
+	protocolLock  sync.RWMutex
+	protocolList  []ProtocolHandler
+	protocolIndex = make(map[string]ProtocolHandler)
+)
+
+func init() {
+	// The order matters: fork rollbacks run in registration order.
+	RegisterProtocol(drc20Handler{})
+	RegisterProtocol(meme20Handler{})
+	RegisterProtocol(pumpHandler{})
+	RegisterProtocol(swapHandler{})
+	RegisterProtocol(swapV2Handler{})
+	RegisterProtocol(wdogeHandler{})
+	RegisterProtocol(fileHandler{})
+	RegisterProtocol(nftHandler{})
+	RegisterProtocol(exchangeHandler{})
+	RegisterProtocol(stakeHandler{})
+	RegisterProtocol(boxHandler{})
+	RegisterProtocol(fileExchangeHandler{})
+	RegisterProtocol(stakeV2Handler{})
+	RegisterProtocol(crossHandler{})
+	RegisterProtocol(consensusHandler{})
+}
+
+// RegisterProtocol adds a protocol handler to the scanner dispatch. It panics if
+// a handler with the same name is already registered. Handlers of other
+// packages register from their init, like explorer/invite, and run after the
+// ones above.
+func RegisterProtocol(h ProtocolHandler) {
+	protocolLock.Lock()
+	defer protocolLock.Unlock()
+
+	if _, ok := protocolIndex[h.Name()]; ok {
+		panic(fmt.Sprintf("protocol %s already registered", h.Name()))
+	}
+
+	protocolList = append(protocolList, h)
+	protocolIndex[h.Name()] = h
+}
+
+// Protocols returns the registered handlers in registration order.
+func Protocols() []ProtocolHandler {
+	protocolLock.RLock()
+	defer protocolLock.RUnlock()
+
+	hs := make([]ProtocolHandler, len(protocolList))
+	copy(hs, protocolList)
+	return hs
+}
+
+// ProtocolNames returns the p of the registered handlers.
+func ProtocolNames() []string {
+	hs := Protocols()
+	names := make([]string, len(hs))
+	for i, h := range hs {
+		names[i] = h.Name()
+	}
+	return names
+}
+
+func findProtocol(p string) (ProtocolHandler, bool) {
+	protocolLock.RLock()
+	defer protocolLock.RUnlock()
+
+	h, ok := protocolIndex[p]
+	return h, ok
+}
+
+// DBClient returns the state database used by the explorer.
+func (e *Explorer) DBClient() *storage.DBClient {
+	return e.dbc
+}
+
+// Node returns the chain source the explorer reads blocks from.
+func (e *Explorer) Node() chain.ChainSource {
+	return e.node
+}
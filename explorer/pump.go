@@ -2,26 +2,23 @@ package explorer
 
 import (
 	"dogeuni-indexer/models"
+	"dogeuni-indexer/router"
 	"dogeuni-indexer/utils"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/dogecoinw/doged/btcjson"
-	"github.com/dogecoinw/doged/chaincfg/chainhash"
 	"github.com/dogecoinw/go-dogecoin/log"
-	"github.com/google/uuid"
+	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 	"math/big"
 )
 
-const (
-	PumpCreateFee = 500000000
-	PumpTipFee    = 10000000
-)
+func (e *Explorer) pumpDecode(tx *btcjson.TxRawResult, txIndex int, pushedData []byte, number int64) (*models.PumpInfo, error) {
 
-func (e *Explorer) pumpDecode(tx *btcjson.TxRawResult, pushedData []byte, number int64) (*models.PumpInfo, error) {
+	protocol := e.dbc.ProtocolParams(number)
 
-	err := e.dbc.DB.Where("tx_hash = ?", tx.Hash).First(&models.PumpInfo{}).Error
+	err := e.dbc.DB.Where("tx_hash = ? and tx_index = ?", tx.Hash, txIndex).First(&models.PumpInfo{}).Error
 	if !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, fmt.Errorf("pump already exist or err %s", tx.Hash)
 	}
@@ -57,36 +54,35 @@ func (e *Explorer) pumpDecode(tx *btcjson.TxRawResult, pushedData []byte, number
 		}
 	}
 
-	pump.OrderId = uuid.New().String()
 	pump.FeeTxHash = tx.Vin[0].Txid
 	pump.TxHash = tx.Hash
+	pump.TxIndex = txIndex
+	pump.OrderId = utils.OrderId(pump.TxHash, pump.TxIndex, utils.SubOpInscription)
 	pump.BlockHash = tx.BlockHash
 	pump.BlockNumber = number
 	pump.BlockTime = tx.Blocktime
-	pump.HolderAddress = tx.Vout[0].ScriptPubKey.Addresses[0]
+	pump.HolderAddress, err = OutputAddress(tx, 0)
+	if err != nil {
+		return nil, err
+	}
+
 	pump.OrderStatus = 1
 
 	if pump.Op == "deploy" {
-		pump.Tick0Id = pump.TxHash
+		pump.Tick0Id = utils.InscriptionId(pump.TxHash, pump.TxIndex)
 		if dogeDepositAmt.Cmp(big.NewInt(0)) > 0 {
 			if len(tx.Vout) < 5 {
 				return nil, fmt.Errorf("deposit op error, vout length is not 5")
 			}
 
-			if utils.Float64ToBigInt(tx.Vout[3].Value*100000000).Cmp(big.NewInt(PumpCreateFee)) < 0 {
-				return nil, fmt.Errorf("the amount of tokens is incorrect %f %s", tx.Vout[1].Value, utils.Float64ToBigInt(tx.Vout[3].Value*100000000).String())
-			}
-
-			if tx.Vout[3].ScriptPubKey.Addresses[0] != pumpCreateFeeAddress {
-				return nil, fmt.Errorf("the address is incorrect")
-			}
-
-			if utils.Float64ToBigInt(tx.Vout[4].Value*100000000).Cmp(big.NewInt(PumpTipFee)) < 0 {
-				return nil, fmt.Errorf("the amount of tokens is incorrect fee %f", tx.Vout[4].Value)
+			err = payment(tx, 3, protocol.PumpCreateFeeAddress, big.NewInt(protocol.PumpCreateFee))
+			if err != nil {
+				return nil, err
 			}
 
-			if tx.Vout[4].ScriptPubKey.Addresses[0] != pumpTipAddress {
-				return nil, fmt.Errorf("the address is incorrect")
+			err = payment(tx, 4, protocol.PumpTipAddress, big.NewInt(protocol.PumpTipFee))
+			if err != nil {
+				return nil, err
 			}
 
 		} else {
@@ -94,20 +90,14 @@ func (e *Explorer) pumpDecode(tx *btcjson.TxRawResult, pushedData []byte, number
 				return nil, fmt.Errorf("deposit op error, vout length is not 5")
 			}
 
-			if utils.Float64ToBigInt(tx.Vout[1].Value*100000000).Cmp(big.NewInt(PumpCreateFee)) < 0 {
-				return nil, fmt.Errorf("the amount of tokens is incorrect %f %s", tx.Vout[1].Value, utils.Float64ToBigInt(tx.Vout[1].Value*100000000).String())
-			}
-
-			if tx.Vout[1].ScriptPubKey.Addresses[0] != pumpCreateFeeAddress {
-				return nil, fmt.Errorf("the address is incorrect")
-			}
-
-			if utils.Float64ToBigInt(tx.Vout[2].Value*100000000).Cmp(big.NewInt(PumpTipFee)) < 0 {
-				return nil, fmt.Errorf("the amount of tokens is incorrect fee %f", tx.Vout[2].Value)
+			err = payment(tx, 1, protocol.PumpCreateFeeAddress, big.NewInt(protocol.PumpCreateFee))
+			if err != nil {
+				return nil, err
 			}
 
-			if tx.Vout[2].ScriptPubKey.Addresses[0] != pumpTipAddress {
-				return nil, fmt.Errorf("the address is incorrect")
+			err = payment(tx, 2, protocol.PumpTipAddress, big.NewInt(protocol.PumpTipFee))
+			if err != nil {
+				return nil, err
 			}
 		}
 	}
@@ -119,12 +109,9 @@ func (e *Explorer) pumpDecode(tx *btcjson.TxRawResult, pushedData []byte, number
 				return nil, fmt.Errorf("deposit op error, vout length is not 5")
 			}
 
-			if utils.Float64ToBigInt(tx.Vout[3].Value*100000000).Cmp(big.NewInt(PumpTipFee)) < 0 {
-				return nil, fmt.Errorf("the amount of tokens is incorrect fee %f", tx.Vout[4].Value)
-			}
-
-			if tx.Vout[3].ScriptPubKey.Addresses[0] != pumpTipAddress {
-				return nil, fmt.Errorf("the address is incorrect")
+			err = payment(tx, 3, protocol.PumpTipAddress, big.NewInt(protocol.PumpTipFee))
+			if err != nil {
+				return nil, err
 			}
 
 		} else {
@@ -132,31 +119,29 @@ func (e *Explorer) pumpDecode(tx *btcjson.TxRawResult, pushedData []byte, number
 				return nil, fmt.Errorf("deposit op error, vout length is not 5")
 			}
 
-			if utils.Float64ToBigInt(tx.Vout[1].Value*100000000).Cmp(big.NewInt(PumpTipFee)) < 0 {
-				return nil, fmt.Errorf("the amount of tokens is incorrect fee %f", tx.Vout[2].Value)
-			}
-
-			if tx.Vout[1].ScriptPubKey.Addresses[0] != pumpTipAddress {
-				return nil, fmt.Errorf("the address is incorrect")
+			err = payment(tx, 1, protocol.PumpTipAddress, big.NewInt(protocol.PumpTipFee))
+			if err != nil {
+				return nil, err
 			}
 		}
 	}
 
-	txhash0, _ := chainhash.NewHashFromStr(pump.FeeTxHash)
-	txRawResult0, err := e.node.GetRawTransactionVerboseBool(txhash0)
+	_, txRawResult0, err := e.SpentOutput(tx, 0)
 	if err != nil {
-		return nil, CHAIN_NETWORK_ERR
+		return nil, err
 	}
 
-	pump.FeeAddress = txRawResult0.Vout[pump.FeeTxIndex].ScriptPubKey.Addresses[0]
+	pump.FeeAddress, err = OutputAddress(txRawResult0, int(pump.FeeTxIndex))
+	if err != nil {
+		return nil, err
+	}
 
-	txhash1, _ := chainhash.NewHashFromStr(txRawResult0.Vin[0].Txid)
-	txRawResult1, err := e.node.GetRawTransactionVerboseBool(txhash1)
+	previous, _, err := e.InputAddress(txRawResult0, 0)
 	if err != nil {
-		return nil, CHAIN_NETWORK_ERR
+		return nil, err
 	}
 
-	if pump.HolderAddress != txRawResult1.Vout[txRawResult0.Vin[0].Vout].ScriptPubKey.Addresses[0] {
+	if pump.HolderAddress != previous {
 		return nil, fmt.Errorf("the address is not the same as the previous transaction")
 	}
 
@@ -170,27 +155,16 @@ func (e *Explorer) pumpDecode(tx *btcjson.TxRawResult, pushedData []byte, number
 			return nil, fmt.Errorf("the number of outputs is incorrect")
 		}
 
-		fee := big.NewInt(0)
-		fee.Mul(dogeDepositAmt, big.NewInt(3))
-		fee.Div(fee, big.NewInt(1000))
-		if fee.Cmp(big.NewInt(50000000)) == -1 {
-			fee = big.NewInt(50000000)
-		}
+		fee := depositFee(dogeDepositAmt)
 
-		if utils.Float64ToBigInt(tx.Vout[1].Value*100000000).Cmp(dogeDepositAmt) < 0 {
-			return nil, fmt.Errorf("the amount of tokens is incorrect %f %s", tx.Vout[1].Value, utils.Float64ToBigInt(tx.Vout[1].Value*100000000).String())
-		}
-
-		if tx.Vout[1].ScriptPubKey.Addresses[0] != wdogeCoolAddress {
-			return nil, fmt.Errorf("the address is incorrect")
-		}
-
-		if utils.Float64ToBigInt(tx.Vout[2].Value*100000000).Cmp(fee) < 0 {
-			return nil, fmt.Errorf("the amount of tokens is incorrect fee %f", tx.Vout[2].Value)
+		err = payment(tx, 1, protocol.WDogeCoolAddress, dogeDepositAmt)
+		if err != nil {
+			return nil, err
 		}
 
-		if tx.Vout[2].ScriptPubKey.Addresses[0] != wdogeFeeAddress {
-			return nil, fmt.Errorf("the address is incorrect")
+		err = payment(tx, 2, protocol.WDogeFeeAddress, fee)
+		if err != nil {
+			return nil, err
 		}
 	}
 
@@ -312,3 +286,48 @@ func (e *Explorer) pumpFork(tx *gorm.DB, height int64) error {
 
 	return nil
 }
+
+type pumpHandler struct{}
+
+func (pumpHandler) Name() string {
+	return "pump"
+}
+
+func (pumpHandler) Decode(e *Explorer, tx *btcjson.TxRawResult, txIndex int, pushedData []byte, height int64) (interface{}, error) {
+	return e.pumpDecode(tx, txIndex, pushedData, height)
+}
+
+func (pumpHandler) Verify(e *Explorer, inscription interface{}) error {
+	// verified inside the execute transaction
+	return nil
+}
+
+func (pumpHandler) Execute(e *Explorer, inscription interface{}) error {
+	return e.executePump(inscription.(*models.PumpInfo))
+}
+
+func (pumpHandler) Fork(e *Explorer, tx *gorm.DB, height int64) error {
+	return e.pumpFork(tx, height)
+}
+
+func (pumpHandler) InfoModel() interface{} {
+	return &models.PumpInfo{}
+}
+
+func (pumpHandler) InfoTables() []interface{} {
+	return []interface{}{&models.PumpInfo{}}
+}
+
+func (pumpHandler) RevertTables() []interface{} {
+	return []interface{}{&models.PumpRevert{}, &models.PumpInviteRewardRevert{}}
+}
+
+func (pumpHandler) Routes(rg *gin.RouterGroup, deps *RouteDeps) {
+	pumpRouter := router.NewPumpRouter(deps.DBC, deps.Node)
+	rg.POST("/pump/order", deps.Pending.Order("pump", pumpRouter.Order))
+	rg.POST("/pump/mergeorder", pumpRouter.MergeOrder)
+	rg.POST("/pump/liquidity", pumpRouter.Liquidity)
+	rg.POST("/pump/board", pumpRouter.Board)
+	rg.POST("/pump/k", pumpRouter.K)
+	rg.POST("/pump/king", pumpRouter.King)
+}
@@ -0,0 +1,335 @@
+package explorer
+
+import (
+	"dogeuni-indexer/models"
+	"dogeuni-indexer/storage"
+	"errors"
+	"fmt"
+	"github.com/dogecoinw/go-dogecoin/log"
+	"gorm.io/gorm"
+	"reflect"
+)
+
+// syntheticWDogeOps are the wdoge_info rows pair routers and pumps write while
+// executing, a replay writes them again.
+var syntheticWDogeOps = []string{"deposit-swap", "withdraw-swap", "deposit-pump", "withdraw-pump"}
+
+// ReplayLoader is implemented by protocols whose decoded inscription is more
+// than one *_info row, it reads back what Decode returned.
+type ReplayLoader interface {
+	LoadReplay(e *Explorer, txHash string) (interface{}, error)
+}
+
+// replayInscription is a stored inscription to apply again.
+type replayInscription struct {
+	handler ProtocolHandler
+	txHash  string
+	txIndex int
+}
+
+// Reindex rolls the derived state back to from-1 and applies the stored *_info
+// rows of every indexed block from on again, in block order, without the node.
+// The candles that contain the block of from are rebuilt from the trade log.
+// Trades indexed before the log existed are only in the candles, so Reindex
+// refuses unless widen lets it replay from the first block of those candles.
+// The journal holds from until the replay ends, a scanner started after an
+// interrupted replay rolls back and indexes those blocks from the node.
+func (e *Explorer) Reindex(from int64, widen bool) error {
+	maxHeight := int64(0)
+	err := e.dbc.DB.Model(&models.Block{}).Select("max(block_number)").Scan(&maxHeight).Error
+	if err != nil {
+		return fmt.Errorf("Reindex max block err: %s", err.Error())
+	}
+
+	if from > maxHeight {
+		return fmt.Errorf("Reindex from %d is above the last indexed block %d", from, maxHeight)
+	}
+
+	block := &models.Block{}
+	err = e.dbc.DB.Where("block_number = ?", from).First(block).Error
+	if err != nil {
+		return fmt.Errorf("Reindex block %d err: %s", from, err.Error())
+	}
+
+	since := block.BlockTime
+	logged, err := e.dbc.SummaryLogged(e.dbc.DB, from-1, since)
+	if err != nil {
+		return fmt.Errorf("Reindex SummaryLogged err: %s", err.Error())
+	}
+
+	replay := int64(0)
+	if !logged {
+		window := from
+		err = e.dbc.DB.Model(&models.Block{}).Select("coalesce(min(block_number), ?)", from).Where("block_number < ? and block_time >= ?", from, storage.CandleWindow(since)).Scan(&window).Error
+		if err != nil {
+			return fmt.Errorf("Reindex candle window err: %s", err.Error())
+		}
+
+		if !widen {
+			return fmt.Errorf("Reindex the candles of block %d hold trades indexed before the trade log, reindex from %d or pass --widen", from, window)
+		}
+
+		// the trades of the candles are replayed from their first block
+		log.Info("reindex", "from", from, "candles from", window)
+		from = window
+		replay = since
+	}
+
+	unfinished, ok, err := e.dbc.JournalUnfinished()
+	if err != nil {
+		return fmt.Errorf("Reindex JournalUnfinished err: %s", err.Error())
+	}
+
+	if ok && unfinished < from {
+		return fmt.Errorf("Reindex block %d is unfinished, reindex from it or below", unfinished)
+	}
+
+	ordered := maxHeight + 1
+	err = e.dbc.DB.Model(&models.BlockInscription{}).Select("coalesce(min(block_number), ?)", ordered).Scan(&ordered).Error
+	if err != nil {
+		return fmt.Errorf("Reindex min block inscription err: %s", err.Error())
+	}
+
+	if from < ordered {
+		log.Warn("reindex", "blocks without inscription order", fmt.Sprintf("%d-%d", from, ordered-1), "order", "protocol registration")
+	}
+
+	missing, ok, err := e.dbc.StateRootMissing()
+	if err != nil {
+		return fmt.Errorf("Reindex StateRootMissing err: %s", err.Error())
+	}
+
+	if ok && missing < from {
+		log.Warn("reindex", "state roots stay empty", fmt.Sprintf("block %d has none", missing), "backfill", fmt.Sprintf("reindex --from %d", missing))
+	}
+
+	log.Info("reindex", "from", from, "to", maxHeight)
+
+	tx := e.dbc.DB.Begin()
+	err = e.rollbackState(tx, from-1, since)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = e.dbc.WithTx(tx).JournalBegin(from, "")
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("Reindex JournalBegin err: %s", err.Error())
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		return fmt.Errorf("Reindex rollback commit err: %s", err.Error())
+	}
+
+	e.dbc.SetReplay(replay)
+	defer e.dbc.SetReplay(0)
+
+	dbc := e.dbc
+	defer func() { e.dbc = dbc }()
+
+	for height := from; height <= maxHeight; height++ {
+		e.dbc = dbc
+		if e.ctx.Err() != nil {
+			return fmt.Errorf("Reindex stopped at %d", height)
+		}
+
+		block := &models.Block{}
+		err = e.dbc.DB.Where("block_number = ?", height).First(block).Error
+		if err != nil {
+			return fmt.Errorf("Reindex block %d err: %s", height, err.Error())
+		}
+
+		e.currentHeight = height
+		e.dbc = dbc.AtBlockTime(block.BlockTime)
+
+		err = e.dbc.ScheduledTasks(height)
+		if err != nil {
+			return fmt.Errorf("Reindex ScheduledTasks err: %s", err.Error())
+		}
+
+		var list []*replayInscription
+		if height < ordered {
+			list, err = e.replayByProtocol(height)
+		} else {
+			list, err = e.replayByOrder(height)
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, ins := range list {
+			inscription, err := e.replayLoad(ins)
+			if err != nil {
+				return err
+			}
+			e.apply(ins.handler, inscription, ins.txHash, ins.txIndex)
+		}
+
+		err = e.dbc.StateRootUpdate(height)
+		if err != nil {
+			return fmt.Errorf("Reindex StateRootUpdate err: %s", err.Error())
+		}
+
+		log.Info("reindex", "height", height, "inscriptions", len(list))
+	}
+
+	return dbc.JournalClear(dbc.DB, from)
+}
+
+// Rescan rolls the index back to from-1, so the next start decodes the blocks
+// from on again from the node. It fills the gaps a node without -txindex left
+// before unknown inputs stopped the scan, from 0 starts at the first of them.
+// It returns the first block to rescan, 0 when there is none.
+func (e *Explorer) Rescan(from int64) (int64, error) {
+	if from == 0 {
+		height, ok, err := e.dbc.DecodeFailureNoTx()
+		if err != nil {
+			return 0, fmt.Errorf("Rescan DecodeFailureNoTx err: %s", err.Error())
+		}
+		if !ok {
+			return 0, nil
+		}
+		from = height
+	}
+
+	block := &models.Block{}
+	err := e.dbc.DB.Where("block_number = ?", from).First(block).Error
+	if err != nil {
+		return 0, fmt.Errorf("Rescan block %d err: %s", from, err.Error())
+	}
+
+	logged, err := e.dbc.SummaryLogged(e.dbc.DB, from-1, block.BlockTime)
+	if err != nil {
+		return 0, fmt.Errorf("Rescan SummaryLogged err: %s", err.Error())
+	}
+
+	if !logged {
+		return 0, fmt.Errorf("Rescan the candles of block %d hold trades indexed before the trade log, run reindex --from %d --widen first", from, from)
+	}
+
+	return from, e.Rollback(from - 1)
+}
+
+// rollbackState rolls the derived state back to height like fork does, but
+// keeps the decoded *_info rows so they can be applied again. The rows written
+// while executing, like stake rewards or synthetic wdoge orders, are dropped,
+// and the candles from the ones that contain since are rebuilt from the
+// trades logged up to height.
+func (e *Explorer) rollbackState(tx *gorm.DB, height int64, since int64) error {
+	for _, h := range Protocols() {
+		model := reflect.TypeOf(h.InfoModel())
+		for _, table := range h.InfoTables() {
+			if reflect.TypeOf(table) == model {
+				continue
+			}
+
+			err := tx.Where("block_number > ?", height).Delete(table).Error
+			if err != nil {
+				return fmt.Errorf("Delete %s derived rows error: %v", h.Name(), err)
+			}
+		}
+	}
+
+	err := tx.Where("block_number > ? and op in ?", height, syntheticWDogeOps).Delete(&models.WDogeInfo{}).Error
+	if err != nil {
+		return fmt.Errorf("Delete synthetic wdoge error: %v", err)
+	}
+
+	err = e.dbc.SummaryRebuild(tx, height, since)
+	if err != nil {
+		return fmt.Errorf("Rebuild candles error: %v", err)
+	}
+
+	err = e.forkProtocols(tx, height)
+	if err != nil {
+		return err
+	}
+
+	return e.delRevert(tx, height)
+}
+
+// replayByOrder lists the inscriptions of height in the order the scanner
+// applied them.
+func (e *Explorer) replayByOrder(height int64) ([]*replayInscription, error) {
+	rows, err := e.dbc.BlockInscriptions(height)
+	if err != nil {
+		return nil, fmt.Errorf("Reindex BlockInscriptions err: %s", err.Error())
+	}
+
+	list := make([]*replayInscription, 0, len(rows))
+	for _, row := range rows {
+		h, ok := findProtocol(row.P)
+		if !ok {
+			return nil, fmt.Errorf("Reindex protocol %s not found", row.P)
+		}
+		list = append(list, &replayInscription{handler: h, txHash: row.TxHash, txIndex: row.TxIndex})
+	}
+	return list, nil
+}
+
+// replayByProtocol lists the inscriptions of a block indexed before their
+// order was recorded, protocol by protocol in registration order.
+func (e *Explorer) replayByProtocol(height int64) ([]*replayInscription, error) {
+	list := make([]*replayInscription, 0)
+	for _, h := range Protocols() {
+		rows := make([]*struct {
+			TxHash  string
+			TxIndex int
+		}, 0)
+
+		query := e.dbc.DB.Model(h.InfoModel()).Where("block_number = ?", height)
+		if _, whole := h.(WholeTxHandler); whole {
+			query = query.Select("tx_hash, min(tx_index) as tx_index").Group("tx_hash").Order("min(id)")
+		} else {
+			query = query.Select("tx_hash, tx_index").Order("id")
+		}
+
+		err := query.Scan(&rows).Error
+		if err != nil {
+			return nil, fmt.Errorf("Reindex %s rows err: %s", h.Name(), err.Error())
+		}
+
+		for _, row := range rows {
+			list = append(list, &replayInscription{handler: h, txHash: row.TxHash, txIndex: row.TxIndex})
+		}
+	}
+	return list, nil
+}
+
+// replayLoad resets the *_info row of ins to its decoded state and reads it
+// back the way Decode returned it.
+func (e *Explorer) replayLoad(ins *replayInscription) (interface{}, error) {
+	h := ins.handler
+	_, whole := h.(WholeTxHandler)
+
+	query := e.dbc.DB.Model(h.InfoModel()).Where("tx_hash = ?", ins.txHash)
+	if !whole {
+		query = query.Where("tx_index = ?", ins.txIndex)
+	}
+
+	err := query.Updates(map[string]interface{}{"order_status": 1, "err_info": ""}).Error
+	if err != nil {
+		return nil, fmt.Errorf("Reindex reset %s %s err: %s", h.Name(), ins.txHash, err.Error())
+	}
+
+	if loader, ok := h.(ReplayLoader); ok {
+		return loader.LoadReplay(e, ins.txHash)
+	}
+
+	inscription := reflect.New(reflect.TypeOf(h.InfoModel()).Elem()).Interface()
+	query = e.dbc.DB.Where("tx_hash = ?", ins.txHash)
+	if !whole {
+		query = query.Where("tx_index = ?", ins.txIndex)
+	}
+
+	err = query.First(inscription).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("Reindex %s %s:%d not found", h.Name(), ins.txHash, ins.txIndex)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Reindex load %s %s err: %s", h.Name(), ins.txHash, err.Error())
+	}
+	return inscription, nil
+}
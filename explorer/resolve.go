@@ -0,0 +1,146 @@
+package explorer
+
+import (
+	"errors"
+	"fmt"
+	"github.com/dogecoinw/doged/btcjson"
+	"github.com/dogecoinw/doged/chaincfg/chainhash"
+	"math/big"
+)
+
+var (
+	ErrNoInput   = errors.New("no such input")
+	ErrNoOutput  = errors.New("no such output")
+	ErrNoAddress = errors.New("script has no single address")
+	ErrBadValue  = errors.New("value is not a valid amount")
+)
+
+// ScriptError tells which input or output of a transaction the decoders could
+// not resolve. Err is one of the Err* values above.
+type ScriptError struct {
+	TxHash string
+	Index  int
+	Input  bool
+	Type   string // scriptPubKey type given by the node, e.g. nulldata, multisig
+	Err    error
+}
+
+func (e *ScriptError) Error() string {
+	kind := "output"
+	if e.Input {
+		kind = "input"
+	}
+	if e.Type != "" {
+		return fmt.Sprintf("tx %s %s %d (%s): %s", e.TxHash, kind, e.Index, e.Type, e.Err.Error())
+	}
+	return fmt.Sprintf("tx %s %s %d: %s", e.TxHash, kind, e.Index, e.Err.Error())
+}
+
+func (e *ScriptError) Unwrap() error {
+	return e.Err
+}
+
+// Output is a transaction output as the decoders read it.
+type Output struct {
+	Address string // the one address of the script, empty when it has none or several
+	Value   int64  // koinu
+	Type    string
+}
+
+// output resolves output index of tx. Outputs without a single address, like
+// OP_RETURN or bare multisig, resolve with an empty Address.
+func output(tx *btcjson.TxRawResult, index int) (*Output, error) {
+	if index < 0 || index >= len(tx.Vout) {
+		return nil, &ScriptError{TxHash: tx.Txid, Index: index, Err: ErrNoOutput}
+	}
+
+	vout := tx.Vout[index]
+	value, err := koinu(tx, index)
+	if err != nil {
+		return nil, &ScriptError{TxHash: tx.Txid, Index: index, Type: vout.ScriptPubKey.Type, Err: ErrBadValue}
+	}
+
+	out := &Output{Value: value, Type: vout.ScriptPubKey.Type}
+	if len(vout.ScriptPubKey.Addresses) == 1 {
+		out.Address = vout.ScriptPubKey.Addresses[0]
+	}
+	return out, nil
+}
+
+// OutputAddress is the owner of output index of tx.
+func OutputAddress(tx *btcjson.TxRawResult, index int) (string, error) {
+	out, err := output(tx, index)
+	if err != nil {
+		return "", err
+	}
+
+	if out.Address == "" {
+		return "", &ScriptError{TxHash: tx.Txid, Index: index, Type: out.Type, Err: ErrNoAddress}
+	}
+	return out.Address, nil
+}
+
+// OutputValue is the value of output index of tx in koinu.
+func OutputValue(tx *btcjson.TxRawResult, index int) (int64, error) {
+	out, err := output(tx, index)
+	if err != nil {
+		return 0, err
+	}
+	return out.Value, nil
+}
+
+// SpentOutput resolves the output spent by input index of tx and returns it
+// with the transaction it belongs to. Node errors wrap CHAIN_NETWORK_ERR like
+// those of getRawTransaction.
+func (e *Explorer) SpentOutput(tx *btcjson.TxRawResult, index int) (*Output, *btcjson.TxRawResult, error) {
+	if index < 0 || index >= len(tx.Vin) || tx.Vin[index].IsCoinBase() {
+		return nil, nil, &ScriptError{TxHash: tx.Txid, Index: index, Input: true, Err: ErrNoInput}
+	}
+
+	in := tx.Vin[index]
+	txhash, err := chainhash.NewHashFromStr(in.Txid)
+	if err != nil {
+		return nil, nil, &ScriptError{TxHash: tx.Txid, Index: index, Input: true, Err: ErrNoInput}
+	}
+
+	parent, err := e.getRawTransaction(txhash)
+	if err != nil {
+		return nil, nil, fmt.Errorf("GetRawTransactionVerboseBool err: %w", err)
+	}
+
+	out, err := output(parent, int(in.Vout))
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, parent, nil
+}
+
+// InputAddress is the owner of the output spent by input index of tx.
+func (e *Explorer) InputAddress(tx *btcjson.TxRawResult, index int) (string, *btcjson.TxRawResult, error) {
+	out, parent, err := e.SpentOutput(tx, index)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if out.Address == "" {
+		return "", nil, &ScriptError{TxHash: parent.Txid, Index: int(tx.Vin[index].Vout), Type: out.Type, Err: ErrNoAddress}
+	}
+	return out.Address, parent, nil
+}
+
+// payment checks that output index of tx pays at least min koinu to address.
+func payment(tx *btcjson.TxRawResult, index int, address string, min *big.Int) error {
+	out, err := output(tx, index)
+	if err != nil {
+		return err
+	}
+
+	if big.NewInt(out.Value).Cmp(min) < 0 {
+		return fmt.Errorf("the amount of tokens is incorrect %d < %s", out.Value, min.String())
+	}
+
+	if out.Address != address {
+		return fmt.Errorf("the address is incorrect")
+	}
+	return nil
+}
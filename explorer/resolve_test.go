@@ -0,0 +1,95 @@
+package explorer
+
+import (
+	"errors"
+	"github.com/dogecoinw/doged/btcjson"
+	"math/big"
+	"testing"
+)
+
+func resolveTestTx(vout ...btcjson.Vout) *btcjson.TxRawResult {
+	return &btcjson.TxRawResult{
+		Txid: "resolve",
+		Hash: "resolve",
+		Vin:  []btcjson.Vin{{Txid: "0000000000000000000000000000000000000000000000000000000000000001"}},
+		Vout: vout,
+	}
+}
+
+func pay(address string, value float64) btcjson.Vout {
+	return btcjson.Vout{Value: value, ScriptPubKey: btcjson.ScriptPubKeyResult{Type: "pubkeyhash", Addresses: []string{address}}}
+}
+
+func TestOutputResolve(t *testing.T) {
+	nulldata := btcjson.Vout{ScriptPubKey: btcjson.ScriptPubKeyResult{Type: "nulldata"}}
+	multisig := btcjson.Vout{Value: 0.001, ScriptPubKey: btcjson.ScriptPubKeyResult{Type: "multisig", Addresses: []string{stakeCreator, stakeHolder}}}
+	tx := resolveTestTx(pay(stakeCreator, 1.1), nulldata, multisig)
+
+	out, err := output(tx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Address != stakeCreator || out.Value != 110000000 || out.Type != "pubkeyhash" {
+		t.Fatalf("output %+v", out)
+	}
+
+	out, err = output(tx, 1)
+	if err != nil || out.Address != "" || out.Type != "nulldata" {
+		t.Fatalf("nulldata %+v %v", out, err)
+	}
+
+	for _, index := range []int{1, 2} {
+		_, err = OutputAddress(tx, index)
+		script := &ScriptError{}
+		if !errors.Is(err, ErrNoAddress) || !errors.As(err, &script) || script.Index != index {
+			t.Fatalf("output %d: %v", index, err)
+		}
+	}
+
+	_, err = OutputValue(tx, 3)
+	if !errors.Is(err, ErrNoOutput) {
+		t.Fatalf("out of range: %v", err)
+	}
+
+	err = payment(tx, 0, stakeCreator, big.NewInt(110000000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payment(tx, 0, stakeCreator, big.NewInt(110000001)) == nil || payment(tx, 0, stakeHolder, big.NewInt(1)) == nil {
+		t.Fatal("payment should check the amount and the address")
+	}
+}
+
+func TestDecodeNonStandardOutput(t *testing.T) {
+	e := newIndexTestExplorer(t)
+
+	tx := resolveTestTx(btcjson.Vout{Value: 0.001, ScriptPubKey: btcjson.ScriptPubKeyResult{Type: "nulldata"}})
+	data := []byte(`{"p":"drc-20","op":"deploy","tick":"RSLV","max":"1000","lim":"10"}`)
+
+	_, err := e.drc20Decode(tx, 0, data, 100)
+	if !errors.Is(err, ErrNoAddress) {
+		t.Fatalf("decode err %v", err)
+	}
+
+	_, _, err = e.SpentOutput(resolveTestTx(), 1)
+	if !errors.Is(err, ErrNoInput) {
+		t.Fatalf("spent output err %v", err)
+	}
+}
+
+func TestUnknownInputStopsBlockScan(t *testing.T) {
+	tx := resolveTestTx(pay(stakeHolder, 0.001))
+	e := &Explorer{node: mempoolSource{txs: map[string]*btcjson.TxRawResult{}}}
+
+	_, _, err := e.SpentOutput(tx, 0)
+	if err == nil || errors.Is(err, CHAIN_NETWORK_ERR) {
+		t.Fatalf("mempool SpentOutput err = %v, want a final error", err)
+	}
+
+	// a confirmed transaction always spends known outputs
+	e.resolved = map[string]*btcjson.TxRawResult{}
+	_, _, err = e.SpentOutput(tx, 0)
+	if !errors.Is(err, CHAIN_NETWORK_ERR) {
+		t.Fatalf("block SpentOutput err = %v, want CHAIN_NETWORK_ERR", err)
+	}
+}
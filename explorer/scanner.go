@@ -2,30 +2,25 @@ package explorer
 
 import (
 	"context"
+	"dogeuni-indexer/chain"
 	"dogeuni-indexer/config"
+	"dogeuni-indexer/metrics"
 	"dogeuni-indexer/models"
 	"dogeuni-indexer/storage"
+	"dogeuni-indexer/utils"
 	"errors"
 	"fmt"
-	"github.com/dogecoinw/doged/chaincfg/chainhash"
-	"github.com/dogecoinw/doged/rpcclient"
+	"github.com/dogecoinw/doged/btcjson"
 	"github.com/dogecoinw/go-dogecoin/log"
-	"github.com/google/uuid"
 	shell "github.com/ipfs/go-ipfs-api"
 	"math/big"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 const (
 	startInterval = 3 * time.Second
-
-	wdogeFeeAddress  = "D86Dc4n49LZDiXvB41ds2XaDAP1BFjP1qy"
-	wdogeCoolAddress = "DKMyk8cfSTGfnCVXfmo8gXta9F6gziu7Z5"
-	nftFeeAddress    = "DBFQmJ5oGCgtnDVxUU7xEraztpEyqJHdxz"
-
-	pumpCreateFeeAddress = "DJ9wVHBFnbcZUtfWdHWPEnijdxz1CABPUY"
-	pumpTipAddress       = "DSPAZ6cZC7ShL63UFKPgs4vBGrbpHBwWQG"
 )
 
 var (
@@ -34,29 +29,56 @@ var (
 
 type Explorer struct {
 	config        *config.Config
-	node          *rpcclient.Client
+	node          chain.ChainSource
 	dbc           *storage.DBClient
 	ipfs          *shell.Shell
 	verify        *Verifys
 	currentHeight int64
 
+	// resolved holds the transactions prefetched for the block being applied.
+	resolved map[string]*btcjson.TxRawResult
+
+	// notify wakes the scanner up ahead of the polling ticker.
+	notify chan struct{}
+	// synced is set when the last scan reached the chain tip.
+	synced bool
+	// mempoolSeen holds the mempool txs already decoded.
+	mempoolSeen map[string]bool
+	// halted holds a haltReason once a reorg went past the depth limit,
+	// scanning stops.
+	halted atomic.Value
+	// forking is 1 while a reorg or an unfinished block is rolled back.
+	forking int32
+
 	ctx context.Context
 	wg  *sync.WaitGroup
 }
 
-func NewExplorer(ctx context.Context, wg *sync.WaitGroup, rpcClient *rpcclient.Client, dbc *storage.DBClient, ipfs *shell.Shell, currentHeight int64) *Explorer {
+func NewExplorer(ctx context.Context, wg *sync.WaitGroup, cfg *config.Config, node chain.ChainSource, dbc *storage.DBClient, ipfs *shell.Shell) *Explorer {
 	exp := &Explorer{
-		node:          rpcClient,
+		config:        cfg,
+		node:          node,
 		dbc:           dbc,
 		ipfs:          ipfs,
 		verify:        NewVerifys(dbc),
-		currentHeight: currentHeight,
+		currentHeight: cfg.Explorer.FromBlock,
+		notify:        make(chan struct{}, 1),
+		mempoolSeen:   make(map[string]bool),
 		ctx:           ctx,
 		wg:            wg,
 	}
 	return exp
 }
 
+// Notify asks the scanner to look for new blocks now. It never blocks, a
+// pending wake-up already covers any number of new blocks.
+func (e *Explorer) Notify() {
+	select {
+	case e.notify <- struct{}{}:
+	default:
+	}
+}
+
 func (e *Explorer) Start() {
 
 	defer e.wg.Done()
@@ -70,14 +92,25 @@ func (e *Explorer) Start() {
 		}
 	}
 
+	err := e.restoreHalt()
+	if err != nil {
+		log.Error("explorer", "restoreHalt", err.Error())
+	}
+
+	if e.config.Chain.Notify == "zmq" {
+		go e.subscribeBlocks(e.config.Chain.ZmqBlock)
+	}
+
+	// the ticker stays as a fallback in case a notification is lost
 	startTicker := time.NewTicker(startInterval)
+	e.Notify()
 out:
 	for {
 		select {
 		case <-startTicker.C:
-			if err := e.scan(); err != nil {
-				log.Error("explorer", "Start", err.Error())
-			}
+			e.round()
+		case <-e.notify:
+			e.round()
 		case <-e.ctx.Done():
 			log.Warn("explorer", "Stop", "Done")
 			break out
@@ -85,273 +118,224 @@ out:
 	}
 }
 
+// haltReason wraps the error halted holds, atomic.Value keeps one type.
+type haltReason struct {
+	err error
+}
+
+// Halted returns why the scanner stopped, nil while it runs.
+func (e *Explorer) Halted() error {
+	if reason, ok := e.halted.Load().(*haltReason); ok {
+		return reason.err
+	}
+	return nil
+}
+
+// Forking reports whether a rollback is under way, the state is then ahead of
+// the chain the index follows.
+func (e *Explorer) Forking() bool {
+	return atomic.LoadInt32(&e.forking) == 1
+}
+
+// round indexes the new blocks and, once at the tip, the mempool.
+func (e *Explorer) round() {
+	if halted := e.Halted(); halted != nil {
+		log.Error("explorer", "halted", halted.Error())
+		return
+	}
+
+	if err := e.scan(); err != nil {
+		log.Error("explorer", "Start", err.Error())
+		return
+	}
+
+	if e.config.Explorer.Mempool && e.synced && e.ctx.Err() == nil {
+		if err := e.scanMempool(); err != nil {
+			log.Error("explorer", "Start", err.Error())
+		}
+	}
+}
+
 func (e *Explorer) scan() error {
 
+	// a block left half applied by the previous round or process goes first
+	err := e.recoverBlock()
+	if err != nil {
+		return fmt.Errorf("scan recoverBlock err: %s", err.Error())
+	}
+
 	blockCount, err := e.node.GetBlockCount()
 	if err != nil {
 		return fmt.Errorf("scan GetBlockCount err: %s", err.Error())
 	}
 
+	chainCount := blockCount
+	metrics.SetHeights(e.currentHeight-1, chainCount)
+
+	e.synced = false
+
 	temp := int64(0)
-	if blockCount-e.currentHeight > 100 {
+	capped := blockCount-e.currentHeight > 100
+	if capped {
 		temp = 100
+		// more blocks are waiting, go again without waiting for the ticker
+		defer e.Notify()
 	} else {
 		temp = blockCount - e.currentHeight
 	}
 
 	blockCount = e.currentHeight + temp
 
+	if e.currentHeight >= blockCount {
+		e.synced = true
+		return nil
+	}
+
+	pf := newPrefetcher(e, e.currentHeight, blockCount)
+	defer pf.stop()
+
+	dbc := e.dbc
+	defer func() { e.dbc = dbc }()
+
 	for ; e.currentHeight < blockCount; e.currentHeight++ {
-		err := e.forkBack()
-		if err != nil {
-			return fmt.Errorf("scan forkBack err: %s", err.Error())
-		}
+		e.dbc = dbc
 
-		blockHash, err := e.node.GetBlockHash(e.currentHeight)
-		if err != nil {
-			return fmt.Errorf("scan GetBlockHash err: %s", err.Error())
+		// a stop waits for the block being applied, the next one is left
+		if e.ctx.Err() != nil {
+			return nil
 		}
 
-		block, err := e.node.GetBlockVerboseBool(blockHash)
+		fb, err := pf.next()
 		if err != nil {
-			return fmt.Errorf("scan GetBlockVerboseBool err: %s", err.Error())
+			return fmt.Errorf("scan prefetch err: %s", err.Error())
 		}
 
-		log.Info("explorer", "scanning start ", e.currentHeight, "txs", len(block.Tx))
+		start := time.Now()
 
-		err = e.dbc.ScheduledTasks(e.currentHeight)
+		forked, err := e.forkBack(fb.block)
 		if err != nil {
-			return fmt.Errorf("scan ScheduledTasks err: %s", err.Error())
+			return fmt.Errorf("scan forkBack err: %s", err.Error())
 		}
 
-		for _, tx := range block.Tx {
-
-			txhash, _ := chainhash.NewHashFromStr(tx)
-			txv, err := e.node.GetRawTransactionVerboseBool(txhash)
-			if err != nil {
-				return fmt.Errorf("scan GetRawtxvBool err: %s", err.Error())
-			}
-
-			decode, pushedData, err := e.reDecode(txv.Vin[0])
-			if err != nil {
-				log.Trace("scanning", "verifyReDecode", err, "txhash", txv.Txid)
-				continue
-			}
-
-			switch decode.P {
-			case "drc-20":
-				drc20, err := e.drc20Decode(txv, pushedData, e.currentHeight)
-				if err != nil {
-					log.Error("scanning", "drc20Decode", err, "txhash", txv.Txid)
-					continue
-				}
-
-				err = e.executeDrc20(drc20)
-				if err != nil {
-					e.dbc.DB.Model(&models.Drc20Info{}).Where("tx_hash = ?", drc20.TxHash).Update("err_info", err.Error())
-					continue
-				}
-
-			case "pair-v1":
-
-				swaps, err := e.swapRouterDecode(txv, e.currentHeight)
-				if err != nil {
-					log.Error("scanning", "swapRouterDecode", err, "txhash", tx)
-					continue
-				}
-
-				err = e.executePairV1(swaps)
-				if err != nil {
-					e.dbc.DB.Model(&models.SwapInfo{}).Where("tx_hash = ?", tx).Update("err_info", err.Error())
-					continue
-				}
-
-			case "wdoge":
-				wdoge, err := e.wdogeDecode(txv, pushedData, e.currentHeight)
-				if err != nil {
-					log.Error("scanning", "wdogeDecode", err, "txhash", txv.Txid)
-					continue
-				}
-
-				err = e.executeWdoge(wdoge)
-				if err != nil {
-					e.dbc.DB.Model(&models.WDogeInfo{}).Where("tx_hash = ?", wdoge.TxHash).Update("err_info", err.Error())
-					continue
-				}
-
-			case "file":
-				file, err := e.fileDecode(txv, e.currentHeight)
-				if err != nil {
-					log.Error("scanning", "nftDecode", err, "txhash", txv.Txid)
-					continue
-				}
-
-				err = e.executeFile(file)
-				if err != nil {
-					e.dbc.DB.Model(&models.FileInfo{}).Where("tx_hash = ?", file.TxHash).Update("err_info", err.Error())
-					continue
-				}
-
-			case "stake-v1":
-				stake, err := e.stakeDecode(txv, pushedData, e.currentHeight)
-				if err != nil {
-					log.Error("scanning", "stakeDecode", err, "txhash", txv.Txid)
-					continue
-				}
-
-				err = e.executeStakeV1(stake)
-				if err != nil {
-					e.dbc.DB.Model(&models.StakeInfo{}).Where("tx_hash = ?", stake.TxHash).Update("err_info", err.Error())
-					continue
-				}
-
-			case "order-v1":
-				ex, err := e.exchangeDecode(txv, pushedData, e.currentHeight)
-				if err != nil {
-					log.Error("scanning", "exchangeDecode", err, "txhash", txv.Txid)
-					continue
-				}
-
-				err = e.executeOrderV1(ex)
-				if err != nil {
-					e.dbc.DB.Model(&models.ExchangeInfo{}).Where("tx_hash = ?", ex.TxHash).Update("err_info", err.Error())
-					continue
-				}
-
-			case "order-v2":
-				ex, err := e.fileExchangeDecode(txv, pushedData, e.currentHeight)
-				if err != nil {
-					log.Error("scanning", "fileExchangeDecode", err, "txhash", txv.Txid)
-					continue
-				}
-
-				err = e.executeOrderV2(ex)
-				if err != nil {
-					e.dbc.DB.Model(&models.FileExchangeInfo{}).Where("tx_hash = ?", ex.TxHash).Update("err_info", err.Error())
-					continue
-				}
-
-			case "box-v1":
-				box, err := e.boxDecode(txv, pushedData, e.currentHeight)
-				if err != nil {
-					log.Error("scanning", "boxDecode", err, "txhash", txv.Txid)
-					continue
-				}
-
-				err = e.executeBoxV1(box)
-				if err != nil {
-					e.dbc.DB.Model(&models.BoxInfo{}).Where("tx_hash = ?", box.TxHash).Update("err_info", err.Error())
-					continue
-				}
-
-			case "cross":
-
-				cross, err := e.crossDecode(txv, pushedData, e.currentHeight)
-				if err != nil {
-					log.Error("scanning", "crossDecode", err, "txhash", txv.Txid)
-					continue
-				}
-
-				err = e.executeCross(cross)
-				if err != nil {
-					e.dbc.DB.Model(&models.CrossInfo{}).Where("tx_hash = ?", cross.TxHash).Update("err_info", err.Error())
-					continue
-				}
-
-			case "meme-20":
-				meme20, err := e.meme20Decode(txv, pushedData, e.currentHeight)
-				if err != nil {
-					log.Error("scanning", "meme20Decode", err, "txhash", txv.Txid)
-					continue
-				}
-
-				err = e.executeMeme20(meme20)
-				if err != nil {
-					e.dbc.DB.Model(&models.Meme20Info{}).Where("tx_hash = ?", meme20.TxHash).Update("err_info", err.Error())
-					continue
-				}
-
-			case "pair-v2":
-
-				swaps, err := e.swapV2RouterDecode(txv, e.currentHeight)
-				if err != nil {
-					log.Error("scanning", "swapV2RouterDecode", err, "txhash", tx)
-					continue
-				}
-
-				err = e.executePairV2(swaps)
-				if err != nil {
-					e.dbc.DB.Model(&models.SwapV2Info{}).Where("tx_hash = ?", tx).Update("err_info", err.Error())
-					continue
-				}
+		// the prefetched blocks belong to the old chain, start over from the fork point
+		if forked {
+			e.Notify()
+			return nil
+		}
 
-			case "consensus":
-				consensus, err := e.consensusDecode(txv, pushedData, e.currentHeight)
-				if err != nil {
-					log.Error("scanning", "consensusDecode", err, "txhash", txv.Txid)
-					continue
-				}
+		log.Info("explorer", "scanning start ", e.currentHeight, "txs", len(fb.txList))
 
-				err = e.executeConsensus(consensus)
-				if err != nil {
-					e.dbc.DB.Model(&models.ConsensusInfo{}).Where("tx_hash = ?", consensus.TxHash).Update("err_info", err.Error())
-					continue
-				}
+		// rows created for this block are stamped with its header time
+		e.dbc = dbc.AtBlockTime(fb.block.Time)
 
-			case "pump":
+		err = e.dbc.JournalBegin(e.currentHeight, fb.hash.String())
+		if err != nil {
+			return fmt.Errorf("scan JournalBegin err: %s", err.Error())
+		}
 
-				pump, err := e.pumpDecode(txv, pushedData, e.currentHeight)
-				if err != nil {
-					log.Error("scanning", "pumpDecode", err, "txhash", txv.Txid)
-					continue
-				}
+		err = e.dbc.ScheduledTasks(e.currentHeight)
+		if err != nil {
+			return fmt.Errorf("scan ScheduledTasks err: %s", err.Error())
+		}
 
-				err = e.executePump(pump)
-				if err != nil {
-					e.dbc.DB.Model(&models.PumpInfo{}).Where("tx_hash = ?", pump.TxHash).Update("err_info", err.Error())
-					continue
+		e.resolved = fb.txs
+		seq := 0
+		for _, txv := range fb.txList {
+			for _, ins := range e.inscriptions(txv, e.currentHeight) {
+				h := ins.handler
+				inscription, err := h.Decode(e, ins.tx, ins.index, ins.pushedData, e.currentHeight)
+				if errors.Is(err, CHAIN_NETWORK_ERR) {
+					// the journal keeps the block open, the next round rolls it back and retries
+					return fmt.Errorf("scan decode %s err: %w", txv.Txid, err)
 				}
 
-			case "invite":
-
-				invite, err := e.inviteDecode(txv, pushedData, e.currentHeight)
 				if err != nil {
-					log.Error("scanning", "inviteDecode", err, "txhash", txv.Txid)
+					log.Error("scanning", "decode", err, "p", h.Name(), "txhash", txv.Txid, "tx_index", ins.index)
+					metrics.DecodeErrors.WithLabelValues(h.Name()).Inc()
+					err = e.dbc.DecodeFailureCreate(&models.DecodeFailure{
+						P:           h.Name(),
+						TxHash:      txv.Txid,
+						TxIndex:     ins.index,
+						BlockNumber: e.currentHeight,
+						ErrInfo:     err.Error(),
+					})
+					if err != nil {
+						return fmt.Errorf("scan DecodeFailureCreate err: %s", err.Error())
+					}
 					continue
 				}
 
-				err = e.executeInvite(invite)
+				err = e.dbc.BlockInscriptionCreate(&models.BlockInscription{
+					BlockNumber: e.currentHeight,
+					Seq:         seq,
+					P:           h.Name(),
+					TxHash:      txv.Txid,
+					TxIndex:     ins.index,
+				})
 				if err != nil {
-					e.dbc.DB.Model(&models.InviteInfo{}).Where("tx_hash = ?", invite.TxHash).Update("err_info", err.Error())
-					continue
+					return fmt.Errorf("scan BlockInscriptionCreate err: %s", err.Error())
 				}
+				seq++
 
-			default:
-				log.Error("scanning", "op", "not found", "txhash", txv.Txid)
+				e.apply(h, inscription, txv.Txid, ins.index)
 			}
 		}
+		e.resolved = nil
 
 		block1 := &models.Block{
-			BlockHash:   blockHash.String(),
+			BlockHash:   fb.hash.String(),
 			BlockNumber: e.currentHeight,
+			BlockTime:   fb.block.Time,
 		}
 
-		err = e.dbc.DB.Save(block1).Error
+		err = e.dbc.JournalEnd(block1)
 		if err != nil {
-			return fmt.Errorf("scan SetBlockHash err: %s", err.Error())
+			return fmt.Errorf("scan JournalEnd err: %s", err.Error())
+		}
+		e.dbc = dbc
+
+		metrics.BlockSeconds.Observe(time.Since(start).Seconds())
+		metrics.SetHeights(e.currentHeight, chainCount)
+
+		// the mined inscriptions now live in the *_info tables
+		err = e.dbc.PendingDelete(fb.block.Tx)
+		if err != nil {
+			return fmt.Errorf("scan PendingDelete err: %s", err.Error())
 		}
 
 		log.Info("explorer", "scanning end ", e.currentHeight)
 	}
+
+	e.synced = !capped
 	return nil
 }
 
-func (e *Explorer) executeConsensus(consensus *models.ConsensusInfo) error {
-    // Basic validation handled by VerifyConsensus
-    if err := e.verify.VerifyConsensus(consensus); err != nil {
-       return fmt.Errorf("VerifyConsensus err: %s", err.Error())
-    }
+// apply checks the protocol gates, then verifies and executes a decoded
+// inscription. A failure is recorded as the err_info of its *_info row, the
+// inscription itself stays indexed.
+func (e *Explorer) apply(h ProtocolHandler, inscription interface{}, txHash string, txIndex int) {
+	err := e.gate(h, inscription)
+	if err == nil {
+		err = h.Verify(e, inscription)
+	}
+	if err != nil {
+		metrics.VerifyErrors.WithLabelValues(h.Name()).Inc()
+	} else {
+		start := time.Now()
+		err = h.Execute(e, inscription)
+		metrics.ExecuteSeconds.WithLabelValues(h.Name()).Observe(time.Since(start).Seconds())
+	}
+
+	if err != nil {
+		query := e.dbc.DB.Model(h.InfoModel()).Where("tx_hash = ?", txHash)
+		if _, whole := h.(WholeTxHandler); !whole {
+			query = query.Where("tx_index = ?", txIndex)
+		}
+		query.Update("err_info", err.Error())
+	}
+}
 
+func (e *Explorer) executeConsensus(consensus *models.ConsensusInfo) error {
     switch consensus.Op {
     case "stake":
         return e.consensusStake(consensus)
@@ -364,10 +348,7 @@ func (e *Explorer) executeConsensus(consensus *models.ConsensusInfo) error {
 
 func (e *Explorer) executeDrc20(drc20 *models.Drc20Info) error {
 
-	err := e.verify.VerifyDrc20(drc20)
-	if err != nil {
-		return fmt.Errorf("VerifyDrc20 err: %s", err.Error())
-	}
+	var err error
 
 	if drc20.Op == "deploy" {
 		err = e.drc20Deploy(drc20)
@@ -395,10 +376,7 @@ func (e *Explorer) executeDrc20(drc20 *models.Drc20Info) error {
 
 func (e *Explorer) executeWdoge(wdoge *models.WDogeInfo) error {
 
-	err := e.verify.VerifyWDoge(wdoge)
-	if err != nil {
-		return fmt.Errorf("VerifyWDoge err: %s", err.Error())
-	}
+	var err error
 
 	if wdoge.Op == "deposit" {
 		if err = e.wdogeDeposit(wdoge); err != nil {
@@ -417,10 +395,7 @@ func (e *Explorer) executeWdoge(wdoge *models.WDogeInfo) error {
 
 func (e *Explorer) executeNft(nft *models.NftInfo) error {
 
-	err := e.verify.VerifyNFT(nft)
-	if err != nil {
-		return fmt.Errorf("VerifyNFT err: %s", err.Error())
-	}
+	var err error
 
 	if nft.Op == "deploy" {
 		err = e.nftDeploy(nft)
@@ -448,10 +423,7 @@ func (e *Explorer) executeNft(nft *models.NftInfo) error {
 
 func (e *Explorer) executeFile(file *models.FileInfo) error {
 
-	err := e.verify.VerifyFile(file)
-	if err != nil {
-		return fmt.Errorf("VerifyFile err: %s", err.Error())
-	}
+	var err error
 
 	if file.Op == "deploy" {
 		err = e.fileDeploy(file)
@@ -472,10 +444,7 @@ func (e *Explorer) executeFile(file *models.FileInfo) error {
 
 func (e *Explorer) executeStakeV1(stake *models.StakeInfo) error {
 
-	err := e.verify.VerifyStake(stake)
-	if err != nil {
-		return fmt.Errorf("VerifyStake err: %s", err.Error())
-	}
+	var err error
 
 	if stake.Op == "stake" {
 		err = e.stakeStake(stake)
@@ -501,13 +470,45 @@ func (e *Explorer) executeStakeV1(stake *models.StakeInfo) error {
 	return nil
 }
 
-func (e *Explorer) executeOrderV1(ex *models.ExchangeInfo) error {
+func (e *Explorer) executeStakeV2(stake *models.StakeV2Info) error {
 
-	err := e.verify.VerifyExchange(ex)
-	if err != nil {
-		return fmt.Errorf("VerifyExchange err: %s", err.Error())
+	var err error
+
+	if stake.Op == "create" {
+		err = e.stakeV2Create(stake)
+		if err != nil {
+			return fmt.Errorf("stakeV2Create err: %s", err.Error())
+		}
+	}
+
+	if stake.Op == "stake" {
+		err = e.stakeV2Stake(stake)
+		if err != nil {
+			return fmt.Errorf("stakeV2Stake err: %s", err.Error())
+		}
+	}
+
+	if stake.Op == "unstake" {
+		err = e.stakeV2UnStake(stake)
+		if err != nil {
+			return fmt.Errorf("stakeV2UnStake err: %s", err.Error())
+		}
 	}
 
+	if stake.Op == "getreward" {
+		err = e.stakeV2GetReward(stake)
+		if err != nil {
+			return fmt.Errorf("stakeV2GetReward err: %s", err.Error())
+		}
+	}
+
+	return nil
+}
+
+func (e *Explorer) executeOrderV1(ex *models.ExchangeInfo) error {
+
+	var err error
+
 	if ex.Op == "create" {
 		err = e.exchangeCreate(ex)
 		if err != nil {
@@ -533,10 +534,7 @@ func (e *Explorer) executeOrderV1(ex *models.ExchangeInfo) error {
 }
 
 func (e *Explorer) executeOrderV2(ex *models.FileExchangeInfo) error {
-	err := e.verify.VerifyFileExchange(ex)
-	if err != nil {
-		return fmt.Errorf("VerifyFileExchange err: %s", err.Error())
-	}
+	var err error
 
 	if ex.Op == "create" {
 		err = e.fileExchangeCreate(ex)
@@ -563,10 +561,7 @@ func (e *Explorer) executeOrderV2(ex *models.FileExchangeInfo) error {
 }
 
 func (e *Explorer) executeBoxV1(box *models.BoxInfo) error {
-	err := e.verify.VerifyBox(box)
-	if err != nil {
-		return fmt.Errorf("VerifyBox err: %s", err.Error())
-	}
+	var err error
 
 	if box.Op == "deploy" {
 		err = e.boxDeploy(box)
@@ -625,12 +620,13 @@ func (e *Explorer) executePairV1(swaps []*models.SwapInfo) error {
 	if dogeDepositAmt.Cmp(big.NewInt(0)) > 0 {
 		dbtxw := e.dbc.DB.Begin()
 		wdoge := &models.WDogeInfo{}
-		wdoge.OrderId = uuid.New().String()
 		wdoge.Op = "deposit-swap"
 		wdoge.Tick = "WDOGE(WRAPPED-DOGE)"
 		wdoge.Amt = (*models.Number)(dogeDepositAmt)
 		wdoge.HolderAddress = swaps[0].HolderAddress
 		wdoge.TxHash = swaps[0].TxHash
+		wdoge.TxIndex = swaps[0].TxIndex
+		wdoge.OrderId = utils.OrderId(wdoge.TxHash, wdoge.TxIndex, utils.SubOpDeposit)
 		wdoge.BlockHash = swaps[0].BlockHash
 		wdoge.BlockNumber = swaps[0].BlockNumber
 		err := e.wdogeDepositSwap(dbtxw, wdoge)
@@ -705,12 +701,13 @@ func (e *Explorer) executePairV1(swaps []*models.SwapInfo) error {
 
 	if dogeWithdrawAmt.Cmp(big.NewInt(0)) > 0 {
 		wdoge := &models.WDogeInfo{}
-		wdoge.OrderId = uuid.New().String()
 		wdoge.Op = "withdraw-swap"
 		wdoge.Tick = "WDOGE(WRAPPED-DOGE)"
 		wdoge.Amt = (*models.Number)(dogeWithdrawAmt)
 		wdoge.HolderAddress = swaps[0].HolderAddress
 		wdoge.TxHash = swaps[0].TxHash
+		wdoge.TxIndex = swaps[0].TxIndex
+		wdoge.OrderId = utils.OrderId(wdoge.TxHash, wdoge.TxIndex, utils.SubOpWithdraw)
 		wdoge.BlockHash = swaps[0].BlockHash
 		wdoge.BlockNumber = swaps[0].BlockNumber
 		err := e.wdogeWithdrawSwap(dbtx, wdoge)
@@ -730,10 +727,7 @@ func (e *Explorer) executePairV1(swaps []*models.SwapInfo) error {
 
 func (e *Explorer) executeCross(cross *models.CrossInfo) error {
 
-	err := e.verify.VerifyCross(cross)
-	if err != nil {
-		return fmt.Errorf("VerifyCross err: %s", err.Error())
-	}
+	var err error
 
 	if cross.Op == "deploy" {
 		err = e.crossDeploy(cross)
@@ -761,10 +755,7 @@ func (e *Explorer) executeCross(cross *models.CrossInfo) error {
 
 func (e *Explorer) executeMeme20(meme20 *models.Meme20Info) error {
 
-	err := e.verify.VerifyMeme20(meme20)
-	if err != nil {
-		return fmt.Errorf("VerifyDrc20 err: %s", err.Error())
-	}
+	var err error
 
 	if meme20.Op == "deploy" {
 		err = e.memeDeploy(meme20)
@@ -821,12 +812,13 @@ func (e *Explorer) executePairV2(swaps []*models.SwapV2Info) error {
 	if dogeDepositAmt.Cmp(big.NewInt(0)) > 0 {
 		dbtxw := e.dbc.DB.Begin()
 		wdoge := &models.WDogeInfo{}
-		wdoge.OrderId = uuid.New().String()
 		wdoge.Op = "deposit-swap"
 		wdoge.Tick = "WDOGE(WRAPPED-DOGE)"
 		wdoge.Amt = (*models.Number)(dogeDepositAmt)
 		wdoge.HolderAddress = swaps[0].HolderAddress
 		wdoge.TxHash = swaps[0].TxHash
+		wdoge.TxIndex = swaps[0].TxIndex
+		wdoge.OrderId = utils.OrderId(wdoge.TxHash, wdoge.TxIndex, utils.SubOpDeposit)
 		wdoge.BlockHash = swaps[0].BlockHash
 		wdoge.BlockNumber = swaps[0].BlockNumber
 		err := e.wdogeDepositSwap(dbtxw, wdoge)
@@ -901,12 +893,13 @@ func (e *Explorer) executePairV2(swaps []*models.SwapV2Info) error {
 
 	if dogeWithdrawAmt.Cmp(big.NewInt(0)) > 0 {
 		wdoge := &models.WDogeInfo{}
-		wdoge.OrderId = uuid.New().String()
 		wdoge.Op = "withdraw-swap"
 		wdoge.Tick = "WDOGE(WRAPPED-DOGE)"
 		wdoge.Amt = (*models.Number)(dogeWithdrawAmt)
 		wdoge.HolderAddress = swaps[0].HolderAddress
 		wdoge.TxHash = swaps[0].TxHash
+		wdoge.TxIndex = swaps[0].TxIndex
+		wdoge.OrderId = utils.OrderId(wdoge.TxHash, wdoge.TxIndex, utils.SubOpWithdraw)
 		wdoge.BlockHash = swaps[0].BlockHash
 		wdoge.BlockNumber = swaps[0].BlockNumber
 		err := e.wdogeWithdrawSwap(dbtx, wdoge)
@@ -946,12 +939,13 @@ func (e *Explorer) executePump(pump *models.PumpInfo) error {
 	if dogeDepositAmt.Cmp(big.NewInt(0)) > 0 {
 		dbtxw := e.dbc.DB.Begin()
 		wdoge := &models.WDogeInfo{}
-		wdoge.OrderId = uuid.New().String()
 		wdoge.Op = "deposit-pump"
 		wdoge.Tick = "WDOGE(WRAPPED-DOGE)"
 		wdoge.Amt = (*models.Number)(dogeDepositAmt)
 		wdoge.HolderAddress = pump.HolderAddress
 		wdoge.TxHash = pump.TxHash
+		wdoge.TxIndex = pump.TxIndex
+		wdoge.OrderId = utils.OrderId(wdoge.TxHash, wdoge.TxIndex, utils.SubOpDeposit)
 		wdoge.BlockHash = pump.BlockHash
 		wdoge.BlockNumber = pump.BlockNumber
 		err := e.wdogeDepositSwap(dbtxw, wdoge)
@@ -994,12 +988,13 @@ func (e *Explorer) executePump(pump *models.PumpInfo) error {
 
 	if dogeWithdrawAmt.Cmp(big.NewInt(0)) > 0 {
 		wdoge := &models.WDogeInfo{}
-		wdoge.OrderId = uuid.New().String()
 		wdoge.Op = "withdraw-pump"
 		wdoge.Tick = "WDOGE(WRAPPED-DOGE)"
 		wdoge.Amt = (*models.Number)(dogeWithdrawAmt)
 		wdoge.HolderAddress = pump.HolderAddress
 		wdoge.TxHash = pump.TxHash
+		wdoge.TxIndex = pump.TxIndex
+		wdoge.OrderId = utils.OrderId(wdoge.TxHash, wdoge.TxIndex, utils.SubOpWithdraw)
 		wdoge.BlockHash = pump.BlockHash
 		wdoge.BlockNumber = pump.BlockNumber
 		err := e.wdogeWithdrawSwap(dbtx, wdoge)
@@ -1016,20 +1011,3 @@ func (e *Explorer) executePump(pump *models.PumpInfo) error {
 
 	return nil
 }
-
-func (e *Explorer) executeInvite(invite *models.InviteInfo) error {
-
-	err := e.verify.VerifyInvite(invite)
-	if err != nil {
-		return fmt.Errorf("VerifyInvite err: %s", err.Error())
-	}
-
-	if invite.Op == "deploy" {
-		err = e.inviteDeploy(invite)
-		if err != nil {
-			return fmt.Errorf("inviteInvite err: %s", err.Error())
-		}
-	}
-
-	return nil
-}
@@ -2,23 +2,22 @@ package explorer
 
 import (
 	"dogeuni-indexer/models"
+	"dogeuni-indexer/router"
 	"dogeuni-indexer/utils"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/dogecoinw/doged/btcjson"
 	"github.com/dogecoinw/doged/btcutil"
-	"github.com/dogecoinw/doged/chaincfg"
-	"github.com/dogecoinw/doged/chaincfg/chainhash"
 	"github.com/dogecoinw/go-dogecoin/log"
-	"github.com/google/uuid"
+	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 	"math/big"
 )
 
-func (e *Explorer) stakeDecode(tx *btcjson.TxRawResult, pushedData []byte, number int64) (*models.StakeInfo, error) {
+func (e *Explorer) stakeDecode(tx *btcjson.TxRawResult, txIndex int, pushedData []byte, number int64) (*models.StakeInfo, error) {
 
-	err := e.dbc.DB.Where("tx_hash = ?", tx.Txid).First(&models.StakeInfo{}).Error
+	err := e.dbc.DB.Where("tx_hash = ? and tx_index = ?", tx.Txid, txIndex).First(&models.StakeInfo{}).Error
 	if !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, fmt.Errorf("stake already exist or err %s", tx.Txid)
 	}
@@ -38,28 +37,30 @@ func (e *Explorer) stakeDecode(tx *btcjson.TxRawResult, pushedData []byte, numbe
 		return nil, fmt.Errorf("op error, vout length is not 0")
 	}
 
-	stake.OrderId = uuid.New().String()
 	stake.FeeTxHash = tx.Vin[0].Txid
 	stake.TxHash = tx.Txid
+	stake.TxIndex = txIndex
+	stake.OrderId = utils.OrderId(stake.TxHash, stake.TxIndex, utils.SubOpInscription)
 	stake.BlockHash = tx.BlockHash
 	stake.BlockNumber = number
 	stake.OrderStatus = 1
 
-	stake.HolderAddress = tx.Vout[0].ScriptPubKey.Addresses[0]
+	stake.HolderAddress, err = OutputAddress(tx, 0)
+	if err != nil {
+		return nil, err
+	}
 
-	txhash0, _ := chainhash.NewHashFromStr(tx.Vin[0].Txid)
-	txRawResult0, err := e.node.GetRawTransactionVerboseBool(txhash0)
+	_, txRawResult0, err := e.SpentOutput(tx, 0)
 	if err != nil {
-		return nil, CHAIN_NETWORK_ERR
+		return nil, err
 	}
 
-	txhash1, _ := chainhash.NewHashFromStr(txRawResult0.Vin[0].Txid)
-	txRawResult1, err := e.node.GetRawTransactionVerboseBool(txhash1)
+	previous, _, err := e.InputAddress(txRawResult0, 0)
 	if err != nil {
-		return nil, CHAIN_NETWORK_ERR
+		return nil, err
 	}
 
-	if stake.HolderAddress != txRawResult1.Vout[txRawResult0.Vin[0].Vout].ScriptPubKey.Addresses[0] {
+	if stake.HolderAddress != previous {
 		return nil, fmt.Errorf("The address is not the same as the previous transaction")
 	}
 
@@ -72,7 +73,7 @@ func (e *Explorer) stakeDecode(tx *btcjson.TxRawResult, pushedData []byte, numbe
 }
 
 func (e *Explorer) stakeStake(stake *models.StakeInfo) error {
-	reservesAddress, _ := btcutil.NewAddressScriptHash([]byte(stake.Tick+"--STAKE"), &chaincfg.MainNetParams)
+	reservesAddress, _ := btcutil.NewAddressScriptHash([]byte(stake.Tick+"--STAKE"), e.dbc.NetParams())
 
 	tx := e.dbc.DB.Begin()
 	err := e.dbc.StakeStake(tx, stake, reservesAddress.String())
@@ -81,7 +82,7 @@ func (e *Explorer) stakeStake(stake *models.StakeInfo) error {
 		return err
 	}
 
-	err = tx.Model(&models.StakeInfo{}).Where("tx_hash = ?", stake.TxHash).Update("order_status", 0).Error
+	err = tx.Model(&models.StakeInfo{}).Where("tx_hash = ? and tx_index = ?", stake.TxHash, stake.TxIndex).Update("order_status", 0).Error
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -98,7 +99,7 @@ func (e *Explorer) stakeStake(stake *models.StakeInfo) error {
 
 func (e *Explorer) stakeUnStake(stake *models.StakeInfo) error {
 
-	reservesAddress, _ := btcutil.NewAddressScriptHash([]byte(stake.Tick+"--STAKE"), &chaincfg.MainNetParams)
+	reservesAddress, _ := btcutil.NewAddressScriptHash([]byte(stake.Tick+"--STAKE"), e.dbc.NetParams())
 
 	tx := e.dbc.DB.Begin()
 	err := e.dbc.StakeUnStake(tx, stake, reservesAddress.String())
@@ -107,7 +108,7 @@ func (e *Explorer) stakeUnStake(stake *models.StakeInfo) error {
 		return err
 	}
 
-	err = tx.Model(&models.StakeInfo{}).Where("tx_hash = ?", stake.TxHash).Update("order_status", 0).Error
+	err = tx.Model(&models.StakeInfo{}).Where("tx_hash = ? and tx_index = ?", stake.TxHash, stake.TxIndex).Update("order_status", 0).Error
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -131,7 +132,7 @@ func (e *Explorer) stakeGetAllReward(stake *models.StakeInfo) error {
 		return err
 	}
 
-	err = tx.Model(&models.StakeInfo{}).Where("tx_hash = ?", stake.TxHash).Update("order_status", 0).Error
+	err = tx.Model(&models.StakeInfo{}).Where("tx_hash = ? and tx_index = ?", stake.TxHash, stake.TxIndex).Update("order_status", 0).Error
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -206,3 +207,50 @@ func (e *Explorer) stakeFork(tx *gorm.DB, height int64) error {
 
 	return nil
 }
+
+type stakeHandler struct{}
+
+func (stakeHandler) Name() string {
+	return "stake-v1"
+}
+
+func (stakeHandler) Decode(e *Explorer, tx *btcjson.TxRawResult, txIndex int, pushedData []byte, height int64) (interface{}, error) {
+	return e.stakeDecode(tx, txIndex, pushedData, height)
+}
+
+func (stakeHandler) Verify(e *Explorer, inscription interface{}) error {
+	err := e.verify.VerifyStake(inscription.(*models.StakeInfo))
+	if err != nil {
+		return fmt.Errorf("VerifyStake err: %s", err.Error())
+	}
+	return nil
+}
+
+func (stakeHandler) Execute(e *Explorer, inscription interface{}) error {
+	return e.executeStakeV1(inscription.(*models.StakeInfo))
+}
+
+func (stakeHandler) Fork(e *Explorer, tx *gorm.DB, height int64) error {
+	return e.stakeFork(tx, height)
+}
+
+func (stakeHandler) InfoModel() interface{} {
+	return &models.StakeInfo{}
+}
+
+func (stakeHandler) InfoTables() []interface{} {
+	return []interface{}{&models.StakeInfo{}, &models.StakeRewardInfo{}}
+}
+
+func (stakeHandler) RevertTables() []interface{} {
+	return []interface{}{&models.StakeRevert{}, &models.StakeRewardRevert{}}
+}
+
+func (stakeHandler) Routes(rg *gin.RouterGroup, deps *RouteDeps) {
+	stakeRouter := router.NewStakeRouter(deps.DBC, deps.Node)
+	rg.POST("/stake/order", deps.Pending.Order("stake-v1", stakeRouter.Order))
+	rg.POST("/stake/collect", stakeRouter.Collect)
+	rg.POST("/stake/collect-address", stakeRouter.CollectAddress)
+	rg.POST("/stake/reward", stakeRouter.Reward)
+	rg.POST("/stake/total", stakeRouter.Total)
+}
@@ -2,22 +2,22 @@ package explorer
 
 import (
 	"dogeuni-indexer/models"
+	"dogeuni-indexer/router"
 	"dogeuni-indexer/utils"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/dogecoinw/doged/btcjson"
 	"github.com/dogecoinw/doged/btcutil"
-	"github.com/dogecoinw/doged/chaincfg"
-	"github.com/dogecoinw/doged/chaincfg/chainhash"
 	"github.com/dogecoinw/go-dogecoin/log"
-	"github.com/google/uuid"
+	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"math/big"
 )
 
-func (e *Explorer) stakeV2Decode(tx *btcjson.TxRawResult, pushedData []byte, number int64) (*models.StakeV2Info, error) {
+func (e *Explorer) stakeV2Decode(tx *btcjson.TxRawResult, txIndex int, pushedData []byte, number int64) (*models.StakeV2Info, error) {
 
-	err := e.dbc.DB.Where("tx_hash = ?", tx.Txid).First(&models.StakeV2Info{}).Error
+	err := e.dbc.DB.Where("tx_hash = ? and tx_index = ?", tx.Txid, txIndex).First(&models.StakeV2Info{}).Error
 	if !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, fmt.Errorf("stake already exist or err %s", tx.Txid)
 	}
@@ -37,15 +37,16 @@ func (e *Explorer) stakeV2Decode(tx *btcjson.TxRawResult, pushedData []byte, num
 		return nil, fmt.Errorf("op error, vout length is not 0")
 	}
 
-	stake.OrderId = uuid.New().String()
 	stake.FeeTxHash = tx.Vin[0].Txid
 	stake.TxHash = tx.Txid
+	stake.TxIndex = txIndex
+	stake.OrderId = utils.OrderId(stake.TxHash, stake.TxIndex, utils.SubOpInscription)
 	stake.BlockHash = tx.BlockHash
 	stake.BlockNumber = number
 	stake.OrderStatus = 1
 
 	if stake.Op == "create" {
-		stake.StakeId = tx.Txid
+		stake.StakeId = utils.InscriptionId(tx.Txid, txIndex)
 	}
 
 	if stake.Op == "stake" {
@@ -61,21 +62,22 @@ func (e *Explorer) stakeV2Decode(tx *btcjson.TxRawResult, pushedData []byte, num
 		stake.EachReward = stakec.EachReward
 	}
 
-	stake.HolderAddress = tx.Vout[0].ScriptPubKey.Addresses[0]
+	stake.HolderAddress, err = OutputAddress(tx, 0)
+	if err != nil {
+		return nil, err
+	}
 
-	txhash0, _ := chainhash.NewHashFromStr(tx.Vin[0].Txid)
-	txRawResult0, err := e.node.GetRawTransactionVerboseBool(txhash0)
+	_, txRawResult0, err := e.SpentOutput(tx, 0)
 	if err != nil {
-		return nil, CHAIN_NETWORK_ERR
+		return nil, err
 	}
 
-	txhash1, _ := chainhash.NewHashFromStr(txRawResult0.Vin[0].Txid)
-	txRawResult1, err := e.node.GetRawTransactionVerboseBool(txhash1)
+	previous, _, err := e.InputAddress(txRawResult0, 0)
 	if err != nil {
-		return nil, CHAIN_NETWORK_ERR
+		return nil, err
 	}
 
-	if stake.HolderAddress != txRawResult1.Vout[txRawResult0.Vin[0].Vout].ScriptPubKey.Addresses[0] {
+	if stake.HolderAddress != previous {
 		return nil, fmt.Errorf("The address is not the same as the previous transaction")
 	}
 
@@ -88,7 +90,7 @@ func (e *Explorer) stakeV2Decode(tx *btcjson.TxRawResult, pushedData []byte, num
 }
 
 func (e *Explorer) stakeV2Create(stake *models.StakeV2Info) error {
-	reservesAddress, _ := btcutil.NewAddressScriptHash([]byte(stake.StakeId+"--STAKE-V2"), &chaincfg.MainNetParams)
+	reservesAddress, _ := btcutil.NewAddressScriptHash([]byte(stake.StakeId+"--STAKE-V2"), e.dbc.NetParams())
 
 	tx := e.dbc.DB.Begin()
 	err := e.dbc.StakeV2Create(tx, stake, reservesAddress.String())
@@ -97,7 +99,7 @@ func (e *Explorer) stakeV2Create(stake *models.StakeV2Info) error {
 		return err
 	}
 
-	err = tx.Model(&models.StakeV2Info{}).Where("tx_hash = ?", stake.TxHash).Update("order_status", 0).Error
+	err = tx.Model(&models.StakeV2Info{}).Where("tx_hash = ? and tx_index = ?", stake.TxHash, stake.TxIndex).Update("order_status", 0).Error
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -121,7 +123,7 @@ func (e *Explorer) stakeV2Stake(stake *models.StakeV2Info) error {
 		return err
 	}
 
-	err = tx.Model(&models.StakeV2Info{}).Where("tx_hash = ?", stake.TxHash).Update("order_status", 0).Error
+	err = tx.Model(&models.StakeV2Info{}).Where("tx_hash = ? and tx_index = ?", stake.TxHash, stake.TxIndex).Update("order_status", 0).Error
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -144,7 +146,7 @@ func (e *Explorer) stakeV2UnStake(stake *models.StakeV2Info) error {
 		return err
 	}
 
-	err = tx.Model(&models.StakeV2Info{}).Where("tx_hash = ?", stake.TxHash).Update("order_status", 0).Error
+	err = tx.Model(&models.StakeV2Info{}).Where("tx_hash = ? and tx_index = ?", stake.TxHash, stake.TxIndex).Update("order_status", 0).Error
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -167,7 +169,7 @@ func (e *Explorer) stakeV2GetReward(stake *models.StakeV2Info) error {
 		return err
 	}
 
-	err = tx.Model(&models.StakeV2Info{}).Where("tx_hash = ?", stake.TxHash).Update("order_status", 0).Error
+	err = tx.Model(&models.StakeV2Info{}).Where("tx_hash = ? and tx_index = ?", stake.TxHash, stake.TxIndex).Update("order_status", 0).Error
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -196,16 +198,11 @@ func (e *Explorer) stakeV2Fork(tx *gorm.DB, height int64) error {
 
 	for _, revert := range stakeV2Reverts {
 		if revert.Op == "create" {
-
+			// the reward sent to the pool is returned by the drc-20 fork
 			err = tx.Where("stake_id = ?", revert.StakeId).Delete(&models.StakeV2Collect{}).Error
 			if err != nil {
 				return fmt.Errorf("StakeV2Collect error: %v", err)
 			}
-
-			err = tx.Where("tick = ?", revert.Tick).Delete(&models.Drc20Collect{}).Error
-			if err != nil {
-				return fmt.Errorf("Drc20Collect error: %v", err)
-			}
 		}
 
 		if revert.Op == "stake-pool" {
@@ -213,6 +210,7 @@ func (e *Explorer) stakeV2Fork(tx *gorm.DB, height int64) error {
 			err = tx.Model(&models.StakeV2Collect{}).Where("stake_id = ?", revert.StakeId).Updates(map[string]interface{}{
 				"total_staked":         revert.Amt,
 				"acc_reward_per_share": revert.AccRewardPerShare,
+				"last_reward_block":    revert.LastRewardBlock,
 				"last_block":           revert.LastBlock,
 			}).Error
 			if err != nil {
@@ -227,25 +225,39 @@ func (e *Explorer) stakeV2Fork(tx *gorm.DB, height int64) error {
 			}
 		}
 
-		if revert.Op == "stake" {
+		if revert.Op == "stake" || revert.Op == "unstake" {
 
-			err = tx.Model(&models.StakeV2CollectAddress{}).Where("stake_id = ? AND holder_address = ?", revert.StakeId, revert.HolderAddress).Updates(map[string]interface{}{
-				"amt":            revert.Amt,
-				"reward_debt":    revert.RewardDebt,
-				"pending_reward": revert.PendingReward,
-			}).Error
+			stakea := &models.StakeV2CollectAddress{}
+			err = tx.Where("stake_id = ? AND holder_address = ?", revert.StakeId, revert.HolderAddress).First(stakea).Error
 			if err != nil {
 				return fmt.Errorf("StakeV2CollectAddress error: %v", err)
 			}
-		}
 
-		if revert.Op == "unstake" {
+			stakec := &models.StakeV2Collect{}
+			err = tx.Where("stake_id = ?", revert.StakeId).First(stakec).Error
+			if err != nil {
+				return fmt.Errorf("StakeV2Collect error: %v", err)
+			}
 
-			err = tx.Model(&models.StakeV2CollectAddress{}).Where("stake_id = ? AND holder_address = ?", revert.StakeId, revert.HolderAddress).Updates(map[string]interface{}{
+			// the pool moved by the same amount as the address
+			delta := big.NewInt(0).Sub(stakea.Amt.Int(), revert.Amt.Int())
+			totalStaked := big.NewInt(0).Sub(stakec.TotalStaked.Int(), delta)
+			err = tx.Model(stakec).Update("total_staked", totalStaked.String()).Error
+			if err != nil {
+				return fmt.Errorf("StakeV2Collect error: %v", err)
+			}
+
+			updates := map[string]interface{}{
 				"amt":            revert.Amt,
 				"reward_debt":    revert.RewardDebt,
 				"pending_reward": revert.PendingReward,
-			}).Error
+			}
+
+			if revert.Op == "stake" {
+				updates["last_block"] = revert.LastBlock
+			}
+
+			err = tx.Model(&models.StakeV2CollectAddress{}).Where("stake_id = ? AND holder_address = ?", revert.StakeId, revert.HolderAddress).Updates(updates).Error
 			if err != nil {
 				return fmt.Errorf("StakeV2CollectAddress error: %v", err)
 			}
@@ -265,3 +277,49 @@ func (e *Explorer) stakeV2Fork(tx *gorm.DB, height int64) error {
 
 	return nil
 }
+
+type stakeV2Handler struct{}
+
+func (stakeV2Handler) Name() string {
+	return "stake-v2"
+}
+
+func (stakeV2Handler) Decode(e *Explorer, tx *btcjson.TxRawResult, txIndex int, pushedData []byte, height int64) (interface{}, error) {
+	return e.stakeV2Decode(tx, txIndex, pushedData, height)
+}
+
+func (stakeV2Handler) Verify(e *Explorer, inscription interface{}) error {
+	err := e.verify.VerifyStakeV2(inscription.(*models.StakeV2Info))
+	if err != nil {
+		return fmt.Errorf("VerifyStakeV2 err: %s", err.Error())
+	}
+	return nil
+}
+
+func (stakeV2Handler) Execute(e *Explorer, inscription interface{}) error {
+	return e.executeStakeV2(inscription.(*models.StakeV2Info))
+}
+
+func (stakeV2Handler) Fork(e *Explorer, tx *gorm.DB, height int64) error {
+	return e.stakeV2Fork(tx, height)
+}
+
+func (stakeV2Handler) InfoModel() interface{} {
+	return &models.StakeV2Info{}
+}
+
+func (stakeV2Handler) InfoTables() []interface{} {
+	return []interface{}{&models.StakeV2Info{}}
+}
+
+func (stakeV2Handler) RevertTables() []interface{} {
+	return []interface{}{&models.StakeV2Revert{}}
+}
+
+func (stakeV2Handler) Routes(rg *gin.RouterGroup, deps *RouteDeps) {
+	stakeV2Router := router.NewStakeV2Router(deps.DBC, deps.Node)
+	rg.POST("/stake_v2/order", deps.Pending.Order("stake-v2", stakeV2Router.Order))
+	rg.POST("/stake_v2/collect", stakeV2Router.Collect)
+	rg.POST("/stake_v2/collect-address", stakeV2Router.CollectAddress)
+	rg.POST("/stake_v2/reward", stakeV2Router.Reward)
+}
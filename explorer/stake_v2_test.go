@@ -0,0 +1,188 @@
+package explorer
+
+import (
+	"dogeuni-indexer/models"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+const (
+	stakeTick0   = "STAKE0"
+	stakeTick1   = "REWARD1"
+	stakeCreator = "DCreatorAddressxxxxxxxxxxxxxxxxxx"
+	stakeHolder  = "DHolderAddressxxxxxxxxxxxxxxxxxxx"
+)
+
+// stakeV2Balances deploys the two ticks of the pool and funds its creator
+// and staker.
+func stakeV2Balances(t *testing.T, e *Explorer) {
+	t.Helper()
+
+	for _, tick := range []string{stakeTick0, stakeTick1} {
+		err := e.dbc.DB.Create(&models.Drc20Collect{Tick: tick, Max: models.NewNumber(1e12), Lim: models.NewNumber(1e12), AmtSum: models.NewNumber(1e6)}).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	balances := []*models.Drc20CollectAddress{
+		{Tick: stakeTick1, HolderAddress: stakeCreator, AmtSum: models.NewNumber(1000000)},
+		{Tick: stakeTick0, HolderAddress: stakeHolder, AmtSum: models.NewNumber(5000)},
+	}
+	for _, b := range balances {
+		if err := e.dbc.DB.Create(b).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// stakeV2State captures the balances and pools a fork has to restore. Empty
+// drc-20 balances are left out, a fork does not delete the rows it emptied.
+func stakeV2State(t *testing.T, e *Explorer) map[string]string {
+	t.Helper()
+
+	state := make(map[string]string)
+
+	balances := make([]*models.Drc20CollectAddress, 0)
+	if err := e.dbc.DB.Find(&balances).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range balances {
+		if b.AmtSum.Int().Sign() != 0 {
+			state["drc20/"+b.Tick+"/"+b.HolderAddress] = b.AmtSum.String()
+		}
+	}
+
+	pools := make([]*models.StakeV2Collect, 0)
+	if err := e.dbc.DB.Find(&pools).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range pools {
+		state["pool/"+p.StakeId] = fmt.Sprintf("total=%s acc=%s last_reward=%d last=%d",
+			p.TotalStaked, p.AccRewardPerShare, p.LastRewardBlock, p.LastBlock)
+	}
+
+	stakers := make([]*models.StakeV2CollectAddress, 0)
+	if err := e.dbc.DB.Find(&stakers).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range stakers {
+		state["staker/"+s.StakeId+"/"+s.HolderAddress] = fmt.Sprintf("amt=%s debt=%s pending=%s last=%d",
+			s.Amt, s.RewardDebt, s.PendingReward, s.LastBlock)
+	}
+
+	return state
+}
+
+func stakeV2Apply(t *testing.T, e *Explorer, stake *models.StakeV2Info) {
+	t.Helper()
+
+	stake.TxHash = fmt.Sprintf("%s-%d", stake.Op, stake.BlockNumber)
+	if err := e.dbc.DB.Create(stake).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.executeStakeV2(stake); err != nil {
+		t.Fatalf("%s at %d: %s", stake.Op, stake.BlockNumber, err.Error())
+	}
+}
+
+func stakeV2Rollback(t *testing.T, e *Explorer, height int64) {
+	t.Helper()
+
+	if err := e.Rollback(height); err != nil {
+		t.Fatalf("rollback to %d: %s", height, err.Error())
+	}
+}
+
+// stakeV2Scenario creates a pool at 101, stakes at 102 and 104, claims at 106,
+// unstakes at 108 and again at 210 once the rewards ran out, reporting the
+// state after every block.
+func stakeV2Scenario(t *testing.T, e *Explorer) map[int64]map[string]string {
+	t.Helper()
+
+	stakeId := "pool-101"
+	states := map[int64]map[string]string{100: stakeV2State(t, e)}
+
+	ops := []*models.StakeV2Info{
+		{Op: "create", StakeId: stakeId, Tick0: stakeTick0, Tick1: stakeTick1, Reward: models.NewNumber(100000), EachReward: models.NewNumber(1000), HolderAddress: stakeCreator, BlockNumber: 101},
+		{Op: "stake", StakeId: stakeId, Amt: models.NewNumber(2000), HolderAddress: stakeHolder, BlockNumber: 102},
+		{Op: "stake", StakeId: stakeId, Amt: models.NewNumber(1000), HolderAddress: stakeHolder, BlockNumber: 104},
+		{Op: "getreward", StakeId: stakeId, HolderAddress: stakeHolder, BlockNumber: 106},
+		{Op: "unstake", StakeId: stakeId, Amt: models.NewNumber(1500), HolderAddress: stakeHolder, BlockNumber: 108},
+		{Op: "unstake", StakeId: stakeId, Amt: models.NewNumber(500), HolderAddress: stakeHolder, BlockNumber: 210},
+	}
+
+	for _, op := range ops {
+		if op.Op == "stake" {
+			pool := &models.StakeV2Collect{}
+			if err := e.dbc.DB.Where("stake_id = ?", stakeId).First(pool).Error; err != nil {
+				t.Fatal(err)
+			}
+			op.Tick0 = pool.Tick0
+			op.Tick1 = pool.Tick1
+			op.Reward = pool.Reward
+			op.EachReward = pool.EachReward
+		}
+
+		stakeV2Apply(t, e, op)
+		states[op.BlockNumber] = stakeV2State(t, e)
+	}
+
+	return states
+}
+
+func TestStakeV2ForkToBeforeCreate(t *testing.T) {
+	e := newIndexTestExplorer(t)
+	stakeV2Balances(t, e)
+	states := stakeV2Scenario(t, e)
+
+	stakeV2Rollback(t, e, 100)
+
+	if got := stakeV2State(t, e); !reflect.DeepEqual(got, states[100]) {
+		t.Fatalf("state after fork to 100\n got: %v\nwant: %v", got, states[100])
+	}
+
+	reverts := int64(0)
+	e.dbc.DB.Model(&models.StakeV2Revert{}).Count(&reverts)
+	if reverts != 0 {
+		t.Fatalf("stake_v2_revert rows left after fork: %d", reverts)
+	}
+
+	infos := int64(0)
+	e.dbc.DB.Model(&models.StakeV2Info{}).Count(&infos)
+	if infos != 0 {
+		t.Fatalf("stake_v2_info rows left after fork: %d", infos)
+	}
+}
+
+func TestStakeV2ForkToEveryHeight(t *testing.T) {
+	for _, height := range []int64{101, 102, 104, 106, 108} {
+		t.Run(fmt.Sprint(height), func(t *testing.T) {
+			e := newIndexTestExplorer(t)
+			stakeV2Balances(t, e)
+			states := stakeV2Scenario(t, e)
+
+			stakeV2Rollback(t, e, height)
+
+			if got := stakeV2State(t, e); !reflect.DeepEqual(got, states[height]) {
+				t.Fatalf("state after fork to %d\n got: %v\nwant: %v", height, got, states[height])
+			}
+		})
+	}
+}
+
+func TestStakeV2ForkThenReapply(t *testing.T) {
+	e := newIndexTestExplorer(t)
+	stakeV2Balances(t, e)
+	states := stakeV2Scenario(t, e)
+
+	stakeV2Rollback(t, e, 102)
+
+	stakeV2Apply(t, e, &models.StakeV2Info{Op: "stake", StakeId: "pool-101", Tick0: stakeTick0, Tick1: stakeTick1, Amt: models.NewNumber(1000), HolderAddress: stakeHolder, BlockNumber: 104})
+
+	if got := stakeV2State(t, e); !reflect.DeepEqual(got, states[104]) {
+		t.Fatalf("state after re-applying 104\n got: %v\nwant: %v", got, states[104])
+	}
+}
@@ -0,0 +1,153 @@
+package explorer
+
+import (
+	"dogeuni-indexer/models"
+	"testing"
+)
+
+const (
+	rootTick0 = "ROOTA"
+	rootTick1 = "ROOTB"
+)
+
+// stateRootScenario mints two drc-20 ticks at 100, transfers one of them at
+// 101 and pools both into a swap at 102. 103 writes nothing.
+func stateRootScenario(t *testing.T, e *Explorer) {
+	t.Helper()
+
+	for _, tick := range []string{rootTick0, rootTick1} {
+		err := e.dbc.DB.Create(&models.Drc20Collect{Tick: tick, Max: models.NewNumber(1e12), Lim: models.NewNumber(1e12)}).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = e.dbc.MintDrc20(e.dbc.DB, tick, stakeHolder, models.NewNumber(1e9).Int(), "mint-"+tick, 100, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := e.dbc.TransferDrc20(e.dbc.DB, rootTick0, stakeHolder, stakeCreator, models.NewNumber(1e6).Int(), "transfer", 101, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	swap := &models.SwapInfo{Op: "create", Tick0: rootTick0, Tick1: rootTick1, Amt0: models.NewNumber(4e8), Amt1: models.NewNumber(9e8), HolderAddress: stakeHolder, TxHash: "create", BlockNumber: 102}
+	if err := e.dbc.SwapCreate(e.dbc.DB, swap); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// stateRoots saves the blocks 100 to 103 the way the scanner does once their
+// inscriptions were applied and returns their state roots.
+func stateRoots(t *testing.T, e *Explorer) map[int64]string {
+	t.Helper()
+
+	roots := make(map[int64]string)
+	for height := int64(100); height <= 103; height++ {
+		block := &models.Block{BlockNumber: height, BlockHash: "hash"}
+		if err := e.dbc.JournalEnd(block); err != nil {
+			t.Fatal(err)
+		}
+		roots[height] = block.StateRoot
+	}
+	return roots
+}
+
+func TestStateRootDeterministic(t *testing.T) {
+	a := newIndexTestExplorer(t)
+	stateRootScenario(t, a)
+
+	b := newIndexTestExplorer(t)
+	stateRootScenario(t, b)
+
+	kinds := make([]string, 0)
+	a.dbc.DB.Model(&models.StateChange{}).Distinct().Order("kind").Pluck("kind", &kinds)
+	if len(kinds) != 2 || kinds[0] != "drc20" || kinds[1] != "swap" {
+		t.Fatalf("changes of %v, want drc20 and swap", kinds)
+	}
+
+	rootsA, rootsB := stateRoots(t, a), stateRoots(t, b)
+	for height, root := range rootsA {
+		if rootsB[height] != root {
+			t.Fatalf("root at %d: %s != %s", height, root, rootsB[height])
+		}
+	}
+
+	for height := int64(101); height <= 103; height++ {
+		if rootsA[height] == rootsA[height-1] {
+			t.Fatalf("root at %d should chain onto %d", height, height-1)
+		}
+	}
+}
+
+func TestStateRootDiverges(t *testing.T) {
+	a := newIndexTestExplorer(t)
+	stateRootScenario(t, a)
+	rootsA := stateRoots(t, a)
+
+	b := newIndexTestExplorer(t)
+	stateRootScenario(t, b)
+
+	// the second instance holds one more unit in the pool at 102
+	err := b.dbc.DB.Model(&models.StateChange{}).
+		Where("block_number = ? and kind = ?", 102, "swap").
+		Update("value", "400000001,900000000,1").Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootsB := stateRoots(t, b)
+
+	if rootsA[101] != rootsB[101] {
+		t.Fatal("roots before the divergence should match")
+	}
+
+	// the empty block after it still carries the difference
+	for height := int64(102); height <= 103; height++ {
+		if rootsA[height] == rootsB[height] {
+			t.Fatalf("root at %d should differ", height)
+		}
+	}
+}
+
+func TestStateRootHashesNftOwners(t *testing.T) {
+	e := newIndexTestExplorer(t)
+	if err := e.dbc.DB.Create(&models.NftCollect{Tick: "ROOTNFT"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.dbc.MintNft(e.dbc.DB, "ROOTNFT", stakeHolder, "", "", "mint", 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.dbc.TransferNft(e.dbc.DB, "ROOTNFT", stakeHolder, stakeCreator, 1, 101, false); err != nil {
+		t.Fatal(err)
+	}
+
+	owners := make([]string, 0)
+	e.dbc.DB.Model(&models.StateChange{}).Where("kind = ? and key_ = ?", "nft", "ROOTNFT#1").Order("id").Pluck("holder_address", &owners)
+	if len(owners) != 2 || owners[0] != stakeHolder || owners[1] != stakeCreator {
+		t.Fatalf("nft owners %v, want the mint and the transfer", owners)
+	}
+}
+
+func TestStateRootWaitsForBackfill(t *testing.T) {
+	e := newIndexTestExplorer(t)
+	stateRootScenario(t, e)
+
+	// block 99 was indexed before state roots existed
+	if err := e.dbc.DB.Create(&models.Block{BlockNumber: 99, BlockHash: "hash"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	roots := stateRoots(t, e)
+	for height, root := range roots {
+		if root != "" {
+			t.Fatalf("root at %d chains onto block 99 without one", height)
+		}
+	}
+
+	missing, ok, err := e.dbc.StateRootMissing()
+	if err != nil || !ok || missing != 99 {
+		t.Fatalf("StateRootMissing = %d %v %v, want 99", missing, ok, err)
+	}
+}
@@ -2,20 +2,22 @@ package explorer
 
 import (
 	"dogeuni-indexer/models"
+	"dogeuni-indexer/router"
 	"dogeuni-indexer/utils"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/dogecoinw/doged/btcjson"
-	"github.com/dogecoinw/doged/chaincfg/chainhash"
 	"github.com/dogecoinw/go-dogecoin/log"
-	"github.com/google/uuid"
+	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 	"math/big"
 )
 
 func (e *Explorer) swapV2RouterDecode(tx *btcjson.TxRawResult, height int64) ([]*models.SwapV2Info, error) {
 
+	protocol := e.dbc.ProtocolParams(height)
+
 	err := e.dbc.DB.Where("tx_hash = ?", tx.Hash).First(&models.SwapV2Info{}).Error
 	if !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, fmt.Errorf("swap already exist or err %s", tx.Hash)
@@ -69,32 +71,37 @@ func (e *Explorer) swapV2RouterDecode(tx *btcjson.TxRawResult, height int64) ([]
 			}
 		}
 
-		swap.OrderId = uuid.New().String()
 		swap.FeeTxHash = in.Txid
 		swap.FeeTxIndex = in.Vout
 		swap.TxHash = tx.Hash
 		swap.TxIndex = i
+		swap.OrderId = utils.OrderId(swap.TxHash, swap.TxIndex, utils.SubOpInscription)
 		swap.BlockHash = tx.BlockHash
 		swap.BlockNumber = height
 		swap.BlockTime = tx.Blocktime
-		swap.HolderAddress = tx.Vout[0].ScriptPubKey.Addresses[0]
+		swap.HolderAddress, err = OutputAddress(tx, 0)
+		if err != nil {
+			return nil, err
+		}
+
 		swap.OrderStatus = 1
 
-		txhash0, _ := chainhash.NewHashFromStr(swap.FeeTxHash)
-		txRawResult0, err := e.node.GetRawTransactionVerboseBool(txhash0)
+		_, txRawResult0, err := e.SpentOutput(tx, i)
 		if err != nil {
-			return nil, CHAIN_NETWORK_ERR
+			return nil, err
 		}
 
-		swap.FeeAddress = txRawResult0.Vout[swap.FeeTxIndex].ScriptPubKey.Addresses[0]
+		swap.FeeAddress, err = OutputAddress(txRawResult0, int(swap.FeeTxIndex))
+		if err != nil {
+			return nil, err
+		}
 
-		txhash1, _ := chainhash.NewHashFromStr(txRawResult0.Vin[0].Txid)
-		txRawResult1, err := e.node.GetRawTransactionVerboseBool(txhash1)
+		previous, _, err := e.InputAddress(txRawResult0, 0)
 		if err != nil {
-			return nil, CHAIN_NETWORK_ERR
+			return nil, err
 		}
 
-		if swap.HolderAddress != txRawResult1.Vout[txRawResult0.Vin[0].Vout].ScriptPubKey.Addresses[0] {
+		if swap.HolderAddress != previous {
 			return nil, fmt.Errorf("the address is not the same as the previous transaction")
 		}
 
@@ -111,27 +118,16 @@ func (e *Explorer) swapV2RouterDecode(tx *btcjson.TxRawResult, height int64) ([]
 			return nil, fmt.Errorf("mint op error, vout length is not 3")
 		}
 
-		fee := big.NewInt(0)
-		fee.Mul(dogeDepositAmt, big.NewInt(3))
-		fee.Div(fee, big.NewInt(1000))
-		if fee.Cmp(big.NewInt(50000000)) == -1 {
-			fee = big.NewInt(50000000)
-		}
-
-		if utils.Float64ToBigInt(tx.Vout[1].Value*100000000).Cmp(dogeDepositAmt) < 0 {
-			return nil, fmt.Errorf("the amount of tokens is incorrect %f %s", tx.Vout[1].Value, utils.Float64ToBigInt(tx.Vout[1].Value*100000000).String())
-		}
-
-		if tx.Vout[1].ScriptPubKey.Addresses[0] != wdogeCoolAddress {
-			return nil, fmt.Errorf("the address is incorrect")
-		}
+		fee := depositFee(dogeDepositAmt)
 
-		if utils.Float64ToBigInt(tx.Vout[2].Value*100000000).Cmp(fee) < 0 {
-			return nil, fmt.Errorf("the amount of tokens is incorrect fee %f", tx.Vout[2].Value)
+		err = payment(tx, 1, protocol.WDogeCoolAddress, dogeDepositAmt)
+		if err != nil {
+			return nil, err
 		}
 
-		if tx.Vout[2].ScriptPubKey.Addresses[0] != wdogeFeeAddress {
-			return nil, fmt.Errorf("the address is incorrect")
+		err = payment(tx, 2, protocol.WDogeFeeAddress, fee)
+		if err != nil {
+			return nil, err
 		}
 	}
 
@@ -274,3 +270,61 @@ func (e *Explorer) swapV2Fork(tx *gorm.DB, height int64) error {
 
 	return err
 }
+
+type swapV2Handler struct{}
+
+func (swapV2Handler) Name() string {
+	return "pair-v2"
+}
+
+func (swapV2Handler) Decode(e *Explorer, tx *btcjson.TxRawResult, txIndex int, pushedData []byte, height int64) (interface{}, error) {
+	return e.swapV2RouterDecode(tx, height)
+}
+
+func (swapV2Handler) Verify(e *Explorer, inscription interface{}) error {
+	// verified inside the execute transaction
+	return nil
+}
+
+func (swapV2Handler) Execute(e *Explorer, inscription interface{}) error {
+	return e.executePairV2(inscription.([]*models.SwapV2Info))
+}
+
+func (swapV2Handler) Fork(e *Explorer, tx *gorm.DB, height int64) error {
+	return e.swapV2Fork(tx, height)
+}
+
+func (swapV2Handler) WholeTx() {}
+
+func (swapV2Handler) LoadReplay(e *Explorer, txHash string) (interface{}, error) {
+	swaps := make([]*models.SwapV2Info, 0)
+	err := e.dbc.DB.Where("tx_hash = ?", txHash).Order("tx_index asc").Find(&swaps).Error
+	if err != nil {
+		return nil, fmt.Errorf("load swaps err: %s", err.Error())
+	}
+	return swaps, nil
+}
+
+func (swapV2Handler) InfoModel() interface{} {
+	return &models.SwapV2Info{}
+}
+
+func (swapV2Handler) InfoTables() []interface{} {
+	return []interface{}{&models.SwapV2Info{}}
+}
+
+func (swapV2Handler) RevertTables() []interface{} {
+	return []interface{}{&models.SwapV2Revert{}}
+}
+
+func (swapV2Handler) Routes(rg *gin.RouterGroup, deps *RouteDeps) {
+	swapV2Router := router.NewSwapV2Router(deps.DBC, deps.Node)
+	rg.POST("/swap_v2/order", deps.Pending.Order("pair-v2", swapV2Router.Order))
+	rg.POST("/swap_v2/liquidity", swapV2Router.Liquidity)
+	rg.POST("/swap_v2/liquidity/address", swapV2Router.SwapLiquidityHolder)
+	rg.POST("/swap_v2/price", swapV2Router.SwapPrice)
+
+	// pair-v2 shares the pump k-line
+	pumpRouter := router.NewPumpRouter(deps.DBC, deps.Node)
+	rg.POST("/swap_v2/k", pumpRouter.K)
+}
@@ -51,7 +51,7 @@ func (v *Verifys) verifyConsensusStake(c *models.ConsensusInfo) error {
 	}
 	// Pre-stake validation: check if CARDI balance is sufficient
 	holder := &models.Drc20CollectAddress{}
-	if err := v.dbc.DB.Where("tick = ? and holder_address = ?", "CARDI", c.HolderAddress).First(holder).Error; err != nil {
+	if err := v.dbc.DB.Where("tick = ? and holder_address = ?", v.dbc.ProtocolParams(c.BlockNumber).ConsensusTick, c.HolderAddress).First(holder).Error; err != nil {
 		return fmt.Errorf("the contract does not exist err %s", err.Error())
 	}
 	if c.Amt.Int().Cmp(holder.AmtSum.Int()) > 0 {
@@ -469,7 +469,7 @@ func (v *Verifys) verifyNFTDeploy(nft *models.NftInfo) error {
 	}
 
 	cardA0 := &models.Drc20CollectAddress{}
-	err = v.dbc.DB.Where("tick = ? and holder_address = ?", "CARDI", nft.HolderAddress).First(cardA0).Error
+	err = v.dbc.DB.Where("tick = ? and holder_address = ?", v.dbc.ProtocolParams(nft.BlockNumber).ConsensusTick, nft.HolderAddress).First(cardA0).Error
 	if err != nil {
 		return errors.New("Deploying AI/NFT requires holding 8400 CARDI for deployment. Please note that holding is only for identity verification and will not affect your assets.")
 	}
@@ -1412,27 +1412,3 @@ func (v *Verifys) verifyPumpTrade(tx *gorm.DB, pump *models.PumpInfo) error {
 
 	return nil
 }
-
-func (v *Verifys) VerifyInvite(invite *models.InviteInfo) error {
-	switch invite.Op {
-	case "deploy":
-		return v.verifyInviteDeploy(invite)
-	default:
-		return fmt.Errorf("do not support the type of tokens")
-	}
-}
-
-func (v *Verifys) verifyInviteDeploy(invite *models.InviteInfo) error {
-
-	if invite.InviteAddress == invite.HolderAddress {
-		return fmt.Errorf("the same address cannot be invited")
-	}
-
-	invitec := &models.InviteCollect{}
-	err := v.dbc.DB.Where("holder_address = ? ", invite.HolderAddress).First(invitec).Error
-	if err == nil {
-		return fmt.Errorf("already invited")
-	}
-
-	return nil
-}
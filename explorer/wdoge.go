@@ -2,20 +2,21 @@ package explorer
 
 import (
 	"dogeuni-indexer/models"
+	"dogeuni-indexer/router"
 	"dogeuni-indexer/utils"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/dogecoinw/doged/btcjson"
-	"github.com/dogecoinw/doged/chaincfg/chainhash"
-	"github.com/google/uuid"
+	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
-	"math/big"
 )
 
-func (e *Explorer) wdogeDecode(tx *btcjson.TxRawResult, pushedData []byte, number int64) (*models.WDogeInfo, error) {
+func (e *Explorer) wdogeDecode(tx *btcjson.TxRawResult, txIndex int, pushedData []byte, number int64) (*models.WDogeInfo, error) {
 
-	err := e.dbc.DB.Where("tx_hash = ?", tx.Txid).First(&models.WDogeInfo{}).Error
+	protocol := e.dbc.ProtocolParams(number)
+
+	err := e.dbc.DB.Where("tx_hash = ? and tx_index = ?", tx.Txid, txIndex).First(&models.WDogeInfo{}).Error
 	if !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, fmt.Errorf("wdoge already exist or err %s", tx.Txid)
 	}
@@ -42,51 +43,42 @@ func (e *Explorer) wdogeDecode(tx *btcjson.TxRawResult, pushedData []byte, numbe
 			return nil, fmt.Errorf("mint op error, vout length is not 3")
 		}
 
-		fee := big.NewInt(0)
-		fee.Mul(wdoge.Amt.Int(), big.NewInt(3))
-		fee.Div(fee, big.NewInt(1000))
-		if fee.Cmp(big.NewInt(50000000)) == -1 {
-			fee = big.NewInt(50000000)
-		}
+		fee := depositFee(wdoge.Amt.Int())
 
-		if utils.Float64ToBigInt(tx.Vout[1].Value*100000000).Cmp(wdoge.Amt.Int()) < 0 {
-			return nil, fmt.Errorf("the amount of tokens is incorrect %f %s", tx.Vout[1].Value, utils.Float64ToBigInt(tx.Vout[1].Value*100000000).String())
+		err = payment(tx, 1, protocol.WDogeCoolAddress, wdoge.Amt.Int())
+		if err != nil {
+			return nil, err
 		}
 
-		if tx.Vout[1].ScriptPubKey.Addresses[0] != wdogeCoolAddress {
-			return nil, fmt.Errorf("the address is incorrect")
-		}
-
-		if utils.Float64ToBigInt(tx.Vout[2].Value*100000000).Cmp(fee) < 0 {
-			return nil, fmt.Errorf("the amount of tokens is incorrect fee %f", tx.Vout[2].Value)
-		}
-
-		if tx.Vout[2].ScriptPubKey.Addresses[0] != wdogeFeeAddress {
-			return nil, fmt.Errorf("the address is incorrect")
+		err = payment(tx, 2, protocol.WDogeFeeAddress, fee)
+		if err != nil {
+			return nil, err
 		}
 	}
 
-	wdoge.OrderId = uuid.New().String()
 	wdoge.FeeTxHash = tx.Vin[0].Txid
 	wdoge.TxHash = tx.Hash
+	wdoge.TxIndex = txIndex
+	wdoge.OrderId = utils.OrderId(wdoge.TxHash, wdoge.TxIndex, utils.SubOpInscription)
 	wdoge.BlockHash = tx.BlockHash
 	wdoge.BlockNumber = number
 	wdoge.OrderStatus = 1
-	wdoge.HolderAddress = tx.Vout[0].ScriptPubKey.Addresses[0]
+	wdoge.HolderAddress, err = OutputAddress(tx, 0)
+	if err != nil {
+		return nil, err
+	}
 
-	txhash0, _ := chainhash.NewHashFromStr(tx.Vin[0].Txid)
-	txRawResult0, err := e.node.GetRawTransactionVerboseBool(txhash0)
+	_, txRawResult0, err := e.SpentOutput(tx, 0)
 	if err != nil {
-		return nil, CHAIN_NETWORK_ERR
+		return nil, err
 	}
 
-	txhash1, _ := chainhash.NewHashFromStr(txRawResult0.Vin[0].Txid)
-	txRawResult1, err := e.node.GetRawTransactionVerboseBool(txhash1)
+	previous, _, err := e.InputAddress(txRawResult0, 0)
 	if err != nil {
-		return nil, CHAIN_NETWORK_ERR
+		return nil, err
 	}
 
-	if wdoge.HolderAddress != txRawResult1.Vout[txRawResult0.Vin[0].Vout].ScriptPubKey.Addresses[0] {
+	if wdoge.HolderAddress != previous {
 		return nil, fmt.Errorf("the address is not the same as the previous transaction")
 	}
 
@@ -108,7 +100,7 @@ func (e *Explorer) wdogeDeposit(wdoge *models.WDogeInfo) error {
 		return err
 	}
 
-	err = tx.Model(&models.WDogeInfo{}).Where("tx_hash = ?", wdoge.TxHash).Update("order_status", 0).Error
+	err = tx.Model(&models.WDogeInfo{}).Where("tx_hash = ? and tx_index = ?", wdoge.TxHash, wdoge.TxIndex).Update("order_status", 0).Error
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -131,7 +123,7 @@ func (e *Explorer) wdogeWithdraw(wdoge *models.WDogeInfo) error {
 		return err
 	}
 
-	err = tx.Model(&models.WDogeInfo{}).Where("tx_hash = ?", wdoge.TxHash).Update("order_status", 0).Error
+	err = tx.Model(&models.WDogeInfo{}).Where("tx_hash = ? and tx_index = ?", wdoge.TxHash, wdoge.TxIndex).Update("order_status", 0).Error
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -147,8 +139,6 @@ func (e *Explorer) wdogeWithdraw(wdoge *models.WDogeInfo) error {
 
 func (e *Explorer) wdogeDepositSwap(dbtx *gorm.DB, wdoge *models.WDogeInfo) error {
 
-	wdoge.OrderId = uuid.New().String()
-
 	err := e.dbc.DogeDeposit(dbtx, wdoge)
 	if err != nil {
 		return err
@@ -176,3 +166,47 @@ func (e *Explorer) wdogeWithdrawSwap(dbtx *gorm.DB, wdoge *models.WDogeInfo) err
 
 	return nil
 }
+
+type wdogeHandler struct{}
+
+func (wdogeHandler) Name() string {
+	return "wdoge"
+}
+
+func (wdogeHandler) Decode(e *Explorer, tx *btcjson.TxRawResult, txIndex int, pushedData []byte, height int64) (interface{}, error) {
+	return e.wdogeDecode(tx, txIndex, pushedData, height)
+}
+
+func (wdogeHandler) Verify(e *Explorer, inscription interface{}) error {
+	err := e.verify.VerifyWDoge(inscription.(*models.WDogeInfo))
+	if err != nil {
+		return fmt.Errorf("VerifyWDoge err: %s", err.Error())
+	}
+	return nil
+}
+
+func (wdogeHandler) Execute(e *Explorer, inscription interface{}) error {
+	return e.executeWdoge(inscription.(*models.WDogeInfo))
+}
+
+func (wdogeHandler) Fork(e *Explorer, tx *gorm.DB, height int64) error {
+	// wdoge balances are reverted through drc-20
+	return nil
+}
+
+func (wdogeHandler) InfoModel() interface{} {
+	return &models.WDogeInfo{}
+}
+
+func (wdogeHandler) InfoTables() []interface{} {
+	return []interface{}{&models.WDogeInfo{}}
+}
+
+func (wdogeHandler) RevertTables() []interface{} {
+	return nil
+}
+
+func (wdogeHandler) Routes(rg *gin.RouterGroup, deps *RouteDeps) {
+	wdogeRouter := router.NewWdogeRouter(deps.DBC, deps.Node)
+	rg.POST("/wdoge/order", deps.Pending.Order("wdoge", wdogeRouter.Order))
+}
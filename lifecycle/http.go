@@ -0,0 +1,48 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+)
+
+type httpService struct {
+	srv *http.Server
+	m   *Manager
+}
+
+// HTTP serves srv. Stop drains the requests in flight with Shutdown.
+func HTTP(m *Manager, srv *http.Server) Service {
+	return &httpService{srv: srv, m: m}
+}
+
+func (s *httpService) Name() string {
+	return "http " + s.srv.Addr
+}
+
+// Start listens first, so a taken port fails the start instead of the
+// running process.
+func (s *httpService) Start() error {
+	addr := s.srv.Addr
+	if addr == "" {
+		addr = ":http"
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		err := s.srv.Serve(ln)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.m.Fail(s.Name(), err)
+		}
+	}()
+	return nil
+}
+
+func (s *httpService) Stop(ctx context.Context) error {
+	return s.srv.Shutdown(ctx)
+}
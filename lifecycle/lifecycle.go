@@ -0,0 +1,142 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/dogecoinw/go-dogecoin/log"
+	"sync"
+	"time"
+)
+
+// Service is one part of the process the Manager starts and stops.
+type Service interface {
+	Name() string
+	// Start returns once the service runs, work goes on in its own goroutines.
+	Start() error
+	// Stop returns once the service finished, or when ctx is done.
+	Stop(ctx context.Context) error
+}
+
+// Manager starts services in the order they were added and stops them in the
+// reverse order, so a service can use the ones added before it until it stops.
+type Manager struct {
+	services []Service
+	timeout  time.Duration
+	failed   chan error
+}
+
+// NewManager gives every Stop at most timeout.
+func NewManager(timeout time.Duration) *Manager {
+	return &Manager{
+		timeout: timeout,
+		failed:  make(chan error, 1),
+	}
+}
+
+func (m *Manager) Add(s Service) {
+	m.services = append(m.services, s)
+}
+
+// Fail stops the process because a running service failed.
+func (m *Manager) Fail(name string, err error) {
+	select {
+	case m.failed <- fmt.Errorf("%s: %s", name, err.Error()):
+	default:
+	}
+}
+
+// Run starts the services and blocks until ctx is done or one of them fails,
+// then stops the started ones. It returns the failures, nil on a clean stop.
+func (m *Manager) Run(ctx context.Context) error {
+	var errs []error
+	started := 0
+	for _, s := range m.services {
+		log.Info("lifecycle", "start", s.Name())
+		if err := s.Start(); err != nil {
+			errs = append(errs, fmt.Errorf("start %s: %s", s.Name(), err.Error()))
+			break
+		}
+		started++
+	}
+
+	if len(errs) == 0 {
+		select {
+		case <-ctx.Done():
+		case err := <-m.failed:
+			errs = append(errs, err)
+		}
+	}
+
+	errs = append(errs, m.stop(started)...)
+	return errors.Join(errs...)
+}
+
+// Abort stops every service added so far without starting them, for a setup
+// that fails before Run. Services hold what they were built with, like open
+// databases, from the time they are added.
+func (m *Manager) Abort() error {
+	return errors.Join(m.stop(len(m.services))...)
+}
+
+// stop stops the first n services in the reverse order.
+func (m *Manager) stop(n int) []error {
+	var errs []error
+	for i := n - 1; i >= 0; i-- {
+		s := m.services[i]
+		log.Info("lifecycle", "stop", s.Name())
+
+		stopCtx, cancel := context.WithTimeout(context.Background(), m.timeout)
+		err := s.Stop(stopCtx)
+		cancel()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("stop %s: %s", s.Name(), err.Error()))
+		}
+	}
+	return errs
+}
+
+type funcService struct {
+	name  string
+	start func() error
+	stop  func(ctx context.Context) error
+}
+
+// Func makes a Service of start and stop, either may be nil.
+func Func(name string, start func() error, stop func(ctx context.Context) error) Service {
+	return &funcService{name: name, start: start, stop: stop}
+}
+
+func (s *funcService) Name() string {
+	return s.name
+}
+
+func (s *funcService) Start() error {
+	if s.start == nil {
+		return nil
+	}
+	return s.start()
+}
+
+func (s *funcService) Stop(ctx context.Context) error {
+	if s.stop == nil {
+		return nil
+	}
+	return s.stop(ctx)
+}
+
+// Wait waits for wg, or returns the error of ctx when it is done first.
+func Wait(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
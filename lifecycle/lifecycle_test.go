@@ -0,0 +1,130 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func recorder(name string, events *[]string, startErr error) Service {
+	return Func(name, func() error {
+		*events = append(*events, "start "+name)
+		return startErr
+	}, func(ctx context.Context) error {
+		*events = append(*events, "stop "+name)
+		return nil
+	})
+}
+
+func TestManagerOrder(t *testing.T) {
+	events := make([]string, 0)
+	m := NewManager(time.Second)
+	m.Add(recorder("db", &events, nil))
+	m.Add(recorder("explorer", &events, nil))
+	m.Add(recorder("http", &events, nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := m.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"start db", "start explorer", "start http", "stop http", "stop explorer", "stop db"}
+	if !reflect.DeepEqual(events, want) {
+		t.Fatalf("events %v", events)
+	}
+}
+
+func TestManagerStartFailure(t *testing.T) {
+	events := make([]string, 0)
+	m := NewManager(time.Second)
+	m.Add(recorder("db", &events, nil))
+	m.Add(recorder("http", &events, errors.New("address in use")))
+	m.Add(recorder("never", &events, nil))
+
+	if err := m.Run(context.Background()); err == nil {
+		t.Fatal("a failed start should fail the run")
+	}
+
+	want := []string{"start db", "start http", "stop db"}
+	if !reflect.DeepEqual(events, want) {
+		t.Fatalf("events %v", events)
+	}
+}
+
+func TestManagerAbort(t *testing.T) {
+	events := make([]string, 0)
+	m := NewManager(time.Second)
+	m.Add(recorder("db", &events, nil))
+	m.Add(recorder("leveldb", &events, nil))
+
+	if err := m.Abort(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"stop leveldb", "stop db"}
+	if !reflect.DeepEqual(events, want) {
+		t.Fatalf("events %v", events)
+	}
+}
+
+func TestHTTPDrainsRequests(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+		io.WriteString(w, "done")
+	})
+
+	m := NewManager(5 * time.Second)
+	m.Add(HTTP(m, &http.Server{Addr: addr, Handler: handler}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	run := make(chan error, 1)
+	go func() { run <- m.Run(ctx) }()
+
+	body := make(chan string, 1)
+	go func() {
+		var resp *http.Response
+		var err error
+		for i := 0; i < 50; i++ {
+			resp, err = http.Get("http://" + addr)
+			if err == nil {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		body <- string(data)
+	}()
+
+	<-entered
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	if got := <-body; got != "done" {
+		t.Fatalf("in-flight request got %q", got)
+	}
+	if err := <-run; err != nil {
+		t.Fatal(err)
+	}
+}
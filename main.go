@@ -2,38 +2,79 @@ package main
 
 import (
 	"context"
+	"dogeuni-indexer/chain"
 	"dogeuni-indexer/config"
 	"dogeuni-indexer/explorer"
+	_ "dogeuni-indexer/explorer/invite"
+	"dogeuni-indexer/lifecycle"
+	"dogeuni-indexer/metrics"
+	"dogeuni-indexer/params"
 	"dogeuni-indexer/router"
 	"dogeuni-indexer/router_v3"
 	"dogeuni-indexer/storage"
 	"dogeuni-indexer/storage_v3"
+	"github.com/dogecoinw/doged/chaincfg/chainhash"
 	"github.com/dogecoinw/doged/rpcclient"
 	"github.com/dogecoinw/go-dogecoin/log"
 	"github.com/gin-gonic/gin"
 	shell "github.com/ipfs/go-ipfs-api"
+	"net/http"
 	_ "net/http/pprof"
 	"os"
 	"os/signal"
 	"sync"
 	"syscall"
+	"time"
 )
 
+// shutdownTimeout bounds each service's stop. A block still being applied
+// after it is rolled back from the journal on the next start.
+const shutdownTimeout = 30 * time.Second
+
 var (
 	cfg config.Config
 )
 
 func main() {
 
+	if len(os.Args) > 1 && os.Args[1] == "reindex" {
+		reindex(os.Args[2:])
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "snapshot" {
+		snapshotCmd(os.Args[2:])
+		return
+	}
+
 	// Load configuration file
 	config.LoadConfig(&cfg, "")
+	setupLog()
 
-	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(true)))
-	glogger.Verbosity(log.Lvl(cfg.DebugLevel))
-	log.Root().SetHandler(glogger)
+	netParams, err := chain.NetParams(cfg.Chain.ChainName)
+	if err != nil {
+		log.Error("main", "NetParams", err.Error())
+		os.Exit(1)
+	}
+
+	protocolParams, err := params.Load(cfg.Explorer.Params, params.Mainnet, netParams, explorer.ProtocolNames())
+	if err != nil {
+		log.Error("main", "params.Load", err.Error())
+		os.Exit(1)
+	}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	wg := &sync.WaitGroup{}
+	// services stop in the reverse order they are added: the API drains
+	// first, then the explorer finishes its block, then the stores close
+	mgr := lifecycle.NewManager(shutdownTimeout)
+
+	// a failed setup closes what was opened so far and exits like a failed run
+	fail := func(name string, err error) {
+		log.Error("main", name, err.Error())
+		if err := mgr.Abort(); err != nil {
+			log.Error("main", "lifecycle", err.Error())
+		}
+		os.Exit(1)
+	}
 
 	mysqlClient := storage_v3.NewSqliteClient(cfg.Sqlite)
 
@@ -43,32 +84,96 @@ func main() {
 	} else {
 		dbClient = storage.NewMysqlClient(cfg.Mysql)
 	}
+	dbClient.SetNetParams(netParams)
+	dbClient.SetProtocolParams(protocolParams)
+	mgr.Add(lifecycle.Func("db", nil, func(ctx context.Context) error {
+		dbClient.Stop()
+		if mysqlClient != nil {
+			mysqlClient.Stop()
+		}
+		return nil
+	}))
+
+	// the http routes and the chain cache share the one LevelDB
+	var levelClient *storage.LevelDB
+	if cfg.HttpServer.Switch || cfg.LevelDB.ChainCache > 0 {
+		levelClient = storage.NewLevelDB(cfg.LevelDB)
+		mgr.Add(lifecycle.Func("leveldb", nil, func(ctx context.Context) error {
+			levelClient.Stop()
+			return nil
+		}))
+	}
 
-	connCfg := &rpcclient.ConnConfig{
-		Host:         cfg.Chain.Rpc,
-		Endpoint:     "ws",
-		User:         cfg.Chain.UserName,
-		Pass:         cfg.Chain.PassWord,
-		HTTPPostMode: true, // Bitcoin core only supports HTTP POST mode
-		DisableTLS:   true, // Bitcoin core does not provide TLS by default
+	// Notifications are only delivered over a websocket connection, for
+	// nodes that serve one. HTTP POST mode keeps the handlers nil.
+	var exp *explorer.Explorer
+	var handlers *rpcclient.NotificationHandlers
+	if cfg.Chain.Notify == "ws" && cfg.Chain.Archive == "" {
+		handlers = &rpcclient.NotificationHandlers{
+			OnBlockConnected: func(hash *chainhash.Hash, height int32, t time.Time) {
+				if exp != nil {
+					exp.Notify()
+				}
+			},
+		}
 	}
 
-	// Notice the notification parameter is nil since notifications are
-	// not supported in HTTP POST mode.
-	rpcClient, _ := rpcclient.New(connCfg, nil)
+	// recorded blocks replace the node, for offline syncs from an archive
+	var node chain.ChainSource
+	var rpcClient *rpcclient.Client
+	if cfg.Chain.Archive != "" {
+		fileSource, err := chain.NewFileSource(cfg.Chain.Archive)
+		if err != nil {
+			fail("NewFileSource", err)
+		}
+		node = fileSource
+	} else {
+		var err error
+		rpcClient, err = chain.NewRPCSource(cfg.Chain, handlers)
+		if err != nil {
+			fail("rpcclient.New", err)
+		}
+
+		mgr.Add(lifecycle.Func("rpc", nil, func(ctx context.Context) error {
+			rpcClient.Shutdown()
+			rpcClient.WaitForShutdown()
+			return nil
+		}))
+
+		node = rpcClient
+		if cfg.LevelDB.ChainCache > 0 {
+			node = chain.NewCache(node, levelClient, uint64(cfg.LevelDB.ChainCache)<<20)
+		}
+		if cfg.Chain.Record != "" {
+			node = chain.NewRecorder(node, cfg.Chain.Record)
+		}
+	}
 
 	ipfs := shell.NewShell(cfg.Ipfs)
 
 	if cfg.Explorer.Switch {
-		exp := explorer.NewExplorer(ctx, wg, rpcClient, dbClient, ipfs, cfg.Explorer.FromBlock)
-		wg.Add(1)
-		go exp.Start()
+		ctx, cancel := context.WithCancel(context.Background())
+		wg := &sync.WaitGroup{}
+		exp = explorer.NewExplorer(ctx, wg, &cfg, node, dbClient, ipfs)
+
+		mgr.Add(lifecycle.Func("explorer", func() error {
+			wg.Add(1)
+			go exp.Start()
+
+			if handlers != nil && rpcClient != nil {
+				if err := rpcClient.NotifyBlocks(); err != nil {
+					log.Error("main", "NotifyBlocks", err.Error())
+				}
+			}
+			return nil
+		}, func(ctx context.Context) error {
+			cancel()
+			return lifecycle.Wait(ctx, wg)
+		}))
 	}
 
 	if cfg.HttpServer.Switch {
 
-		levelClient := storage.NewLevelDB(cfg.LevelDB)
-
 		grt := gin.Default()
 		grt.Use(func(c *gin.Context) {
 			c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
@@ -82,8 +187,18 @@ func main() {
 			}
 			c.Next()
 		})
+		grt.Use(metrics.Gin())
+		grt.GET("/metrics", gin.WrapH(metrics.Handler()))
 
-		rt := router_v3.NewRouter(mysqlClient, dbClient, levelClient, rpcClient, ipfs)
+		var scan router.ScanState
+		if exp != nil {
+			scan = exp
+		}
+		healthRouter := router.NewHealthRouter(dbClient, node, scan, cfg.HttpServer.ReadyLag)
+		grt.GET("/healthz", healthRouter.Healthz)
+		grt.GET("/readyz", healthRouter.Readyz)
+
+		rt := router_v3.NewRouter(mysqlClient, dbClient, levelClient, node, ipfs)
 
 		grt.POST("/v3/info/lastnumber", rt.LastNumber)
 
@@ -161,132 +276,42 @@ func main() {
 		v4 := grt.Group("/v4")
 		{
 
-			infoRouter := router.NewInfoRouter(dbClient, rpcClient, levelClient, ipfs)
+			infoRouter := router.NewInfoRouter(dbClient, node, levelClient, ipfs)
 			v4.POST("/info/lastnumber", infoRouter.LastNumber)
 			v4.POST("/info/blocknumber", infoRouter.BlockNumber)
+			v4.POST("/info/decode-failures", infoRouter.DecodeFailures)
+			v4.GET("/info/state-root", infoRouter.StateRoot)
+			v4.POST("/info/reorgs", infoRouter.ReorgEvents)
+
+			deps := &explorer.RouteDeps{
+				DBC:     dbClient,
+				Node:    node,
+				Level:   levelClient,
+				Ipfs:    ipfs,
+				Pending: router.NewPendingRouter(dbClient),
+			}
 
-			drc20Router := router.NewDrc20Router(dbClient, rpcClient, levelClient, ipfs)
-			v4.POST("/drc20/order", drc20Router.Order)
-			v4.POST("/drc20/collect", drc20Router.Collect)
-			v4.POST("/drc20/collect-address", drc20Router.CollectAddress)
-			v4.POST("/drc20/history", drc20Router.History)
-
-			swapRouter := router.NewSwapRouter(dbClient, rpcClient)
-			v4.POST("/swap/order", swapRouter.Order)
-			v4.POST("/swap/liquidity", swapRouter.SwapLiquidity)
-			v4.POST("/swap/liquidity/address", swapRouter.SwapLiquidityHolder)
-			v4.POST("/swap/price", swapRouter.SwapPrice)
-			v4.POST("/swap/k", swapRouter.SwapK)
-			v4.POST("/swap/tvl", swapRouter.SwapTvl)
-			v4.POST("/swap/tvl/total", swapRouter.SwapSummaryTvlTotal)
-			v4.POST("/swap/summary", swapRouter.SwapSummary)
-			v4.POST("/swap/pair", swapRouter.SwapPair)
-
-			// exchange
-			exchangeRouter := router.NewExchangeRouter(dbClient, rpcClient)
-			v4.POST("/exchange/order", exchangeRouter.Order)
-			v4.POST("/exchange/collect", exchangeRouter.Collect)
-			v4.POST("/exchange/summary", exchangeRouter.Summary)
-			v4.POST("/exchange/summary/total", exchangeRouter.SummaryTotal)
-			v4.POST("/exchange/k", exchangeRouter.SummaryK)
-
-			// box
-			boxRouter := router.NewBoxRouter(dbClient, rpcClient)
-			v4.POST("/box/order", boxRouter.Order)
-			v4.POST("/box/collect", boxRouter.Collect)
-
-			// wdoge
-			wdogeRouter := router.NewWdogeRouter(dbClient, rpcClient)
-			v4.POST("/wdoge/order", wdogeRouter.Order)
-
-			// stake
-			stakeRouter := router.NewStakeRouter(dbClient, rpcClient)
-			v4.POST("/stake/order", stakeRouter.Order)
-			v4.POST("/stake/collect", stakeRouter.Collect)
-			v4.POST("/stake/collect-address", stakeRouter.CollectAddress)
-			v4.POST("/stake/reward", stakeRouter.Reward)
-			v4.POST("/stake/total", stakeRouter.Total)
-
-			// nft
-			nftRouter := router.NewNftRouter(dbClient, rpcClient)
-			v4.POST("/nft/order", nftRouter.Order)
-			v4.POST("/nft/collect", nftRouter.Collect)
-			v4.POST("/nft/collect-address", nftRouter.CollectAddress)
-
-			// file
-			fileRouter := router.NewFileRouter(dbClient, rpcClient, ipfs)
-			v4.POST("/file/order", fileRouter.Order)
-			v4.POST("/file/collect-address", fileRouter.CollectAddress)
-
-			v4.POST("/file/upload/meta", fileRouter.UploadMeta)
-			v4.POST("/file/upload/inscriptions/meta", fileRouter.UploadInscriptionsMeta)
-
-			v4.POST("/file/collections", fileRouter.Collections)
-			v4.POST("/file/collections/inscriptions", fileRouter.CollectionsInscriptions)
-			v4.POST("/file/collections/attributes", fileRouter.CollectionsAttributes)
-
-			// file exchange
-			fileExchangeRouter := router.NewFileExchangeRouter(dbClient, rpcClient, ipfs)
-			v4.POST("/file-exchange/order", fileExchangeRouter.Order)
-			v4.POST("/file-exchange/activity", fileExchangeRouter.Activity)
-			v4.POST("/file-exchange/collect", fileExchangeRouter.Collect)
-			v4.POST("/file-exchange/summary/all", fileExchangeRouter.SummaryAll)
-			v4.POST("/file-exchange/summary/nft/all", fileExchangeRouter.SummaryAll)
-			v4.POST("/file-exchange/inscriptions", fileExchangeRouter.Inscriptions)
-
-			// cross
-			crossRouter := router.NewCrossRouter(dbClient, rpcClient)
-			v4.POST("/cross/order", crossRouter.Order)
-			v4.POST("/cross/collect", crossRouter.Collect)
-			// meme20
-			meme20Router := router.NewMeme20Router(dbClient, rpcClient, levelClient)
-			v4.POST("/meme20/order", meme20Router.Order)
-			v4.POST("/meme20/collect", meme20Router.Collect)
-			v4.POST("/meme20/collect-address", meme20Router.CollectAddress)
-			v4.POST("/meme20/history", meme20Router.History)
-
-			// pump
-			pumpRouter := router.NewPumpRouter(dbClient, rpcClient)
-			v4.POST("/pump/order", pumpRouter.Order)
-			v4.POST("/pump/mergeorder", pumpRouter.MergeOrder)
-			v4.POST("/pump/liquidity", pumpRouter.Liquidity)
-			v4.POST("/pump/board", pumpRouter.Board)
-			v4.POST("/pump/k", pumpRouter.K)
-			v4.POST("/pump/king", pumpRouter.King)
-
-			// swapv2
-			swapV2Router := router.NewSwapV2Router(dbClient, rpcClient)
-			v4.POST("/swap_v2/order", swapV2Router.Order)
-			v4.POST("/swap_v2/liquidity", swapV2Router.Liquidity)
-			v4.POST("/swap_v2/liquidity/address", swapV2Router.SwapLiquidityHolder)
-			v4.POST("/swap_v2/price", swapV2Router.SwapPrice)
-			v4.POST("/swap_v2/k", pumpRouter.K)
-
-			// invite
-			inviteRouter := router.NewInviteRouter(dbClient, rpcClient, levelClient)
-			v4.POST("/invite/order", inviteRouter.Order)
-			v4.POST("/invite/collect", inviteRouter.Collect)
-			v4.POST("/invite/pump-reword", inviteRouter.PumpReward)
-			v4.POST("/invite/pump-reword-total", inviteRouter.PumpRewardTotal)
-			// consensus
-			consensusRouter := router.NewConsensusRouter(dbClient, rpcClient)
-			v4.POST("/consensus/order", consensusRouter.Order)
-			v4.POST("/consensus/records", consensusRouter.Records)
-			v4.POST("/consensus/score", consensusRouter.Score)
+			for _, h := range explorer.Protocols() {
+				h.Routes(v4, deps)
+			}
 		}
 
-		err := grt.Run(cfg.HttpServer.Server)
-		if err != nil {
-			panic(err)
-		}
+		mgr.Add(lifecycle.HTTP(mgr, &http.Server{Addr: cfg.HttpServer.Server, Handler: grt}))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err = mgr.Run(ctx)
+	if err != nil {
+		log.Error("main", "lifecycle", err.Error())
+		os.Exit(1)
 	}
+	log.Info("main", "stopped", "clean")
+}
 
-	c := make(chan os.Signal)
-	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
-	go func() {
-		<-c
-		println("\nReceived an interrupt, stopping services...")
-		cancel()
-	}()
-	wg.Wait()
+func setupLog() {
+	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(true)))
+	glogger.Verbosity(log.Lvl(cfg.DebugLevel))
+	log.Root().SetHandler(glogger)
 }
@@ -0,0 +1,28 @@
+package metrics
+
+import (
+	"github.com/gin-gonic/gin"
+	"strconv"
+	"time"
+)
+
+// Gin times every request by its route pattern, so path parameters do not
+// make new series. Requests matching no route count under "unmatched".
+func Gin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		status := c.Writer.Status()
+		code := strconv.Itoa(status)
+		HTTPSeconds.WithLabelValues(c.Request.Method, route, code).Observe(time.Since(start).Seconds())
+		if status >= 400 {
+			HTTPErrors.WithLabelValues(c.Request.Method, route, code).Inc()
+		}
+	}
+}
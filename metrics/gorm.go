@@ -0,0 +1,38 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"time"
+)
+
+// gormLogger times the queries gorm traces and hands them on to the logger
+// it wraps.
+type gormLogger struct {
+	logger.Interface
+}
+
+// GormLogger wraps l to record DBSeconds.
+func GormLogger(l logger.Interface) logger.Interface {
+	return &gormLogger{Interface: l}
+}
+
+func (l *gormLogger) LogMode(level logger.LogLevel) logger.Interface {
+	return &gormLogger{Interface: l.Interface.LogMode(level)}
+}
+
+// Trace leaves fc to the wrapped logger, rendering the SQL of every query
+// would cost more than the timing is worth.
+func (l *gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	result := "ok"
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		result = "not_found"
+	} else if err != nil {
+		result = "error"
+	}
+	DBSeconds.WithLabelValues(result).Observe(time.Since(begin).Seconds())
+
+	l.Interface.Trace(ctx, begin, fc, err)
+}
@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+)
+
+// Registry holds the metrics served at /metrics.
+var Registry = prometheus.NewRegistry()
+
+var (
+	IndexHeight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "dogeuni_explorer_index_height",
+		Help: "Height of the last block indexed.",
+	})
+	ChainHeight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "dogeuni_explorer_chain_height",
+		Help: "Block count of the node at the last scan.",
+	})
+	Lag = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "dogeuni_explorer_lag_blocks",
+		Help: "Blocks below the node's block count not indexed yet.",
+	})
+	Forks = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "dogeuni_explorer_forks_total",
+		Help: "Reorgs rolled back.",
+	})
+
+	BlockSeconds = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "dogeuni_explorer_block_seconds",
+		Help:    "Time to index one block.",
+		Buckets: prometheus.ExponentialBuckets(0.01, 2, 14),
+	})
+	ExecuteSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "dogeuni_explorer_execute_seconds",
+		Help:    "Time to execute one inscription, by protocol.",
+		Buckets: prometheus.ExponentialBuckets(0.0005, 2, 14),
+	}, []string{"p"})
+	DecodeErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "dogeuni_explorer_decode_errors_total",
+		Help: "Inscriptions that failed to decode, by protocol.",
+	}, []string{"p"})
+	VerifyErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "dogeuni_explorer_verify_errors_total",
+		Help: "Inscriptions gated off or rejected by verify, by protocol.",
+	}, []string{"p"})
+
+	HTTPSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "dogeuni_http_request_seconds",
+		Help:    "Latency of the API, by route and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "code"})
+	HTTPErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "dogeuni_http_errors_total",
+		Help: "API responses with a 4xx or 5xx status, by route.",
+	}, []string{"method", "route", "code"})
+
+	DBSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "dogeuni_db_query_seconds",
+		Help:    "Database query time as traced by gorm, by result.",
+		Buckets: prometheus.ExponentialBuckets(0.0001, 4, 10),
+	}, []string{"result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		IndexHeight, ChainHeight, Lag, Forks,
+		BlockSeconds, ExecuteSeconds, DecodeErrors, VerifyErrors,
+		HTTPSeconds, HTTPErrors, DBSeconds,
+	)
+}
+
+// SetHeights records the last indexed block against the block count of the
+// node. The scanner indexes the blocks below the count, the lag is the number
+// of them still to go.
+func SetHeights(index, chain int64) {
+	IndexHeight.Set(float64(index))
+	ChainHeight.Set(float64(chain))
+	Lag.Set(float64(chain - 1 - index))
+}
+
+// Handler serves Registry in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGinRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	grt := gin.New()
+	grt.Use(Gin())
+	grt.GET("/metrics", gin.WrapH(Handler()))
+	grt.POST("/v4/drc20/:tick", func(c *gin.Context) { c.Status(http.StatusOK) })
+	grt.POST("/v4/fail", func(c *gin.Context) { c.Status(http.StatusInternalServerError) })
+
+	for _, path := range []string{"/v4/drc20/CARDI", "/v4/drc20/WDOGE", "/v4/fail", "/v4/missing"} {
+		grt.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, path, nil))
+	}
+
+	if n := testutil.CollectAndCount(HTTPSeconds); n != 3 {
+		t.Fatalf("%d latency series, want one per route and code", n)
+	}
+	if v := testutil.ToFloat64(HTTPErrors.WithLabelValues(http.MethodPost, "/v4/fail", "500")); v != 1 {
+		t.Fatalf("errors %v", v)
+	}
+	if v := testutil.ToFloat64(HTTPErrors.WithLabelValues(http.MethodPost, "unmatched", "404")); v != 1 {
+		t.Fatalf("unmatched errors %v", v)
+	}
+
+	SetHeights(99, 110)
+	w := httptest.NewRecorder()
+	grt.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if !strings.Contains(w.Body.String(), "dogeuni_explorer_lag_blocks 10") {
+		t.Fatalf("lag missing from\n%s", w.Body.String())
+	}
+}
+
+func TestGormLogger(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: GormLogger(logger.Discard)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type row struct {
+		ID int64
+	}
+	if err := db.AutoMigrate(&row{}); err != nil {
+		t.Fatal(err)
+	}
+
+	before := queries(t, "not_found")
+	db.First(&row{})
+	if n := queries(t, "not_found"); n != before+1 {
+		t.Fatalf("not_found queries %d, want %d", n, before+1)
+	}
+}
+
+func queries(t *testing.T, result string) uint64 {
+	m := &dto.Metric{}
+	err := DBSeconds.WithLabelValues(result).(prometheus.Metric).Write(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
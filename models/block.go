@@ -3,8 +3,52 @@ package models
 type Block struct {
 	BlockNumber int64  `gorm:"primarykey" json:"block_number"`
 	BlockHash   string `json:"block_hash"`
+	BlockTime   int64  `json:"block_time"`
+	StateRoot   string `gorm:"size:64" json:"state_root"`
 }
 
 func (Block) TableName() string {
 	return "block"
 }
+
+// BlockJournal marks a block whose application started. It is removed together
+// with the Block row being saved, one left behind belongs to a block that was
+// cut short.
+type BlockJournal struct {
+	BlockNumber int64  `gorm:"primarykey" json:"block_number"`
+	BlockHash   string `json:"block_hash"`
+}
+
+func (BlockJournal) TableName() string {
+	return "block_journal"
+}
+
+// BlockInscription is the position of a decoded inscription in its block, the
+// order a replay applies the *_info rows in.
+type BlockInscription struct {
+	ID          uint   `gorm:"primarykey" json:"id"`
+	BlockNumber int64  `gorm:"index" json:"block_number"`
+	Seq         int    `json:"seq"`
+	P           string `json:"p"`
+	TxHash      string `gorm:"size:64" json:"tx_hash"`
+	TxIndex     int    `json:"tx_index"`
+}
+
+func (BlockInscription) TableName() string {
+	return "block_inscription"
+}
+
+// StateChange is a balance or liquidity value left by a block, in the order the
+// block wrote it. The block's state root hashes its changes.
+type StateChange struct {
+	ID            uint   `gorm:"primarykey" json:"id"`
+	BlockNumber   int64  `gorm:"index" json:"block_number"`
+	Kind          string `json:"kind"`
+	Key           string `gorm:"column:key_" json:"key"`
+	HolderAddress string `json:"holder_address"`
+	Value         string `json:"value"`
+}
+
+func (StateChange) TableName() string {
+	return "state_change"
+}
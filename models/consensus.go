@@ -13,6 +13,7 @@ type ConsensusInfo struct {
 	FeeAddress    string    `json:"fee_address"`    // 手续费地址
 	FeeTxHash     string    `json:"fee_tx_hash"`    // 手续费交易哈希
 	TxHash        string    `json:"tx_hash"`
+	TxIndex       int       `gorm:"default:0" json:"tx_index"`
 	BlockNumber   int64     `json:"block_number"`
 	BlockHash     string    `json:"block_hash"`
 	ErrInfo       string    `json:"err_info"` // 错误信息
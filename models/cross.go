@@ -14,6 +14,7 @@ type CrossInfo struct {
 	FeeAddress    string    `json:"fee_address"`
 	FeeTxHash     string    `json:"fee_tx_hash"`
 	TxHash        string    `json:"tx_hash"`
+	TxIndex       int       `gorm:"default:0" json:"tx_index"`
 	BlockNumber   int64     `json:"block_number"`
 	BlockHash     string    `json:"block_hash"`
 	ErrInfo       string    `json:"err_info"`
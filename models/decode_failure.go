@@ -0,0 +1,17 @@
+package models
+
+// DecodeFailure is an inscription whose decoding failed for good, so it has
+// no *_info row. Transient node errors abort the block instead of landing here.
+type DecodeFailure struct {
+	ID          uint      `gorm:"primarykey" json:"id"`
+	P           string    `gorm:"index" json:"p"`
+	TxHash      string    `gorm:"uniqueIndex:idx_decode_failure_tx;size:64" json:"tx_hash"`
+	TxIndex     int       `gorm:"uniqueIndex:idx_decode_failure_tx" json:"tx_index"`
+	BlockNumber int64     `gorm:"index" json:"block_number"`
+	ErrInfo     string    `gorm:"type:text" json:"err_info"`
+	CreateDate  LocalTime `json:"create_date"`
+}
+
+func (DecodeFailure) TableName() string {
+	return "decode_failure"
+}
@@ -12,6 +12,7 @@ type ExchangeInfo struct {
 	FeeAddress    string    `json:"fee_address"`
 	FeeTxHash     string    `json:"fee_tx_hash"`
 	TxHash        string    `json:"tx_hash"`
+	TxIndex       int       `gorm:"default:0" json:"tx_index"`
 	BlockNumber   int64     `json:"block_number"`
 	BlockHash     string    `json:"block_hash"`
 	HolderAddress string    `json:"holder_address"`
@@ -12,6 +12,7 @@ type FileExchangeInfo struct {
 	FeeAddress    string    `gorm:"column:fee_address" json:"fee_address"`
 	FeeTxHash     string    `gorm:"column:fee_tx_hash" json:"fee_tx_hash"`
 	TxHash        string    `gorm:"column:tx_hash" json:"tx_hash"`
+	TxIndex       int       `gorm:"column:tx_index;default:0" json:"tx_index"`
 	BlockNumber   int64     `gorm:"column:block_number" json:"block_number"`
 	BlockHash     string    `gorm:"column:block_hash" json:"block_hash"`
 	ErrInfo       string    `gorm:"column:err_info" json:"err_info"`
@@ -17,15 +17,27 @@ func (t *LocalTime) MarshalJSON() ([]byte, error) {
 	return []byte(fmt.Sprintf("%d", timestamp)), nil
 }
 
+// GormDataType keeps the columns datetime, gorm would infer integer from a
+// zero time stored as NULL.
+func (LocalTime) GormDataType() string {
+	return "time"
+}
+
+// Value stores a zero time as NULL. Indexed rows take their dates from the
+// block, writers outside a block set them themselves.
 func (t LocalTime) Value() (driver.Value, error) {
-	tlt := time.Unix(int64(t), 0)
 	if t == 0 {
-		return time.Now(), nil
+		return nil, nil
 	}
-	return tlt, nil
+	return time.Unix(int64(t), 0), nil
 }
 
 func (t *LocalTime) Scan(v interface{}) error {
+	if v == nil {
+		*t = 0
+		return nil
+	}
+
 	if value, ok := v.(time.Time); ok {
 		*t = LocalTime(value.Unix())
 		return nil
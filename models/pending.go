@@ -0,0 +1,20 @@
+package models
+
+// PendingInfo is an inscription seen in the mempool that is not mined yet.
+// Data holds the decoded *_info row, or rows for pair routers, as json.
+type PendingInfo struct {
+	ID            uint      `gorm:"primarykey" json:"id"`
+	P             string    `gorm:"index" json:"p"`
+	Op            string    `json:"op"`
+	Tick          string    `json:"tick"`
+	TxHash        string    `gorm:"uniqueIndex:idx_pending_tx;size:64" json:"tx_hash"`
+	TxIndex       int       `gorm:"uniqueIndex:idx_pending_tx" json:"tx_index"`
+	HolderAddress string    `gorm:"index;size:64" json:"holder_address"`
+	ToAddress     string    `json:"to_address"`
+	Data          string    `gorm:"type:text" json:"data"`
+	CreateDate    LocalTime `json:"create_date"`
+}
+
+func (PendingInfo) TableName() string {
+	return "pending_info"
+}
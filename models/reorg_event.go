@@ -0,0 +1,55 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// ReorgEvent is a chain reorganization met by the scanner. Height is the common
+// ancestor the index was rolled back to, Depth the number of indexed blocks
+// above it. A halted event was deeper than the configured limit and was not
+// rolled back.
+type ReorgEvent struct {
+	ID         uint          `gorm:"primarykey" json:"id"`
+	Height     int64         `gorm:"index" json:"height"`
+	Depth      int64         `json:"depth"`
+	OldTip     string        `json:"old_tip"`
+	NewTip     string        `json:"new_tip"`
+	Affected   ReorgAffected `gorm:"type:text" json:"affected"`
+	Halted     bool          `json:"halted"`
+	CreateDate LocalTime     `json:"create_date"`
+}
+
+func (ReorgEvent) TableName() string {
+	return "reorg_event"
+}
+
+// ReorgAffected maps a protocol to the tx hashes of its inscriptions in the
+// dropped blocks.
+type ReorgAffected map[string][]string
+
+func (a ReorgAffected) Value() (driver.Value, error) {
+	if a == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (a *ReorgAffected) Scan(v interface{}) error {
+	switch value := v.(type) {
+	case []byte:
+		return json.Unmarshal(value, a)
+	case string:
+		return json.Unmarshal([]byte(value), a)
+	case nil:
+		*a = nil
+		return nil
+	}
+
+	return fmt.Errorf("can not convert %v to reorg affected", v)
+}
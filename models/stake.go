@@ -11,6 +11,7 @@ type StakeInfo struct {
 	Amt              *Number        `json:"amt"`
 	FeeTxHash        string         `json:"fee_tx_hash"`
 	TxHash           string         `json:"tx_hash"`
+	TxIndex          int            `gorm:"default:0" json:"tx_index"`
 	BlockHash        string         `json:"block_hash"`
 	BlockNumber      int64          `json:"block_number"`
 	FeeAddress       string         `json:"fee_address"`
@@ -15,6 +15,7 @@ type StakeV2Info struct {
 	LockBlock     int64     `json:"lock_block"`
 	FeeTxHash     string    `json:"fee_tx_hash"`
 	TxHash        string    `json:"tx_hash"`
+	TxIndex       int       `gorm:"default:0" json:"tx_index"`
 	BlockHash     string    `json:"block_hash"`
 	BlockNumber   int64     `json:"block_number"`
 	FeeAddress    string    `json:"fee_address"`
@@ -16,6 +16,21 @@ type Summary struct {
 	CreateDate   LocalTime `json:"create_date"`
 }
 
+// SummaryTrade is a trade added to the candles. The candles a rollback drops
+// are rebuilt from the trades kept below the fork height.
+type SummaryTrade struct {
+	ID          uint    `gorm:"primarykey" json:"id"`
+	BlockNumber int64   `gorm:"index" json:"block_number"`
+	TickId      string  `json:"tick_id"`
+	Price       float64 `json:"price"`
+	Volume      *Number `json:"volume"`
+	BlockTime   int64   `gorm:"index" json:"block_time"`
+}
+
+func (SummaryTrade) TableName() string {
+	return "swap_v2_summary_trade"
+}
+
 type SwapSummary struct {
 	ID           uint    `gorm:"primarykey" json:"id"`
 	Tick         string  `json:"tick"`
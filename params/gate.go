@@ -0,0 +1,81 @@
+package params
+
+import (
+	"fmt"
+)
+
+// Gate opens protocol P, or only its Op when set, for the heights From up to
+// but not including Until. An Until of 0 leaves it open. A protocol or op
+// with gates is only accepted inside one of them, the others are not gated.
+type Gate struct {
+	P     string `json:"p"`
+	Op    string `json:"op"`
+	From  int64  `json:"from"`
+	Until int64  `json:"until"`
+}
+
+func (g *Gate) open(height int64) bool {
+	return height >= g.From && (g.Until == 0 || height < g.Until)
+}
+
+func (g *Gate) String() string {
+	name := g.P
+	if g.Op != "" {
+		name += " " + g.Op
+	}
+
+	if g.Until == 0 {
+		return fmt.Sprintf("%s opens at height %d", name, g.From)
+	}
+	return fmt.Sprintf("%s is open from height %d until %d", name, g.From, g.Until)
+}
+
+// Gate reports why op of protocol p is not accepted at height, nil when it is.
+func (s *Schedule) Gate(p, op string, height int64) error {
+	for _, name := range []string{"", op} {
+		var closed *Gate
+		for i := range s.gates {
+			g := &s.gates[i]
+			if g.P != p || g.Op != name {
+				continue
+			}
+
+			if g.open(height) {
+				closed = nil
+				break
+			}
+			closed = g
+		}
+
+		if closed != nil {
+			return fmt.Errorf("gated at height %d: %s", height, closed.String())
+		}
+
+		if op == "" {
+			break
+		}
+	}
+
+	return nil
+}
+
+func (g *Gate) validate(protocols []string) error {
+	if g.P == "" {
+		return fmt.Errorf("gate without p")
+	}
+
+	if g.From < 0 || (g.Until != 0 && g.Until <= g.From) {
+		return fmt.Errorf("gate %s %s: bad heights %d to %d", g.P, g.Op, g.From, g.Until)
+	}
+
+	if protocols == nil {
+		return nil
+	}
+
+	for _, p := range protocols {
+		if p == g.P {
+			return nil
+		}
+	}
+	return fmt.Errorf("gate for unknown protocol %s", g.P)
+}
@@ -0,0 +1,236 @@
+package params
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/dogecoinw/doged/btcutil"
+	"github.com/dogecoinw/doged/chaincfg"
+	"os"
+	"sort"
+)
+
+// Params are the protocol values a block is indexed with. Amounts are in
+// koinu, the smallest unit of a token.
+type Params struct {
+	WDogeFeeAddress      string `json:"wdoge_fee_address"`
+	WDogeCoolAddress     string `json:"wdoge_cool_address"`
+	NftFeeAddress        string `json:"nft_fee_address"`
+	PumpCreateFeeAddress string `json:"pump_create_fee_address"`
+	PumpTipAddress       string `json:"pump_tip_address"`
+	PumpFinishFeeAddress string `json:"pump_finish_fee_address"`
+	PumpTxFeeAddress     string `json:"pump_tx_fee_address"`
+	StakePoolAddress     string `json:"stake_pool_address"`
+
+	PumpCreateFee       int64 `json:"pump_create_fee"`
+	PumpTipFee          int64 `json:"pump_tip_fee"`
+	PumpFinishFee       int64 `json:"pump_finish_fee"`
+	PumpCreateHolderFee int64 `json:"pump_create_holder_fee"`
+	MemeMax             int64 `json:"meme_max"`
+	DogeInit            int64 `json:"doge_init"`
+	MiniLiquidity       int64 `json:"mini_liquidity"`
+
+	// ConsensusTick is the drc-20 staked by consensus and held to deploy nfts.
+	ConsensusTick string `json:"consensus_tick"`
+
+	// MultiInscription reads an inscription from every input of a
+	// transaction, not only from the first one.
+	MultiInscription bool `json:"multi_inscription"`
+
+	// ExactMintValue counts the drc-20 mints an output pays for in koinu.
+	// Before it the value is divided as a float, which rejects some whole
+	// multiples like 0.009 doge.
+	ExactMintValue bool `json:"exact_mint_value"`
+}
+
+// Mainnet are the values in force from the genesis block when no upgrade
+// overrides them.
+var Mainnet = Params{
+	WDogeFeeAddress:      "D86Dc4n49LZDiXvB41ds2XaDAP1BFjP1qy",
+	WDogeCoolAddress:     "DKMyk8cfSTGfnCVXfmo8gXta9F6gziu7Z5",
+	NftFeeAddress:        "DBFQmJ5oGCgtnDVxUU7xEraztpEyqJHdxz",
+	PumpCreateFeeAddress: "DJ9wVHBFnbcZUtfWdHWPEnijdxz1CABPUY",
+	PumpTipAddress:       "DSPAZ6cZC7ShL63UFKPgs4vBGrbpHBwWQG",
+	PumpFinishFeeAddress: "DJ9wVHBFnbcZUtfWdHWPEnijdxz1CABPUY",
+	PumpTxFeeAddress:     "D7NfMMzqWB9FaUssLwgCs14Q5F6CCfpf9A",
+	StakePoolAddress:     "DS8eFcobjXp6oL8YoXoVazDQ32bcDdWwui",
+
+	PumpCreateFee:       500000000,
+	PumpTipFee:          10000000,
+	PumpFinishFee:       100000000000,
+	PumpCreateHolderFee: 10000000000,
+	MemeMax:             100000000000000000,
+	DogeInit:            300000000000,
+	MiniLiquidity:       1000,
+
+	ConsensusTick: "CARDI",
+}
+
+// Schedule holds the parameters in force from each activation height on, and
+// the gates of the protocols.
+type Schedule struct {
+	heights []int64
+	params  []*Params
+	gates   []Gate
+}
+
+// NewSchedule starts from base at height 0.
+func NewSchedule(base Params) *Schedule {
+	return &Schedule{
+		heights: []int64{0},
+		params:  []*Params{&base},
+	}
+}
+
+// At returns the parameters active at height.
+func (s *Schedule) At(height int64) *Params {
+	i := sort.Search(len(s.heights), func(i int) bool { return s.heights[i] > height })
+	if i == 0 {
+		return s.params[0]
+	}
+	return s.params[i-1]
+}
+
+// upgrade is one entry of a parameters file. The values it names apply from
+// Height on, the others are carried over from the entry before it.
+type upgrade struct {
+	Height int64 `json:"height"`
+	*Params
+}
+
+// file is the layout of a parameters file.
+type file struct {
+	Upgrades []json.RawMessage `json:"upgrades"`
+	Gates    []Gate            `json:"gates"`
+}
+
+// Load reads a parameters file, upgrades on top of base and protocol gates:
+//
+//	{
+//	  "upgrades": [{"height": 5600000, "pump_create_fee": 1000000000}],
+//	  "gates": [{"p": "pair-v1", "op": "create", "until": 5700000}]
+//	}
+//
+// Every address has to belong to the network of net, every gate to one of
+// protocols unless it is nil.
+func Load(path string, base Params, net *chaincfg.Params, protocols []string) (*Schedule, error) {
+	s := NewSchedule(base)
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		f := &file{}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(f)
+		if err != nil {
+			return nil, fmt.Errorf("json.Unmarshal err: %s", err.Error())
+		}
+
+		err = s.apply(f.Upgrades)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range f.Gates {
+			err = f.Gates[i].validate(protocols)
+			if err != nil {
+				return nil, err
+			}
+		}
+		s.gates = f.Gates
+	}
+
+	for i, p := range s.params {
+		err := p.validate(net)
+		if err != nil {
+			return nil, fmt.Errorf("params at height %d: %s", s.heights[i], err.Error())
+		}
+	}
+
+	return s, nil
+}
+
+func (s *Schedule) apply(entries []json.RawMessage) error {
+	heights := make([]int64, len(entries))
+	for i, entry := range entries {
+		u := &upgrade{Params: &Params{}}
+		if err := json.Unmarshal(entry, u); err != nil {
+			return fmt.Errorf("upgrade %d err: %s", i, err.Error())
+		}
+		heights[i] = u.Height
+	}
+
+	order := make([]int, len(entries))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return heights[order[a]] < heights[order[b]] })
+
+	for _, i := range order {
+		if heights[i] < 0 {
+			return fmt.Errorf("upgrade %d: negative height", i)
+		}
+
+		p := *s.At(heights[i])
+		dec := json.NewDecoder(bytes.NewReader(entries[i]))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&upgrade{Params: &p}); err != nil {
+			return fmt.Errorf("upgrade %d err: %s", i, err.Error())
+		}
+
+		last := len(s.heights) - 1
+		if s.heights[last] == heights[i] {
+			s.params[last] = &p
+			continue
+		}
+		s.heights = append(s.heights, heights[i])
+		s.params = append(s.params, &p)
+	}
+
+	return nil
+}
+
+func (p *Params) validate(net *chaincfg.Params) error {
+	addresses := map[string]string{
+		"wdoge_fee_address":       p.WDogeFeeAddress,
+		"wdoge_cool_address":      p.WDogeCoolAddress,
+		"nft_fee_address":         p.NftFeeAddress,
+		"pump_create_fee_address": p.PumpCreateFeeAddress,
+		"pump_tip_address":        p.PumpTipAddress,
+		"pump_finish_fee_address": p.PumpFinishFeeAddress,
+		"pump_tx_fee_address":     p.PumpTxFeeAddress,
+		"stake_pool_address":      p.StakePoolAddress,
+	}
+
+	for name, address := range addresses {
+		addr, err := btcutil.DecodeAddress(address, net)
+		if err != nil || !addr.IsForNet(net) {
+			return fmt.Errorf("%s %s is not a %s address", name, address, net.Name)
+		}
+	}
+
+	amounts := map[string]int64{
+		"pump_create_fee":        p.PumpCreateFee,
+		"pump_tip_fee":           p.PumpTipFee,
+		"pump_finish_fee":        p.PumpFinishFee,
+		"pump_create_holder_fee": p.PumpCreateHolderFee,
+		"meme_max":               p.MemeMax,
+		"doge_init":              p.DogeInit,
+		"mini_liquidity":         p.MiniLiquidity,
+	}
+
+	for name, amount := range amounts {
+		if amount <= 0 {
+			return fmt.Errorf("%s %d is not positive", name, amount)
+		}
+	}
+
+	if p.ConsensusTick == "" {
+		return fmt.Errorf("consensus_tick is empty")
+	}
+
+	return nil
+}
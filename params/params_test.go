@@ -0,0 +1,101 @@
+package params
+
+import (
+	"github.com/dogecoinw/doged/chaincfg"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeParams(t *testing.T, data string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "params.json")
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestScheduleActivationHeights(t *testing.T) {
+	path := writeParams(t, `{"upgrades": [
+		{"height": 200, "mini_liquidity": 2000},
+		{"height": 100, "pump_create_fee": 700000000}
+	]}`)
+
+	s, err := Load(path, Mainnet, &chaincfg.MainNetParams, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p := s.At(99); p.PumpCreateFee != Mainnet.PumpCreateFee || p.MiniLiquidity != Mainnet.MiniLiquidity {
+		t.Fatalf("params before the first upgrade changed: %+v", p)
+	}
+
+	if p := s.At(100); p.PumpCreateFee != 700000000 || p.MiniLiquidity != Mainnet.MiniLiquidity {
+		t.Fatalf("params at 100: %+v", p)
+	}
+
+	// the second upgrade keeps the fee of the first
+	if p := s.At(250); p.PumpCreateFee != 700000000 || p.MiniLiquidity != 2000 {
+		t.Fatalf("params at 250: %+v", p)
+	}
+
+	if Mainnet.PumpCreateFee != 500000000 {
+		t.Fatal("upgrades should not change the base values")
+	}
+}
+
+func TestLoadRejectsBadParams(t *testing.T) {
+	files := map[string]string{
+		"unknown field":    `{"upgrades": [{"height": 100, "pump_create_fees": 1}]}`,
+		"wrong network":    `{"upgrades": [{"height": 100, "wdoge_fee_address": "n3GNqMveyvaPvUbH469vDRadqpJMPc84JA"}]}`,
+		"zero amount":      `{"upgrades": [{"height": 100, "meme_max": 0}]}`,
+		"unknown protocol": `{"gates": [{"p": "drc-21", "until": 100}]}`,
+		"empty window":     `{"gates": [{"p": "drc-20", "from": 100, "until": 100}]}`,
+	}
+
+	for name, data := range files {
+		if _, err := Load(writeParams(t, data), Mainnet, &chaincfg.MainNetParams, []string{"drc-20"}); err == nil {
+			t.Fatalf("%s should fail", name)
+		}
+	}
+}
+
+func TestGates(t *testing.T) {
+	path := writeParams(t, `{"gates": [
+		{"p": "pair-v1", "op": "create", "until": 100},
+		{"p": "consensus", "from": 200},
+		{"p": "nft", "until": 50},
+		{"p": "nft", "from": 80}
+	]}`)
+
+	s, err := Load(path, Mainnet, &chaincfg.MainNetParams, []string{"pair-v1", "consensus", "nft"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		p      string
+		op     string
+		height int64
+		open   bool
+	}{
+		{"pair-v1", "create", 99, true},
+		{"pair-v1", "create", 100, false},
+		{"pair-v1", "swap", 100, true},
+		{"consensus", "stake", 199, false},
+		{"consensus", "stake", 200, true},
+		{"nft", "mint", 49, true},
+		{"nft", "mint", 60, false},
+		{"nft", "mint", 80, true},
+		{"drc-20", "mint", 0, true},
+	}
+
+	for _, c := range cases {
+		err := s.Gate(c.p, c.op, c.height)
+		if (err == nil) != c.open {
+			t.Fatalf("%s %s at %d: open %v, got %v", c.p, c.op, c.height, c.open, err)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"dogeuni-indexer/chain"
+	"dogeuni-indexer/config"
+	"dogeuni-indexer/explorer"
+	"dogeuni-indexer/params"
+	"dogeuni-indexer/storage"
+	"flag"
+	"github.com/dogecoinw/go-dogecoin/log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// reindex replays the stored *_info rows from --from on against the current
+// verify and execute rules. It needs the database only, not the node. With
+// --rescan it rolls the index back instead, so the next start decodes the
+// blocks from --from, or from the first input the node did not know, again.
+//
+//	dogeuni-indexer reindex --from 5000000 [--widen] [--config config.json]
+//	dogeuni-indexer reindex --rescan [--from 5000000] [--config config.json]
+func reindex(args []string) {
+	fs := flag.NewFlagSet("reindex", flag.ExitOnError)
+	from := fs.Int64("from", 0, "first block height to replay")
+	widen := fs.Bool("widen", false, "replay from the first block of the candles of --from when their trades predate the trade log")
+	rescan := fs.Bool("rescan", false, "roll back so the next start decodes the blocks from the node again")
+	configFile := fs.String("config", "config.json", "config file")
+	_ = fs.Parse(args)
+
+	config.LoadConfig(&cfg, *configFile)
+	setupLog()
+
+	netParams, err := chain.NetParams(cfg.Chain.ChainName)
+	if err != nil {
+		log.Error("reindex", "NetParams", err.Error())
+		os.Exit(1)
+	}
+
+	protocolParams, err := params.Load(cfg.Explorer.Params, params.Mainnet, netParams, explorer.ProtocolNames())
+	if err != nil {
+		log.Error("reindex", "params.Load", err.Error())
+		os.Exit(1)
+	}
+
+	var dbClient *storage.DBClient
+	if cfg.Sqlite.Switch {
+		dbClient = storage.NewSqliteClient(cfg.Sqlite)
+	} else {
+		dbClient = storage.NewMysqlClient(cfg.Mysql)
+	}
+	defer dbClient.Stop()
+	dbClient.SetNetParams(netParams)
+	dbClient.SetProtocolParams(protocolParams)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-c
+		println("\nReceived an interrupt, stopping reindex...")
+		cancel()
+	}()
+
+	exp := explorer.NewExplorer(ctx, &sync.WaitGroup{}, &cfg, nil, dbClient, nil)
+	if *rescan {
+		height, err := exp.Rescan(*from)
+		if err != nil {
+			log.Error("reindex", "err", err.Error())
+			os.Exit(1)
+		}
+
+		log.Info("reindex", "rescan from", height)
+		return
+	}
+
+	if err := exp.Reindex(*from, *widen); err != nil {
+		log.Error("reindex", "err", err.Error())
+		os.Exit(1)
+	}
+
+	log.Info("reindex", "done", *from)
+}
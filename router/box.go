@@ -1,20 +1,20 @@
 package router
 
 import (
+	"dogeuni-indexer/chain"
 	"dogeuni-indexer/models"
 	"dogeuni-indexer/storage"
 	"dogeuni-indexer/utils"
-	"github.com/dogecoinw/doged/rpcclient"
 	"github.com/gin-gonic/gin"
 	"net/http"
 )
 
 type BoxRouter struct {
 	dbc  *storage.DBClient
-	node *rpcclient.Client
+	node chain.ChainSource
 }
 
-func NewBoxRouter(db *storage.DBClient, node *rpcclient.Client) *BoxRouter {
+func NewBoxRouter(db *storage.DBClient, node chain.ChainSource) *BoxRouter {
 	return &BoxRouter{
 		dbc:  db,
 		node: node,
@@ -1,6 +1,7 @@
 package router
 
 import (
+	"dogeuni-indexer/chain"
 	"math/big"
 	"net/http"
 
@@ -8,16 +9,15 @@ import (
 	"dogeuni-indexer/storage"
 	"dogeuni-indexer/utils"
 
-	"github.com/dogecoinw/doged/rpcclient"
 	"github.com/gin-gonic/gin"
 )
 
 type ConsensusRouter struct {
 	dbc  *storage.DBClient
-	node *rpcclient.Client
+	node chain.ChainSource
 }
 
-func NewConsensusRouter(dbc *storage.DBClient, node *rpcclient.Client) *ConsensusRouter {
+func NewConsensusRouter(dbc *storage.DBClient, node chain.ChainSource) *ConsensusRouter {
 	return &ConsensusRouter{dbc: dbc, node: node}
 }
 
@@ -1,20 +1,20 @@
 package router
 
 import (
+	"dogeuni-indexer/chain"
 	"dogeuni-indexer/models"
 	"dogeuni-indexer/storage"
 	"dogeuni-indexer/utils"
-	"github.com/dogecoinw/doged/rpcclient"
 	"github.com/gin-gonic/gin"
 	"net/http"
 )
 
 type CrossRouter struct {
 	dbc  *storage.DBClient
-	node *rpcclient.Client
+	node chain.ChainSource
 }
 
-func NewCrossRouter(dbc *storage.DBClient, node *rpcclient.Client) *CrossRouter {
+func NewCrossRouter(dbc *storage.DBClient, node chain.ChainSource) *CrossRouter {
 	return &CrossRouter{
 		dbc:  dbc,
 		node: node,
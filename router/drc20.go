@@ -1,10 +1,10 @@
 package router
 
 import (
+	"dogeuni-indexer/chain"
 	"dogeuni-indexer/models"
 	"dogeuni-indexer/storage"
 	"dogeuni-indexer/utils"
-	"github.com/dogecoinw/doged/rpcclient"
 	"github.com/gin-gonic/gin"
 	shell "github.com/ipfs/go-ipfs-api"
 	"net/http"
@@ -16,12 +16,12 @@ var (
 
 type Drc20Router struct {
 	dbc   *storage.DBClient
-	node  *rpcclient.Client
+	node  chain.ChainSource
 	ipfs  *shell.Shell
 	level *storage.LevelDB
 }
 
-func NewDrc20Router(db *storage.DBClient, node *rpcclient.Client, level *storage.LevelDB, ipfs *shell.Shell) *Drc20Router {
+func NewDrc20Router(db *storage.DBClient, node chain.ChainSource, level *storage.LevelDB, ipfs *shell.Shell) *Drc20Router {
 	return &Drc20Router{
 		dbc:   db,
 		node:  node,
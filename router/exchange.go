@@ -1,10 +1,10 @@
 package router
 
 import (
+	"dogeuni-indexer/chain"
 	"dogeuni-indexer/models"
 	"dogeuni-indexer/storage"
 	"dogeuni-indexer/utils"
-	"github.com/dogecoinw/doged/rpcclient"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"strings"
@@ -12,10 +12,10 @@ import (
 
 type ExchangeRouter struct {
 	dbc  *storage.DBClient
-	node *rpcclient.Client
+	node chain.ChainSource
 }
 
-func NewExchangeRouter(db *storage.DBClient, node *rpcclient.Client) *ExchangeRouter {
+func NewExchangeRouter(db *storage.DBClient, node chain.ChainSource) *ExchangeRouter {
 	return &ExchangeRouter{
 		dbc:  db,
 		node: node,
@@ -1,23 +1,24 @@
 package router
 
 import (
+	"dogeuni-indexer/chain"
 	"dogeuni-indexer/models"
 	"dogeuni-indexer/storage"
 	"dogeuni-indexer/utils"
-	"github.com/dogecoinw/doged/rpcclient"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	shell "github.com/ipfs/go-ipfs-api"
 	"net/http"
+	"time"
 )
 
 type FileRouter struct {
 	dbc  *storage.DBClient
-	node *rpcclient.Client
+	node chain.ChainSource
 	ipfs *shell.Shell
 }
 
-func NewFileRouter(db *storage.DBClient, node *rpcclient.Client, ipfs *shell.Shell) *FileRouter {
+func NewFileRouter(db *storage.DBClient, node chain.ChainSource, ipfs *shell.Shell) *FileRouter {
 	return &FileRouter{
 		dbc:  db,
 		node: node,
@@ -379,7 +380,7 @@ func (r *FileRouter) UploadMeta(c *gin.Context) {
 		return
 	}
 
-	inAddress, err := utils.GetAddressFromSig(params.Name, params.SigMsg)
+	inAddress, err := utils.GetAddressFromSig(params.Name, params.SigMsg, r.dbc.NetParams())
 	if err != nil {
 		result := &utils.HttpResult{}
 		result.Code = 500
@@ -412,6 +413,7 @@ func (r *FileRouter) UploadMeta(c *gin.Context) {
 		fileMeta.Slug = params.Slug
 		fileMeta.TwitterLink = params.TwitterLink
 		fileMeta.WebsiteLink = params.WebsiteLink
+		fileMeta.UpdateDate = models.LocalTime(time.Now().Unix())
 
 		err = r.dbc.DB.Save(&fileMeta).Error
 		if err != nil {
@@ -439,6 +441,8 @@ func (r *FileRouter) UploadMeta(c *gin.Context) {
 			TwitterLink:   params.TwitterLink,
 			WebsiteLink:   params.WebsiteLink,
 			HolderAddress: inAddress,
+			UpdateDate:    models.LocalTime(time.Now().Unix()),
+			CreateDate:    models.LocalTime(time.Now().Unix()),
 		}
 
 		err = r.dbc.DB.Save(&fileMeta).Error
@@ -479,7 +483,7 @@ func (r *FileRouter) UploadInscriptionsMeta(c *gin.Context) {
 		return
 	}
 
-	inAddress, err := utils.GetAddressFromSig(params.MetaName, params.SigMsg)
+	inAddress, err := utils.GetAddressFromSig(params.MetaName, params.SigMsg, r.dbc.NetParams())
 	if err != nil {
 		result := &utils.HttpResult{}
 		result.Code = 500
@@ -1,10 +1,10 @@
 package router
 
 import (
+	"dogeuni-indexer/chain"
 	"dogeuni-indexer/models"
 	"dogeuni-indexer/storage"
 	"dogeuni-indexer/utils"
-	"github.com/dogecoinw/doged/rpcclient"
 	"github.com/gin-gonic/gin"
 	shell "github.com/ipfs/go-ipfs-api"
 	"gorm.io/gorm"
@@ -14,11 +14,11 @@ import (
 
 type FileExchangeRouter struct {
 	dbc  *storage.DBClient
-	node *rpcclient.Client
+	node chain.ChainSource
 	ipfs *shell.Shell
 }
 
-func NewFileExchangeRouter(db *storage.DBClient, node *rpcclient.Client, ipfs *shell.Shell) *FileExchangeRouter {
+func NewFileExchangeRouter(db *storage.DBClient, node chain.ChainSource, ipfs *shell.Shell) *FileExchangeRouter {
 	return &FileExchangeRouter{
 		dbc:  db,
 		node: node,
@@ -0,0 +1,166 @@
+package router
+
+import (
+	"context"
+	"dogeuni-indexer/chain"
+	"dogeuni-indexer/models"
+	"dogeuni-indexer/storage"
+	"dogeuni-indexer/utils"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+// defaultReadyLag is the lag /readyz tolerates when the config sets none.
+const defaultReadyLag = 3
+
+// checkTimeout bounds the database and node calls of a check.
+const checkTimeout = 2 * time.Second
+
+// ScanState is what the readiness check asks of the explorer.
+type ScanState interface {
+	Forking() bool
+	Halted() error
+}
+
+type HealthRouter struct {
+	dbc    *storage.DBClient
+	node   chain.ChainSource
+	scan   ScanState
+	maxLag int64
+}
+
+// NewHealthRouter checks the explorer through scan, nil when this process
+// only serves the API.
+func NewHealthRouter(db *storage.DBClient, node chain.ChainSource, scan ScanState, maxLag int64) *HealthRouter {
+	if maxLag <= 0 {
+		maxLag = defaultReadyLag
+	}
+
+	return &HealthRouter{
+		dbc:    db,
+		node:   node,
+		scan:   scan,
+		maxLag: maxLag,
+	}
+}
+
+// Healthz answers 200 while the database and the node can be reached.
+func (r *HealthRouter) Healthz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), checkTimeout)
+	defer cancel()
+
+	data := make(map[string]interface{})
+	err := r.ping(ctx)
+	if err == nil {
+		data["db"] = "ok"
+		_, err = r.blockCount(ctx)
+		if err != nil {
+			err = fmt.Errorf("node: %s", err.Error())
+		} else {
+			data["node"] = "ok"
+		}
+	}
+
+	r.reply(c, data, err)
+}
+
+// Readyz answers 200 while the index is at most the configured number of
+// blocks behind the node and no rollback is running, so a load balancer only
+// routes to instances that are caught up. A rollback or reindex of another
+// process shows as a journal row at or below the indexed height, the scanner
+// only journals the block above it.
+func (r *HealthRouter) Readyz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), checkTimeout)
+	defer cancel()
+
+	indexHeight := int64(0)
+	err := r.dbc.DB.WithContext(ctx).Model(&models.Block{}).Select("coalesce(max(block_number), 0)").Scan(&indexHeight).Error
+	if err != nil {
+		r.reply(c, nil, fmt.Errorf("db: %s", err.Error()))
+		return
+	}
+
+	unfinished, open, err := r.dbc.WithTx(r.dbc.DB.WithContext(ctx)).JournalUnfinished()
+	if err != nil {
+		r.reply(c, nil, fmt.Errorf("db: %s", err.Error()))
+		return
+	}
+
+	chainHeight, err := r.blockCount(ctx)
+	if err != nil {
+		r.reply(c, nil, fmt.Errorf("node: %s", err.Error()))
+		return
+	}
+
+	// the scanner indexes the blocks below the block count
+	lag := chainHeight - 1 - indexHeight
+	data := map[string]interface{}{
+		"index_height": indexHeight,
+		"chain_height": chainHeight,
+		"lag":          lag,
+		"max_lag":      r.maxLag,
+	}
+
+	switch {
+	case r.scan != nil && r.scan.Halted() != nil:
+		err = fmt.Errorf("explorer halted: %s", r.scan.Halted().Error())
+	case r.scan != nil && r.scan.Forking():
+		err = fmt.Errorf("rollback in progress")
+	case open && unfinished <= indexHeight:
+		err = fmt.Errorf("block %d is being rolled back or replayed", unfinished)
+	case lag > r.maxLag:
+		err = fmt.Errorf("index %d blocks behind", lag)
+	}
+
+	r.reply(c, data, err)
+}
+
+// blockCount asks the node for its block count, giving up when ctx is done.
+func (r *HealthRouter) blockCount(ctx context.Context) (int64, error) {
+	type answer struct {
+		count int64
+		err   error
+	}
+
+	done := make(chan answer, 1)
+	go func() {
+		count, err := r.node.GetBlockCount()
+		done <- answer{count, err}
+	}()
+
+	select {
+	case a := <-done:
+		return a.count, a.err
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+func (r *HealthRouter) ping(ctx context.Context) error {
+	db, err := r.dbc.DB.DB()
+	if err == nil {
+		err = db.PingContext(ctx)
+	}
+	if err != nil {
+		return fmt.Errorf("db: %s", err.Error())
+	}
+	return nil
+}
+
+func (r *HealthRouter) reply(c *gin.Context, data map[string]interface{}, err error) {
+	result := &utils.HttpResult{}
+	if err != nil {
+		result.Code = 503
+		result.Msg = err.Error()
+		result.Data = data
+		c.JSON(http.StatusServiceUnavailable, result)
+		return
+	}
+
+	result.Code = 200
+	result.Msg = "success"
+	result.Data = data
+	c.JSON(http.StatusOK, result)
+}
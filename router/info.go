@@ -1,23 +1,27 @@
 package router
 
 import (
+	"dogeuni-indexer/chain"
 	"dogeuni-indexer/models"
 	"dogeuni-indexer/storage"
 	"dogeuni-indexer/utils"
-	"github.com/dogecoinw/doged/rpcclient"
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	shell "github.com/ipfs/go-ipfs-api"
+	"gorm.io/gorm"
 	"net/http"
+	"strconv"
 )
 
 type InfoRouter struct {
 	dbc   *storage.DBClient
-	node  *rpcclient.Client
+	node  chain.ChainSource
 	ipfs  *shell.Shell
 	level *storage.LevelDB
 }
 
-func NewInfoRouter(db *storage.DBClient, node *rpcclient.Client, level *storage.LevelDB, ipfs *shell.Shell) *InfoRouter {
+func NewInfoRouter(db *storage.DBClient, node chain.ChainSource, level *storage.LevelDB, ipfs *shell.Shell) *InfoRouter {
 	return &InfoRouter{
 		dbc:   db,
 		node:  node,
@@ -75,3 +79,150 @@ func (r *InfoRouter) BlockNumber(c *gin.Context) {
 	result.Data = data
 	c.JSON(http.StatusOK, result)
 }
+
+// DecodeFailures lists the inscriptions whose decoding failed, the gaps in the
+// *_info tables.
+func (r *InfoRouter) DecodeFailures(c *gin.Context) {
+	type params struct {
+		P           string `json:"p"`
+		TxHash      string `json:"tx_hash"`
+		BlockNumber int64  `json:"block_number"`
+		Limit       int    `json:"limit"`
+		OffSet      int    `json:"offset"`
+	}
+
+	p := &params{
+		Limit:  10,
+		OffSet: 0,
+	}
+
+	if err := c.ShouldBindJSON(&p); err != nil {
+		result := &utils.HttpResult{}
+		result.Code = 500
+		result.Msg = err.Error()
+		c.JSON(http.StatusBadRequest, result)
+		return
+	}
+
+	filter := &models.DecodeFailure{
+		P:           p.P,
+		TxHash:      p.TxHash,
+		BlockNumber: p.BlockNumber,
+	}
+
+	failures := make([]*models.DecodeFailure, 0)
+	total := int64(0)
+	err := r.dbc.DB.Where(filter).Order("id desc").Count(&total).Limit(p.Limit).Offset(p.OffSet).Find(&failures).Error
+	if err != nil {
+		result := &utils.HttpResult{}
+		result.Code = 500
+		result.Msg = err.Error()
+		c.JSON(http.StatusInternalServerError, result)
+		return
+	}
+
+	result := &utils.HttpResult{}
+	result.Code = 200
+	result.Data = failures
+	result.Total = total
+	c.JSON(http.StatusOK, result)
+}
+
+// ReorgEvents lists the chain reorganizations the scanner met, the latest
+// first. Halted ones were deeper than the limit and wait for the operator.
+func (r *InfoRouter) ReorgEvents(c *gin.Context) {
+	type params struct {
+		Halted *bool `json:"halted"`
+		Limit  int   `json:"limit"`
+		OffSet int   `json:"offset"`
+	}
+
+	p := &params{
+		Limit:  10,
+		OffSet: 0,
+	}
+
+	if err := c.ShouldBindJSON(&p); err != nil {
+		result := &utils.HttpResult{}
+		result.Code = 500
+		result.Msg = err.Error()
+		c.JSON(http.StatusBadRequest, result)
+		return
+	}
+
+	query := r.dbc.DB.Model(&models.ReorgEvent{})
+	if p.Halted != nil {
+		query = query.Where("halted = ?", *p.Halted)
+	}
+
+	events := make([]*models.ReorgEvent, 0)
+	total := int64(0)
+	err := query.Order("id desc").Count(&total).Limit(p.Limit).Offset(p.OffSet).Find(&events).Error
+	if err != nil {
+		result := &utils.HttpResult{}
+		result.Code = 500
+		result.Msg = err.Error()
+		c.JSON(http.StatusInternalServerError, result)
+		return
+	}
+
+	result := &utils.HttpResult{}
+	result.Code = 200
+	result.Data = events
+	result.Total = total
+	c.JSON(http.StatusOK, result)
+}
+
+// StateRoot returns the state root of the block at height. Two indexers agree
+// on balances and liquidity up to the last height whose roots match.
+func (r *InfoRouter) StateRoot(c *gin.Context) {
+	height, err := strconv.ParseInt(c.Query("height"), 10, 64)
+	if err != nil {
+		result := &utils.HttpResult{}
+		result.Code = 400
+		result.Msg = "invalid height"
+		c.JSON(http.StatusBadRequest, result)
+		return
+	}
+
+	block := &models.Block{}
+	err = r.dbc.DB.Where("block_number = ?", height).First(block).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			result := &utils.HttpResult{}
+			result.Code = 404
+			result.Msg = "block not indexed"
+			c.JSON(http.StatusNotFound, result)
+			return
+		}
+
+		result := &utils.HttpResult{}
+		result.Code = 500
+		result.Msg = err.Error()
+		c.JSON(http.StatusInternalServerError, result)
+		return
+	}
+
+	if block.StateRoot == "" {
+		missing, _, err := r.dbc.StateRootMissing()
+		if err != nil {
+			result := &utils.HttpResult{}
+			result.Code = 500
+			result.Msg = err.Error()
+			c.JSON(http.StatusInternalServerError, result)
+			return
+		}
+
+		result := &utils.HttpResult{}
+		result.Code = 404
+		result.Msg = fmt.Sprintf("no state root, backfill with reindex --from %d", missing)
+		c.JSON(http.StatusNotFound, result)
+		return
+	}
+
+	result := &utils.HttpResult{}
+	result.Code = 200
+	result.Msg = "success"
+	result.Data = block
+	c.JSON(http.StatusOK, result)
+}
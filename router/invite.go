@@ -1,21 +1,21 @@
 package router
 
 import (
+	"dogeuni-indexer/chain"
 	"dogeuni-indexer/models"
 	"dogeuni-indexer/storage"
 	"dogeuni-indexer/utils"
-	"github.com/dogecoinw/doged/rpcclient"
 	"github.com/gin-gonic/gin"
 	"net/http"
 )
 
 type InviteRouter struct {
 	dbc   *storage.DBClient
-	node  *rpcclient.Client
+	node  chain.ChainSource
 	level *storage.LevelDB
 }
 
-func NewInviteRouter(db *storage.DBClient, node *rpcclient.Client, level *storage.LevelDB) *InviteRouter {
+func NewInviteRouter(db *storage.DBClient, node chain.ChainSource, level *storage.LevelDB) *InviteRouter {
 	return &InviteRouter{
 		dbc:   db,
 		node:  node,
@@ -1,21 +1,21 @@
 package router
 
 import (
+	"dogeuni-indexer/chain"
 	"dogeuni-indexer/models"
 	"dogeuni-indexer/storage"
 	"dogeuni-indexer/utils"
-	"github.com/dogecoinw/doged/rpcclient"
 	"github.com/gin-gonic/gin"
 	"net/http"
 )
 
 type Meme20Router struct {
 	dbc   *storage.DBClient
-	node  *rpcclient.Client
+	node  chain.ChainSource
 	level *storage.LevelDB
 }
 
-func NewMeme20Router(db *storage.DBClient, node *rpcclient.Client, level *storage.LevelDB) *Meme20Router {
+func NewMeme20Router(db *storage.DBClient, node chain.ChainSource, level *storage.LevelDB) *Meme20Router {
 	return &Meme20Router{
 		dbc:   db,
 		node:  node,
@@ -1,20 +1,20 @@
 package router
 
 import (
+	"dogeuni-indexer/chain"
 	"dogeuni-indexer/models"
 	"dogeuni-indexer/storage"
 	"dogeuni-indexer/utils"
-	"github.com/dogecoinw/doged/rpcclient"
 	"github.com/gin-gonic/gin"
 	"net/http"
 )
 
 type NftRouter struct {
 	dbc  *storage.DBClient
-	node *rpcclient.Client
+	node chain.ChainSource
 }
 
-func NewNftRouter(db *storage.DBClient, node *rpcclient.Client) *NftRouter {
+func NewNftRouter(db *storage.DBClient, node chain.ChainSource) *NftRouter {
 	return &NftRouter{
 		dbc:  db,
 		node: node,
@@ -0,0 +1,133 @@
+package router
+
+import (
+	"bytes"
+	"dogeuni-indexer/models"
+	"dogeuni-indexer/storage"
+	"dogeuni-indexer/utils"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+)
+
+const orderStatusPending = "pending"
+
+type PendingRouter struct {
+	dbc *storage.DBClient
+}
+
+func NewPendingRouter(db *storage.DBClient) *PendingRouter {
+	return &PendingRouter{
+		dbc: db,
+	}
+}
+
+// Order wraps the order handler of protocol p. Requests with
+// "order_status": "pending" are answered from the mempool store, every
+// other request is passed on to next untouched.
+func (r *PendingRouter) Order(p string, next gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			result := &utils.HttpResult{}
+			result.Code = 400
+			result.Msg = err.Error()
+			c.JSON(http.StatusBadRequest, result)
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		status := &struct {
+			OrderStatus interface{} `json:"order_status"`
+		}{}
+
+		_ = json.Unmarshal(body, status)
+		if s, ok := status.OrderStatus.(string); !ok || s != orderStatusPending {
+			next(c)
+			return
+		}
+
+		r.order(c, p, body)
+	}
+}
+
+func (r *PendingRouter) order(c *gin.Context, p string, body []byte) {
+	params := &struct {
+		Op            string `json:"op"`
+		Tick          string `json:"tick"`
+		HolderAddress string `json:"holder_address"`
+		ToAddress     string `json:"to_address"`
+		Address       string `json:"address"`
+		TxHash        string `json:"tx_hash"`
+		Limit         int    `json:"limit"`
+		OffSet        int    `json:"offset"`
+	}{
+		Limit:  10,
+		OffSet: 0,
+	}
+
+	if err := json.Unmarshal(body, params); err != nil {
+		result := &utils.HttpResult{}
+		result.Code = 400
+		result.Msg = err.Error()
+		c.JSON(http.StatusBadRequest, result)
+		return
+	}
+
+	filter := &models.PendingInfo{
+		P:             p,
+		Op:            params.Op,
+		Tick:          params.Tick,
+		HolderAddress: params.HolderAddress,
+		ToAddress:     params.ToAddress,
+		TxHash:        params.TxHash,
+	}
+
+	subQuery := r.dbc.DB.Model(&models.PendingInfo{})
+	if params.Address != "" {
+		subQuery = subQuery.Where("holder_address = ? OR to_address = ?", params.Address, params.Address)
+	}
+
+	pendings := make([]*models.PendingInfo, 0)
+	total := int64(0)
+
+	err := subQuery.Where(filter).Count(&total).Order("id desc").Limit(params.Limit).Offset(params.OffSet).Find(&pendings).Error
+	if err != nil {
+		result := &utils.HttpResult{}
+		result.Code = 500
+		result.Msg = "server error"
+		c.JSON(http.StatusInternalServerError, result)
+		return
+	}
+
+	infos := make([]map[string]interface{}, 0)
+	for _, pending := range pendings {
+		infos = append(infos, pendingRows(pending)...)
+	}
+
+	result := &utils.HttpResult{}
+	result.Code = 200
+	result.Msg = "success"
+	result.Data = infos
+	result.Total = total
+
+	c.JSON(http.StatusOK, result)
+}
+
+// pendingRows decodes the stored *_info rows and marks them pending.
+func pendingRows(pending *models.PendingInfo) []map[string]interface{} {
+	rows := make([]map[string]interface{}, 0)
+	if err := json.Unmarshal([]byte(pending.Data), &rows); err != nil {
+		row := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(pending.Data), &row); err != nil {
+			return nil
+		}
+		rows = append(rows, row)
+	}
+
+	for _, row := range rows {
+		row["order_status"] = orderStatusPending
+	}
+	return rows
+}
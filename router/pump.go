@@ -1,11 +1,11 @@
 package router
 
 import (
+	"dogeuni-indexer/chain"
 	"dogeuni-indexer/models"
 	"dogeuni-indexer/storage"
 	"dogeuni-indexer/utils"
 	"errors"
-	"github.com/dogecoinw/doged/rpcclient"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 	"net/http"
@@ -14,10 +14,10 @@ import (
 
 type PumpRouter struct {
 	dbc  *storage.DBClient
-	node *rpcclient.Client
+	node chain.ChainSource
 }
 
-func NewPumpRouter(db *storage.DBClient, node *rpcclient.Client) *PumpRouter {
+func NewPumpRouter(db *storage.DBClient, node chain.ChainSource) *PumpRouter {
 	return &PumpRouter{
 		dbc:  db,
 		node: node,
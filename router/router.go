@@ -2,11 +2,11 @@ package router
 
 import (
 	"bytes"
+	"dogeuni-indexer/chain"
 	"dogeuni-indexer/storage"
 	"dogeuni-indexer/utils"
 	"encoding/hex"
 	"fmt"
-	"github.com/dogecoinw/doged/rpcclient"
 	"github.com/dogecoinw/doged/wire"
 	"github.com/gin-gonic/gin"
 	"net/http"
@@ -14,10 +14,10 @@ import (
 
 type Router struct {
 	dbc  *storage.DBClient
-	node *rpcclient.Client
+	node chain.ChainSource
 }
 
-func NewRouter(db *storage.DBClient, node *rpcclient.Client) *Router {
+func NewRouter(db *storage.DBClient, node chain.ChainSource) *Router {
 	return &Router{
 		dbc:  db,
 		node: node,
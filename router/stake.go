@@ -1,20 +1,20 @@
 package router
 
 import (
+	"dogeuni-indexer/chain"
 	"dogeuni-indexer/models"
 	"dogeuni-indexer/storage"
 	"dogeuni-indexer/utils"
-	"github.com/dogecoinw/doged/rpcclient"
 	"github.com/gin-gonic/gin"
 	"net/http"
 )
 
 type StakeRouter struct {
 	dbc  *storage.DBClient
-	node *rpcclient.Client
+	node chain.ChainSource
 }
 
-func NewStakeRouter(db *storage.DBClient, node *rpcclient.Client) *StakeRouter {
+func NewStakeRouter(db *storage.DBClient, node chain.ChainSource) *StakeRouter {
 	return &StakeRouter{
 		dbc:  db,
 		node: node,
@@ -132,8 +132,19 @@ func (r *StakeRouter) Reward(c *gin.Context) {
 		return
 	}
 
+	// rewards are split over the stake pool of the last indexed block
+	height := int64(0)
+	err := r.dbc.DB.Model(&models.Block{}).Select("coalesce(max(block_number), 0)").Scan(&height).Error
+	if err != nil {
+		result := &utils.HttpResult{}
+		result.Code = 500
+		result.Msg = err.Error()
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
 	tx := r.dbc.DB.Begin()
-	staker, err := r.dbc.StakeGetRewardV1(tx, params.HolderAddress, params.Tick)
+	staker, err := r.dbc.StakeGetRewardV1(tx, params.HolderAddress, params.Tick, height)
 	if err != nil {
 		tx.Rollback()
 		result := &utils.HttpResult{}
@@ -1,20 +1,20 @@
 package router
 
 import (
+	"dogeuni-indexer/chain"
 	"dogeuni-indexer/models"
 	"dogeuni-indexer/storage"
 	"dogeuni-indexer/utils"
-	"github.com/dogecoinw/doged/rpcclient"
 	"github.com/gin-gonic/gin"
 	"net/http"
 )
 
 type StakeV2Router struct {
 	dbc  *storage.DBClient
-	node *rpcclient.Client
+	node chain.ChainSource
 }
 
-func NewStakeV2Router(dbc *storage.DBClient, node *rpcclient.Client) *StakeV2Router {
+func NewStakeV2Router(dbc *storage.DBClient, node chain.ChainSource) *StakeV2Router {
 	return &StakeV2Router{
 		dbc:  dbc,
 		node: node,
@@ -1,10 +1,10 @@
 package router
 
 import (
+	"dogeuni-indexer/chain"
 	"dogeuni-indexer/models"
 	"dogeuni-indexer/storage"
 	"dogeuni-indexer/utils"
-	"github.com/dogecoinw/doged/rpcclient"
 	"github.com/gin-gonic/gin"
 	"math/big"
 	"net/http"
@@ -14,10 +14,10 @@ import (
 
 type SwapRouter struct {
 	dbc  *storage.DBClient
-	node *rpcclient.Client
+	node chain.ChainSource
 }
 
-func NewSwapRouter(db *storage.DBClient, node *rpcclient.Client) *SwapRouter {
+func NewSwapRouter(db *storage.DBClient, node chain.ChainSource) *SwapRouter {
 	return &SwapRouter{
 		dbc:  db,
 		node: node,
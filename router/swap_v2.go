@@ -1,10 +1,10 @@
 package router
 
 import (
+	"dogeuni-indexer/chain"
 	"dogeuni-indexer/models"
 	"dogeuni-indexer/storage"
 	"dogeuni-indexer/utils"
-	"github.com/dogecoinw/doged/rpcclient"
 	"github.com/gin-gonic/gin"
 	"math/big"
 	"net/http"
@@ -12,10 +12,10 @@ import (
 
 type SwapV2Router struct {
 	dbc  *storage.DBClient
-	node *rpcclient.Client
+	node chain.ChainSource
 }
 
-func NewSwapV2Router(db *storage.DBClient, node *rpcclient.Client) *SwapV2Router {
+func NewSwapV2Router(db *storage.DBClient, node chain.ChainSource) *SwapV2Router {
 	return &SwapV2Router{
 		dbc:  db,
 		node: node,
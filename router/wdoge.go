@@ -1,20 +1,20 @@
 package router
 
 import (
+	"dogeuni-indexer/chain"
 	"dogeuni-indexer/models"
 	"dogeuni-indexer/storage"
 	"dogeuni-indexer/utils"
-	"github.com/dogecoinw/doged/rpcclient"
 	"github.com/gin-gonic/gin"
 	"net/http"
 )
 
 type WdogeRouter struct {
 	dbc  *storage.DBClient
-	node *rpcclient.Client
+	node chain.ChainSource
 }
 
-func NewWdogeRouter(db *storage.DBClient, node *rpcclient.Client) *WdogeRouter {
+func NewWdogeRouter(db *storage.DBClient, node chain.ChainSource) *WdogeRouter {
 	return &WdogeRouter{
 		dbc:  db,
 		node: node,
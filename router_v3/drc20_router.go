@@ -7,7 +7,6 @@ import (
 	"encoding/hex"
 	"fmt"
 	"github.com/dogecoinw/doged/btcutil"
-	"github.com/dogecoinw/doged/chaincfg"
 	"github.com/dogecoinw/doged/txscript"
 	"github.com/dogecoinw/go-dogecoin/log"
 	"github.com/gin-gonic/gin"
@@ -312,7 +311,7 @@ func (r *Router) FindOrderByAddress(c *gin.Context) {
 		p.Limit = 50
 	}
 
-	_, err := btcutil.DecodeAddress(p.ReceiveAddress, &chaincfg.MainNetParams)
+	_, err := btcutil.DecodeAddress(p.ReceiveAddress, r.dbc.NetParams())
 	if err != nil {
 		log.Error("Router", "FindOrders", fmt.Sprintf("btcutil.DecodeAddress is err:%s", err.Error()))
 		c.JSON(http.StatusInternalServerError, nil)
@@ -487,7 +486,7 @@ func (r *Router) FindOrdersByTick(c *gin.Context) {
 		p.Limit = 50
 	}
 
-	_, err := btcutil.DecodeAddress(p.ReceiveAddress, &chaincfg.MainNetParams)
+	_, err := btcutil.DecodeAddress(p.ReceiveAddress, r.dbc.NetParams())
 	if err != nil {
 		result := &utils.HttpResult{}
 		result.Code = 500
@@ -550,7 +549,7 @@ func (r *Router) ConvertAddress(c *gin.Context) {
 		return
 	}
 
-	inadressBad, err := btcutil.NewAddressScriptHash(script, &chaincfg.MainNetParams)
+	inadressBad, err := btcutil.NewAddressScriptHash(script, r.dbc.NetParams())
 	if err != nil {
 		result := &utils.HttpResult{}
 		result.Code = 500
@@ -567,7 +566,7 @@ func (r *Router) ConvertAddress(c *gin.Context) {
 		return
 	}
 
-	inadressBad2, err := btcutil.NewAddressScriptHash(script2, &chaincfg.MainNetParams)
+	inadressBad2, err := btcutil.NewAddressScriptHash(script2, r.dbc.NetParams())
 	if err != nil {
 		result := &utils.HttpResult{}
 		result.Code = 500
@@ -576,7 +575,7 @@ func (r *Router) ConvertAddress(c *gin.Context) {
 		return
 	}
 
-	inadressd, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(PublicKey), &chaincfg.MainNetParams)
+	inadressd, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(PublicKey), r.dbc.NetParams())
 	if err != nil {
 		result := &utils.HttpResult{}
 		result.Code = 500
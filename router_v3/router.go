@@ -2,13 +2,13 @@ package router_v3
 
 import (
 	"bytes"
+	"dogeuni-indexer/chain"
 	"dogeuni-indexer/models"
 	"dogeuni-indexer/storage"
 	"dogeuni-indexer/storage_v3"
 	"dogeuni-indexer/utils"
 	"encoding/hex"
 	"fmt"
-	"github.com/dogecoinw/doged/rpcclient"
 	"github.com/dogecoinw/doged/wire"
 	"github.com/gin-gonic/gin"
 	shell "github.com/ipfs/go-ipfs-api"
@@ -19,12 +19,12 @@ import (
 type Router struct {
 	mysql *storage_v3.MysqlClient
 	dbc   *storage.DBClient
-	node  *rpcclient.Client
+	node  chain.ChainSource
 	level *storage.LevelDB
 	ipfs  *shell.Shell
 }
 
-func NewRouter(mysql *storage_v3.MysqlClient, dbc *storage.DBClient, level *storage.LevelDB, node *rpcclient.Client, ipfs *shell.Shell) *Router {
+func NewRouter(mysql *storage_v3.MysqlClient, dbc *storage.DBClient, level *storage.LevelDB, node chain.ChainSource, ipfs *shell.Shell) *Router {
 	return &Router{
 		mysql: mysql,
 		node:  node,
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"dogeuni-indexer/chain"
+	"dogeuni-indexer/config"
+	"dogeuni-indexer/snapshot"
+	"flag"
+	"github.com/dogecoinw/go-dogecoin/log"
+	"os"
+)
+
+// snapshotCmd exports the sqlite index at a block height into a verified
+// archive, or installs such an archive so a new indexer starts from it.
+//
+//	dogeuni-indexer snapshot export --height 5000000 [--out .] [--config config.json]
+//	dogeuni-indexer snapshot import --file dogeuni-snapshot-5000000.tar.gz [--force] [--config config.json]
+func snapshotCmd(args []string) {
+	if len(args) == 0 {
+		println("usage: dogeuni-indexer snapshot export|import [flags]")
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "export":
+		snapshotExport(args[1:])
+	case "import":
+		snapshotImport(args[1:])
+	default:
+		println("unknown snapshot command " + args[0])
+		os.Exit(2)
+	}
+}
+
+func snapshotExport(args []string) {
+	fs := flag.NewFlagSet("snapshot export", flag.ExitOnError)
+	height := fs.Int64("height", 0, "last block height kept in the snapshot")
+	out := fs.String("out", ".", "directory the archive is written to")
+	configFile := fs.String("config", "config.json", "config file")
+	_ = fs.Parse(args)
+
+	config.LoadConfig(&cfg, *configFile)
+	setupLog()
+
+	if !cfg.Sqlite.Switch {
+		log.Error("snapshot", "err", "snapshots are only supported for sqlite")
+		os.Exit(1)
+	}
+
+	path, err := snapshot.Export(cfg.Sqlite.Database, *height, *out)
+	if err != nil {
+		log.Error("snapshot", "export", err.Error())
+		os.Exit(1)
+	}
+
+	log.Info("snapshot", "export", path)
+}
+
+func snapshotImport(args []string) {
+	fs := flag.NewFlagSet("snapshot import", flag.ExitOnError)
+	file := fs.String("file", "", "snapshot archive")
+	force := fs.Bool("force", false, "replace an existing database")
+	configFile := fs.String("config", "config.json", "config file")
+	_ = fs.Parse(args)
+
+	config.LoadConfig(&cfg, *configFile)
+	setupLog()
+
+	if !cfg.Sqlite.Switch {
+		log.Error("snapshot", "err", "snapshots are only supported for sqlite")
+		os.Exit(1)
+	}
+
+	rpcClient, err := chain.NewRPCSource(cfg.Chain, nil)
+	if err != nil {
+		log.Error("snapshot", "rpcclient.New", err.Error())
+		os.Exit(1)
+	}
+	defer rpcClient.Shutdown()
+
+	manifest, err := snapshot.Import(*file, cfg.Sqlite.Database, rpcClient, *force)
+	if err != nil {
+		log.Error("snapshot", "import", err.Error())
+		os.Exit(1)
+	}
+
+	if cfg.Explorer.FromBlock != 0 {
+		log.Warn("snapshot", "from_block", cfg.Explorer.FromBlock, "note", "set from_block to 0 so the indexer resumes after the snapshot height")
+	}
+
+	log.Info("snapshot", "import", manifest.Height, "hash", manifest.BlockHash)
+}
@@ -0,0 +1,155 @@
+package snapshot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"gorm.io/gorm"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	// FormatVersion is bumped when the archive layout changes.
+	FormatVersion = 1
+	// SchemaVersion is bumped when a migration changes the indexed tables.
+	SchemaVersion = 3
+
+	manifestName = "manifest.json"
+	databaseName = "dogeuni.db"
+)
+
+// skipTables are not part of the indexed state.
+var skipTables = map[string]bool{
+	"pending_info":  true,
+	"block_journal": true,
+}
+
+// Manifest describes a snapshot archive. The checksum covers every row of
+// every table in Tables, in table name and rowid order.
+type Manifest struct {
+	FormatVersion int              `json:"format_version"`
+	SchemaVersion int              `json:"schema_version"`
+	Height        int64            `json:"height"`
+	BlockHash     string           `json:"block_hash"`
+	StateRoot     string           `json:"state_root"`
+	Tables        map[string]int64 `json:"tables"`
+	Checksum      string           `json:"checksum"`
+}
+
+// NewManifest reads the height, the row counts and the checksum of db.
+func NewManifest(db *gorm.DB) (*Manifest, error) {
+	m := &Manifest{
+		FormatVersion: FormatVersion,
+		SchemaVersion: SchemaVersion,
+		Tables:        make(map[string]int64),
+	}
+
+	err := db.Raw("select block_number, block_hash, coalesce(state_root, '') from block order by block_number desc limit 1").Row().Scan(&m.Height, &m.BlockHash, &m.StateRoot)
+	if err != nil {
+		return nil, fmt.Errorf("last block err: %s", err.Error())
+	}
+
+	tables := make([]string, 0)
+	err = db.Raw("select name from sqlite_master where type = 'table' and name not like 'sqlite_%' order by name").Scan(&tables).Error
+	if err != nil {
+		return nil, fmt.Errorf("list tables err: %s", err.Error())
+	}
+
+	sort.Strings(tables)
+	hash := sha256.New()
+	for _, table := range tables {
+		if skipTables[table] {
+			continue
+		}
+
+		count, err := hashTable(db, table, hash)
+		if err != nil {
+			return nil, fmt.Errorf("hash %s err: %s", table, err.Error())
+		}
+		m.Tables[table] = count
+	}
+
+	m.Checksum = hex.EncodeToString(hash.Sum(nil))
+	return m, nil
+}
+
+func hashTable(db *gorm.DB, table string, w io.Writer) (int64, error) {
+	rows, err := db.Raw(fmt.Sprintf("select * from `%s` order by rowid", table)).Rows()
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+
+	_, _ = w.Write([]byte(table + "\n" + strings.Join(columns, ",") + "\n"))
+
+	count := int64(0)
+	values := make([]interface{}, len(columns))
+	ptrs := make([]interface{}, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return 0, err
+		}
+
+		fields := make([]string, len(values))
+		for i, v := range values {
+			if b, ok := v.([]byte); ok {
+				v = string(b)
+			}
+			fields[i] = fmt.Sprintf("%v", v)
+		}
+		_, _ = w.Write([]byte(strings.Join(fields, "\x1f") + "\n"))
+		count++
+	}
+
+	return count, rows.Err()
+}
+
+// Compare reports the first difference between m and the manifest o.
+func (m *Manifest) Compare(o *Manifest) error {
+	if m.Height != o.Height || m.BlockHash != o.BlockHash {
+		return fmt.Errorf("block %d %s, manifest says %d %s", m.Height, m.BlockHash, o.Height, o.BlockHash)
+	}
+
+	for table, count := range o.Tables {
+		if m.Tables[table] != count {
+			return fmt.Errorf("table %s has %d rows, manifest says %d", table, m.Tables[table], count)
+		}
+	}
+
+	if len(m.Tables) != len(o.Tables) {
+		return fmt.Errorf("%d tables, manifest says %d", len(m.Tables), len(o.Tables))
+	}
+
+	if m.Checksum != o.Checksum {
+		return fmt.Errorf("checksum %s, manifest says %s", m.Checksum, o.Checksum)
+	}
+
+	return nil
+}
+
+func readManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{}
+	err = json.Unmarshal(data, m)
+	if err != nil {
+		return nil, fmt.Errorf("json.Unmarshal err: %s", err.Error())
+	}
+	return m, nil
+}
@@ -0,0 +1,204 @@
+package snapshot
+
+import (
+	"context"
+	"dogeuni-indexer/chain"
+	"dogeuni-indexer/config"
+	"dogeuni-indexer/explorer"
+	"dogeuni-indexer/models"
+	"dogeuni-indexer/storage"
+	"dogeuni-indexer/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/dogecoinw/go-dogecoin/log"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Export writes a snapshot of the sqlite index at database, cut at height, into
+// dir and returns the archive path. The live database is opened read-only and
+// without migrations: a copy is rolled back to height, so the indexer may keep
+// running.
+func Export(database string, height int64, dir string) (string, error) {
+	tmp, err := os.MkdirTemp(dir, "snapshot-")
+	if err != nil {
+		return "", fmt.Errorf("MkdirTemp err: %s", err.Error())
+	}
+	defer os.RemoveAll(tmp)
+
+	copyPath := filepath.Join(tmp, databaseName)
+	err = copyLive(database, copyPath)
+	if err != nil {
+		return "", err
+	}
+
+	dbc := storage.NewSqliteClient(utils.SqliteConfig{Switch: true, Database: copyPath})
+	err = cut(dbc, height)
+	if err != nil {
+		dbc.Stop()
+		return "", err
+	}
+
+	manifest, err := NewManifest(dbc.DB)
+	dbc.Stop()
+	if err != nil {
+		return "", fmt.Errorf("NewManifest err: %s", err.Error())
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("json.Marshal err: %s", err.Error())
+	}
+
+	err = os.WriteFile(filepath.Join(tmp, manifestName), data, 0644)
+	if err != nil {
+		return "", fmt.Errorf("write manifest err: %s", err.Error())
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("dogeuni-snapshot-%d.tar.gz", height))
+	err = writeArchive(path, tmp)
+	if err != nil {
+		return "", fmt.Errorf("writeArchive err: %s", err.Error())
+	}
+
+	return path, nil
+}
+
+// copyLive copies the live database at database to path.
+func copyLive(database string, path string) error {
+	src, err := gorm.Open(sqlite.Open("file:"+database+"?mode=ro"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		return fmt.Errorf("open %s err: %s", database, err.Error())
+	}
+
+	sqlDB, err := src.DB()
+	if err != nil {
+		return fmt.Errorf("open %s err: %s", database, err.Error())
+	}
+	defer sqlDB.Close()
+
+	err = src.Exec("VACUUM INTO ?", path).Error
+	if err != nil {
+		return fmt.Errorf("VACUUM INTO err: %s", err.Error())
+	}
+	return nil
+}
+
+// cut rolls the copy back to height and drops what is not indexed state.
+func cut(dbc *storage.DBClient, height int64) error {
+	if _, ok, err := dbc.JournalUnfinished(); err != nil || ok {
+		return errors.New("the index has an unfinished block, let the indexer recover it first")
+	}
+
+	maxHeight := int64(0)
+	err := dbc.DB.Model(&models.Block{}).Select("max(block_number)").Scan(&maxHeight).Error
+	if err != nil {
+		return fmt.Errorf("max block err: %s", err.Error())
+	}
+
+	if height > maxHeight {
+		return fmt.Errorf("height %d is above the last indexed block %d", height, maxHeight)
+	}
+
+	if height < maxHeight {
+		since := int64(0)
+		err = dbc.DB.Model(&models.Block{}).Select("block_time").Where("block_number = ?", height+1).Scan(&since).Error
+		if err != nil {
+			return fmt.Errorf("block %d err: %s", height+1, err.Error())
+		}
+
+		logged, err := dbc.SummaryLogged(dbc.DB, height, since)
+		if err != nil {
+			return fmt.Errorf("SummaryLogged err: %s", err.Error())
+		}
+
+		// Rollback rebuilds the candles from the trade log
+		if !logged {
+			return fmt.Errorf("the candles of block %d hold trades indexed before the trade log, export at the last block %d or reindex with --widen first", height+1, maxHeight)
+		}
+
+		log.Info("snapshot", "rollback", maxHeight, "to", height)
+		exp := explorer.NewExplorer(context.Background(), &sync.WaitGroup{}, &config.Config{}, nil, dbc, nil)
+		err = exp.Rollback(height)
+		if err != nil {
+			return fmt.Errorf("Rollback err: %s", err.Error())
+		}
+	}
+
+	for _, stmt := range []string{"DELETE FROM pending_info", "PRAGMA journal_mode=DELETE", "VACUUM"} {
+		err = dbc.DB.Exec(stmt).Error
+		if err != nil {
+			return fmt.Errorf("%s err: %s", stmt, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// Import unpacks the snapshot archive, checks it against its manifest and the
+// node, and installs it as the sqlite database at database. The indexer then
+// resumes from the block after the manifest height.
+func Import(archive string, database string, node chain.ChainSource, force bool) (*Manifest, error) {
+	if _, err := os.Stat(database); err == nil && !force {
+		return nil, fmt.Errorf("%s already exists", database)
+	}
+
+	tmp, err := os.MkdirTemp(filepath.Dir(database), "snapshot-")
+	if err != nil {
+		return nil, fmt.Errorf("MkdirTemp err: %s", err.Error())
+	}
+	defer os.RemoveAll(tmp)
+
+	err = extractArchive(archive, tmp)
+	if err != nil {
+		return nil, fmt.Errorf("extractArchive err: %s", err.Error())
+	}
+
+	manifest, err := readManifest(filepath.Join(tmp, manifestName))
+	if err != nil {
+		return nil, fmt.Errorf("readManifest err: %s", err.Error())
+	}
+
+	if manifest.FormatVersion != FormatVersion || manifest.SchemaVersion != SchemaVersion {
+		return nil, fmt.Errorf("snapshot format %d schema %d, this build reads format %d schema %d",
+			manifest.FormatVersion, manifest.SchemaVersion, FormatVersion, SchemaVersion)
+	}
+
+	copyPath := filepath.Join(tmp, databaseName)
+	dbc := storage.NewSqliteClient(utils.SqliteConfig{Switch: true, Database: copyPath})
+	local, err := NewManifest(dbc.DB)
+	dbc.Stop()
+	if err != nil {
+		return nil, fmt.Errorf("NewManifest err: %s", err.Error())
+	}
+
+	err = local.Compare(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot does not match its manifest: %s", err.Error())
+	}
+
+	hash, err := node.GetBlockHash(manifest.Height)
+	if err != nil {
+		return nil, fmt.Errorf("GetBlockHash err: %s", err.Error())
+	}
+
+	if hash.String() != manifest.BlockHash {
+		return nil, fmt.Errorf("block %d is %s on the node, snapshot has %s", manifest.Height, hash.String(), manifest.BlockHash)
+	}
+
+	for _, suffix := range []string{"-wal", "-shm"} {
+		_ = os.Remove(database + suffix)
+	}
+
+	err = os.Rename(copyPath, database)
+	if err != nil {
+		return nil, fmt.Errorf("install database err: %s", err.Error())
+	}
+
+	return manifest, nil
+}
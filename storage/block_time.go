@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"context"
+	"dogeuni-indexer/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+	"reflect"
+)
+
+// dateFields are the timestamps filled with the block time when a row is
+// created without them.
+var dateFields = []string{"CreateDate", "UpdateDate"}
+
+type blockTimeKey struct{}
+
+// AtBlockTime returns a client for the writes of a block. Every row it
+// creates without dates takes blockTime as create_date and update_date, so a
+// resync writes the same timestamps. The other clients store missing dates as
+// NULL.
+func (db *DBClient) AtBlockTime(blockTime int64) *DBClient {
+	c := *db
+	c.DB = db.DB.WithContext(context.WithValue(db.DB.Statement.Context, blockTimeKey{}, blockTime))
+	return &c
+}
+
+func (db *DBClient) registerBlockTime() error {
+	return db.DB.Callback().Create().Before("gorm:create").Register("dogeuni:block_time", fillBlockTime)
+}
+
+func fillBlockTime(tx *gorm.DB) {
+	blockTime, _ := tx.Statement.Context.Value(blockTimeKey{}).(int64)
+	if blockTime == 0 || tx.Statement.Schema == nil {
+		return
+	}
+
+	for _, name := range dateFields {
+		field := tx.Statement.Schema.LookUpField(name)
+		if field == nil {
+			continue
+		}
+
+		rv := tx.Statement.ReflectValue
+		switch rv.Kind() {
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < rv.Len(); i++ {
+				fillZero(tx, field, reflect.Indirect(rv.Index(i)), blockTime)
+			}
+		case reflect.Struct:
+			fillZero(tx, field, rv, blockTime)
+		}
+	}
+}
+
+func fillZero(tx *gorm.DB, field *schema.Field, rv reflect.Value, blockTime int64) {
+	if _, zero := field.ValueOf(tx.Statement.Context, rv); !zero {
+		return
+	}
+
+	if err := field.Set(tx.Statement.Context, rv, models.LocalTime(blockTime)); err != nil {
+		_ = tx.AddError(err)
+	}
+}
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"github.com/dogecoinw/go-dogecoin/rlp"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+const (
+	chainCachePrefix    = "chain-"
+	chainCacheSeqPrefix = "chainseq-"
+	chainCacheMetaKey   = "chainmeta"
+)
+
+// chainCacheMeta tracks the cached entries in insertion order, Tail is the
+// oldest one and Head the next sequence to hand out.
+type chainCacheMeta struct {
+	Head uint64
+	Tail uint64
+	Size uint64
+}
+
+type chainCacheSeq struct {
+	Key  string
+	Size uint64
+}
+
+// GetChainCache returns the data cached under key, leveldb.ErrNotFound on a miss.
+func (conn *LevelDB) GetChainCache(key string) ([]byte, error) {
+	conn.lock.RLock()
+	defer conn.lock.RUnlock()
+
+	return conn.DB.Get([]byte(chainCachePrefix+key), nil)
+}
+
+// SetChainCache caches data under key. The oldest entries are evicted until
+// the cache fits in limit bytes.
+func (conn *LevelDB) SetChainCache(key string, data []byte, limit uint64) error {
+	conn.lock.Lock()
+	defer conn.lock.Unlock()
+
+	size := uint64(len(data))
+	if size > limit {
+		return nil
+	}
+
+	if ok, err := conn.DB.Has([]byte(chainCachePrefix+key), nil); err != nil || ok {
+		return err
+	}
+
+	meta := &chainCacheMeta{}
+	if data, err := conn.DB.Get([]byte(chainCacheMetaKey), nil); err == nil {
+		if err := rlp.DecodeBytes(data, meta); err != nil {
+			return fmt.Errorf("chain cache meta err: %s", err.Error())
+		}
+	} else if !errors.Is(err, leveldb.ErrNotFound) {
+		return err
+	}
+
+	batch := new(leveldb.Batch)
+	for meta.Size+size > limit && meta.Tail < meta.Head {
+		seqKey := []byte(fmt.Sprintf("%s%020d", chainCacheSeqPrefix, meta.Tail))
+		data, err := conn.DB.Get(seqKey, nil)
+		if err != nil {
+			return fmt.Errorf("chain cache seq %d err: %s", meta.Tail, err.Error())
+		}
+
+		seq := &chainCacheSeq{}
+		if err := rlp.DecodeBytes(data, seq); err != nil {
+			return fmt.Errorf("chain cache seq %d err: %s", meta.Tail, err.Error())
+		}
+
+		batch.Delete([]byte(chainCachePrefix + seq.Key))
+		batch.Delete(seqKey)
+		meta.Size -= seq.Size
+		meta.Tail++
+	}
+
+	seq, err := rlp.EncodeToBytes(&chainCacheSeq{Key: key, Size: size})
+	if err != nil {
+		return err
+	}
+
+	batch.Put([]byte(chainCachePrefix+key), data)
+	batch.Put([]byte(fmt.Sprintf("%s%020d", chainCacheSeqPrefix, meta.Head)), seq)
+	meta.Head++
+	meta.Size += size
+
+	metaData, err := rlp.EncodeToBytes(meta)
+	if err != nil {
+		return err
+	}
+	batch.Put([]byte(chainCacheMetaKey), metaData)
+
+	return conn.DB.Write(batch, nil)
+}
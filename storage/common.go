@@ -14,14 +14,6 @@ func (db *DBClient) ScheduledTasks(height int64) error {
 
 	s := time.Now()
 
-	//if height < 5260645 {
-	//	err = db.StakeUpdatePoolScheduled(tx, height)
-	//	if err != nil {
-	//		tx.Rollback()
-	//		return err
-	//	}
-	//}
-
 	tx := db.DB.Begin()
 	err := db.BoxDeployScheduled(tx, height)
 	if err != nil {
@@ -107,6 +99,16 @@ func (db *DBClient) TransferDrc20(tx *gorm.DB, tick, from, to string, amt *big.I
 		if err != nil {
 			return err
 		}
+
+		err = db.StateChange(tx, height, "drc20", tick, from, sub.String())
+		if err != nil {
+			return err
+		}
+
+		err = db.StateChange(tx, height, "drc20", tick, to, add.String())
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -148,9 +150,9 @@ func (db *DBClient) MintDrc20(tx *gorm.DB, tick, holderAddress string, amt *big.
 
 	trans := drc20c.Transactions + 1
 	if fork {
-		trans = drc20c.Transactions - 1
-		if trans < 0 {
-			trans = 0
+		trans = 0
+		if drc20c.Transactions > 0 {
+			trans = drc20c.Transactions - 1
 		}
 	}
 
@@ -176,6 +178,11 @@ func (db *DBClient) MintDrc20(tx *gorm.DB, tick, holderAddress string, amt *big.
 		if err != nil {
 			return err
 		}
+
+		err = db.StateChange(tx, height, "drc20", tick, holderAddress, sum1.String())
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -214,9 +221,9 @@ func (db *DBClient) BurnDrc20(tx *gorm.DB, tick, holderAddress string, amt *big.
 
 	trans := drc20c.Transactions + 1
 	if fork {
-		trans = drc20c.Transactions - 1
-		if trans < 0 {
-			trans = 0
+		trans = 0
+		if drc20c.Transactions > 0 {
+			trans = drc20c.Transactions - 1
 		}
 	}
 
@@ -242,6 +249,11 @@ func (db *DBClient) BurnDrc20(tx *gorm.DB, tick, holderAddress string, amt *big.
 		if err != nil {
 			return err
 		}
+
+		err = db.StateChange(tx, height, "drc20", tick, holderAddress, sum1.String())
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -292,6 +304,7 @@ func (db *DBClient) StakeStakeV1(tx *gorm.DB, tick, holderAddress string, amt *b
 		return fmt.Errorf("StakeStake UpdateStakeCollect err: %s tick: %s", err.Error(), tick)
 	}
 
+	amt1 := amt
 	stakeca := &models.StakeCollectAddress{}
 	err = tx.Where("tick = ? and holder_address = ?", tick, holderAddress).First(stakeca).Error
 	if err != nil {
@@ -308,7 +321,7 @@ func (db *DBClient) StakeStakeV1(tx *gorm.DB, tick, holderAddress string, amt *b
 			return fmt.Errorf("StakeStake FindStakeCollectAddress err: %s tick: %s from : %s", err.Error(), tick, holderAddress)
 		}
 	} else {
-		amt1 := big.NewInt(0).Add(stakeca.Amt.Int(), amt)
+		amt1 = big.NewInt(0).Add(stakeca.Amt.Int(), amt)
 		err = tx.Model(stakeca).Where("tick = ? and holder_address = ?", tick, holderAddress).Update("amt", amt1.String()).Error
 		if err != nil {
 			return fmt.Errorf("StakeStake UpdateStakeCollectAddress err: %s tick: %s from : %s", err.Error(), tick, holderAddress)
@@ -328,6 +341,11 @@ func (db *DBClient) StakeStakeV1(tx *gorm.DB, tick, holderAddress string, amt *b
 		if err != nil {
 			return err
 		}
+
+		err = db.StateChange(tx, height, "stake", tick, holderAddress, amt1.String())
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -382,6 +400,11 @@ func (db *DBClient) StakeUnStakeV1(tx *gorm.DB, tick, holderAddress string, amt
 		if err != nil {
 			return err
 		}
+
+		err = db.StateChange(tx, height, "stake", tick, holderAddress, amt1.String())
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -420,8 +443,11 @@ func (db *DBClient) StakeRewardV1(tx *gorm.DB, tick, holderAddress string, txHas
 	return nil
 }
 
-func (db *DBClient) StakeGetRewardV1(tx *gorm.DB, holderAddress, tick string) ([]*models.HolderReward, error) {
+// StakeGetRewardV1 splits the unclaimed rewards of holderAddress over the pool
+// balances at height.
+func (db *DBClient) StakeGetRewardV1(tx *gorm.DB, holderAddress, tick string, height int64) ([]*models.HolderReward, error) {
 
+	stakePoolAddress := db.ProtocolParams(height).StakePoolAddress
 	poolResults := make([]*models.Drc20CollectAddress, 0)
 	err := tx.Where("holder_address = ? and amt_sum != '0'", stakePoolAddress).Find(&poolResults).Error
 	if err != nil {
@@ -623,6 +649,16 @@ func (db *DBClient) TransferMeme20(tx *gorm.DB, tickId, from, to string, amt *bi
 		if err != nil {
 			return err
 		}
+
+		err = db.StateChange(tx, height, "meme20", tickId, from, sub.String())
+		if err != nil {
+			return err
+		}
+
+		err = db.StateChange(tx, height, "meme20", tickId, to, add.String())
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -706,6 +742,11 @@ func (db *DBClient) MintMeme20(tx *gorm.DB, tickId, holderAddress string, amt *b
 		if err != nil {
 			return err
 		}
+
+		err = db.StateChange(tx, height, "meme20", tickId, holderAddress, sum1.String())
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -792,6 +833,144 @@ func (db *DBClient) BurnMeme20(tx *gorm.DB, tickId, holderAddress string, amt *b
 		if err != nil {
 			return err
 		}
+
+		err = db.StateChange(tx, height, "meme20", tickId, holderAddress, sum1.String())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (db *DBClient) MintNft(tx *gorm.DB, tick, holderAddress, prompt, imagePath, txHash string, height int64) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	log.Info("explorer", "MintNft", "start", "tick", tick, "holderAddress", holderAddress)
+
+	nftc := &models.NftCollect{}
+	err := tx.Where("tick = ?", tick).First(nftc).Error
+	if err != nil {
+		return fmt.Errorf("MintNft FindNftCollect err: %s tick: %s", err.Error(), tick)
+	}
+
+	tickId := nftc.TickSum + 1
+	err = tx.Model(nftc).Where("tick = ?", tick).Updates(map[string]interface{}{"tick_sum": tickId, "transactions": nftc.Transactions + 1}).Error
+	if err != nil {
+		return fmt.Errorf("MintNft UpdateNftCollect err: %s tick: %s", err.Error(), tick)
+	}
+
+	nca := &models.NftCollectAddress{
+		Tick:          tick,
+		TickId:        tickId,
+		Prompt:        prompt,
+		ImagePath:     imagePath,
+		DeployHash:    txHash,
+		HolderAddress: holderAddress,
+	}
+
+	err = tx.Create(nca).Error
+	if err != nil {
+		return fmt.Errorf("MintNft CreateNftCollectAddress err: %s tick: %s from : %s", err.Error(), tick, holderAddress)
+	}
+
+	err = db.StateChange(tx, height, "nft", fmt.Sprintf("%s#%d", tick, tickId), holderAddress)
+	if err != nil {
+		return err
+	}
+
+	revert := &models.NftRevert{
+		Tick:        tick,
+		TickId:      tickId,
+		ToAddress:   holderAddress,
+		BlockNumber: height,
+	}
+
+	err = tx.Create(revert).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// BurnNft undoes a mint while forking, the state changes of the block are
+// dropped with it.
+func (db *DBClient) BurnNft(tx *gorm.DB, tick, holderAddress string, tickId int64) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	log.Info("explorer", "BurnNft", "start", "tick", tick, "holderAddress", holderAddress, "tickId", tickId)
+
+	nftc := &models.NftCollect{}
+	err := tx.Where("tick = ?", tick).First(nftc).Error
+	if err != nil {
+		return fmt.Errorf("BurnNft FindNftCollect err: %s tick: %s", err.Error(), tick)
+	}
+
+	trans := nftc.Transactions - 1
+	if trans < 0 {
+		trans = 0
+	}
+
+	err = tx.Model(nftc).Where("tick = ?", tick).Updates(map[string]interface{}{"tick_sum": nftc.TickSum - 1, "transactions": trans}).Error
+	if err != nil {
+		return fmt.Errorf("BurnNft UpdateNftCollect err: %s tick: %s", err.Error(), tick)
+	}
+
+	err = tx.Where("tick = ? AND tick_id = ? AND holder_address = ?", tick, tickId, holderAddress).Delete(&models.NftCollectAddress{}).Error
+	if err != nil {
+		return fmt.Errorf("BurnNft DeleteNftCollectAddress err: %s tick: %s from : %s", err.Error(), tick, holderAddress)
+	}
+
+	return nil
+}
+
+func (db *DBClient) TransferNft(tx *gorm.DB, tick, from, to string, tickId int64, height int64, fork bool) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	log.Info("explorer", "TransferNft", "start", "tick", tick, "from", from, "to", to, "tickId", tickId, "fork", fork)
+
+	nftc := &models.NftCollect{}
+	err := tx.Where("tick = ?", tick).First(nftc).Error
+	if err != nil {
+		return fmt.Errorf("TransferNft FindNftCollect err: %s tick: %s", err.Error(), tick)
+	}
+
+	trans := nftc.Transactions + 1
+	if fork {
+		trans = nftc.Transactions - 1
+		if trans < 0 {
+			trans = 0
+		}
+	}
+
+	err = tx.Model(nftc).Where("tick = ?", tick).Update("transactions", trans).Error
+	if err != nil {
+		return fmt.Errorf("TransferNft UpdateNftCollect err: %s tick: %s", err.Error(), tick)
+	}
+
+	err = tx.Model(&models.NftCollectAddress{}).Where("tick = ? AND tick_id = ? AND holder_address = ?", tick, tickId, from).Update("holder_address", to).Error
+	if err != nil {
+		return fmt.Errorf("TransferNft UpdateNftCollectAddress err: %s tick: %s from : %s", err.Error(), tick, from)
+	}
+
+	if !fork {
+		err = db.StateChange(tx, height, "nft", fmt.Sprintf("%s#%d", tick, tickId), to)
+		if err != nil {
+			return err
+		}
+
+		revert := &models.NftRevert{
+			Tick:        tick,
+			TickId:      tickId,
+			FromAddress: from,
+			ToAddress:   to,
+			BlockNumber: height,
+		}
+		err = tx.Create(revert).Error
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
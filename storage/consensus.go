@@ -28,7 +28,7 @@ func (e *DBClient) ConsensusStake(tx *gorm.DB, consensus *models.ConsensusInfo,
 	}
 
 	// Ledger migration: holder -> reserves
-	if err := e.TransferDrc20(tx, "CARDI", consensus.HolderAddress, reservesAddress, consensus.Amt.Int(), consensus.TxHash, consensus.BlockNumber, false); err != nil {
+	if err := e.TransferDrc20(tx, e.ProtocolParams(consensus.BlockNumber).ConsensusTick, consensus.HolderAddress, reservesAddress, consensus.Amt.Int(), consensus.TxHash, consensus.BlockNumber, false); err != nil {
 		return fmt.Errorf("transfer doge to reserves address error: %v", err)
 	}
 
@@ -79,8 +79,8 @@ func (e *DBClient) ConsensusUnstake(tx *gorm.DB, consensus *models.ConsensusInfo
 		return fmt.Errorf("update stake record error: %v", err)
 	}
 
-	// Ledger migration: reserves -> holder (full amount)
-	if err := e.TransferDrc20(tx, "CARDI", reservesAddress, consensus.HolderAddress, record.Amt.Int(), consensus.TxHash, consensus.BlockNumber, false); err != nil {
+	// Ledger migration: reserves -> holder (full amount), in the tick staked
+	if err := e.TransferDrc20(tx, e.ProtocolParams(record.StakeBlock).ConsensusTick, reservesAddress, consensus.HolderAddress, record.Amt.Int(), consensus.TxHash, consensus.BlockNumber, false); err != nil {
 		return fmt.Errorf("transfer doge from reserves address error: %v", err)
 	}
 
@@ -1,9 +1,12 @@
 package storage
 
 import (
+	"dogeuni-indexer/metrics"
 	"dogeuni-indexer/models"
+	"dogeuni-indexer/params"
 	"dogeuni-indexer/utils"
 	"fmt"
+	"github.com/dogecoinw/doged/chaincfg"
 	_ "github.com/go-sql-driver/mysql"
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/sqlite"
@@ -16,13 +19,15 @@ import (
 )
 
 const (
-	NETWORK          = "tcp"
-	stakePoolAddress = "DS8eFcobjXp6oL8YoXoVazDQ32bcDdWwui"
+	NETWORK = "tcp"
 )
 
 type DBClient struct {
-	DB   *gorm.DB
-	lock *sync.RWMutex
+	DB        *gorm.DB
+	lock      *sync.RWMutex
+	replay    *int64
+	netParams *chaincfg.Params
+	protocol  *params.Schedule
 }
 
 func NewSqliteClient(cfg utils.SqliteConfig) *DBClient {
@@ -38,7 +43,7 @@ func NewSqliteClient(cfg utils.SqliteConfig) *DBClient {
 	)
 
 	// github.com/mattn/go-sqlite3
-	db, err := gorm.Open(sqlite.Open(cfg.Database), &gorm.Config{Logger: newLogger})
+	db, err := gorm.Open(sqlite.Open(cfg.Database), &gorm.Config{Logger: metrics.GormLogger(newLogger)})
 	if err != nil {
 		fmt.Printf("Open failed,err:%v  ", err)
 		os.Exit(0)
@@ -46,8 +51,13 @@ func NewSqliteClient(cfg utils.SqliteConfig) *DBClient {
 
 	_ = db.Exec("PRAGMA journal_mode=WAL;")
 
-	if err := db.AutoMigrate(&models.StakeV2Revert{}); err != nil {
-		fmt.Printf("AutoMigrate stake_v2_revert failed, err:%v  ", err)
+	if err := db.AutoMigrate(&models.StakeV2Revert{}, &models.PendingInfo{}, &models.Block{}, &models.BlockJournal{}, &models.BlockInscription{}, &models.DecodeFailure{}, &models.StateChange{}, &models.ReorgEvent{}, &models.SummaryTrade{}); err != nil {
+		fmt.Printf("AutoMigrate failed, err:%v  ", err)
+		os.Exit(0)
+	}
+
+	if err := migrateTxIndex(db); err != nil {
+		fmt.Printf("migrateTxIndex failed, err:%v  ", err)
 		os.Exit(0)
 	}
 
@@ -63,8 +73,16 @@ func NewSqliteClient(cfg utils.SqliteConfig) *DBClient {
 
 	lock := new(sync.RWMutex)
 	conn := &DBClient{
-		DB:   db,
-		lock: lock,
+		DB:        db,
+		lock:      lock,
+		replay:    new(int64),
+		netParams: &chaincfg.MainNetParams,
+		protocol:  params.NewSchedule(params.Mainnet),
+	}
+
+	if err := conn.registerBlockTime(); err != nil {
+		fmt.Printf("registerBlockTime failed, err:%v  ", err)
+		os.Exit(0)
 	}
 
 	return conn
@@ -84,26 +102,71 @@ func NewMysqlClient(cfg utils.MysqlConfig) *DBClient {
 		},
 	)
 
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: newLogger})
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: metrics.GormLogger(newLogger)})
 	if err != nil {
 		fmt.Printf("Open failed,err:%v  ", err)
 		os.Exit(0)
 	}
 
-	if err := db.AutoMigrate(&models.StakeV2Revert{}); err != nil {
-		fmt.Printf("AutoMigrate stake_v2_revert failed, err:%v  ", err)
+	if err := db.AutoMigrate(&models.StakeV2Revert{}, &models.PendingInfo{}, &models.Block{}, &models.BlockJournal{}, &models.BlockInscription{}, &models.DecodeFailure{}, &models.StateChange{}, &models.ReorgEvent{}, &models.SummaryTrade{}); err != nil {
+		fmt.Printf("AutoMigrate failed, err:%v  ", err)
+		os.Exit(0)
+	}
+
+	if err := migrateTxIndex(db); err != nil {
+		fmt.Printf("migrateTxIndex failed, err:%v  ", err)
 		os.Exit(0)
 	}
 
 	lock := new(sync.RWMutex)
 	conn := &DBClient{
-		DB:   db,
-		lock: lock,
+		DB:        db,
+		lock:      lock,
+		replay:    new(int64),
+		netParams: &chaincfg.MainNetParams,
+		protocol:  params.NewSchedule(params.Mainnet),
+	}
+
+	if err := conn.registerBlockTime(); err != nil {
+		fmt.Printf("registerBlockTime failed, err:%v  ", err)
+		os.Exit(0)
 	}
 
 	return conn
 }
 
+// migrateTxIndex adds the tx_index column to the *_info tables of databases
+// indexed before a transaction could carry more than one inscription.
+func migrateTxIndex(db *gorm.DB) error {
+	infos := []interface{}{
+		&models.BoxInfo{},
+		&models.ConsensusInfo{},
+		&models.CrossInfo{},
+		&models.Drc20Info{},
+		&models.ExchangeInfo{},
+		&models.FileInfo{},
+		&models.FileExchangeInfo{},
+		&models.InviteInfo{},
+		&models.Meme20Info{},
+		&models.NftInfo{},
+		&models.StakeInfo{},
+		&models.StakeV2Info{},
+		&models.WDogeInfo{},
+	}
+
+	m := db.Migrator()
+	for _, info := range infos {
+		if !m.HasTable(info) || m.HasColumn(info, "TxIndex") {
+			continue
+		}
+		if err := m.AddColumn(info, "TxIndex"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (db *DBClient) Stop() {
 	sqlDB, err := db.DB.DB()
 	if err != nil {
@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"dogeuni-indexer/models"
+	"errors"
+	"fmt"
+	"github.com/dogecoinw/doged/btcjson"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (db *DBClient) DecodeFailureCreate(failure *models.DecodeFailure) error {
+	return db.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tx_hash"}, {Name: "tx_index"}},
+		DoUpdates: clause.AssignmentColumns([]string{"p", "block_number", "err_info"}),
+	}).Create(failure).Error
+}
+
+// DecodeFailureNoTx is the first block with an inscription that failed to
+// decode because the node did not know a transaction it spends.
+func (db *DBClient) DecodeFailureNoTx() (int64, bool, error) {
+	failure := &models.DecodeFailure{}
+	err := db.DB.Where("err_info like ?", fmt.Sprintf("%%%d: %%", btcjson.ErrRPCNoTxInfo)).Order("block_number").First(failure).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return failure.BlockNumber, true, nil
+}
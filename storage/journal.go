@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"dogeuni-indexer/models"
+	"errors"
+	"gorm.io/gorm"
+)
+
+// JournalBegin records that the block at height is being applied.
+func (db *DBClient) JournalBegin(height int64, hash string) error {
+	return db.DB.Save(&models.BlockJournal{BlockNumber: height, BlockHash: hash}).Error
+}
+
+// JournalEnd saves block with its state root and drops its journal row in one
+// transaction, the block counts as applied from then on.
+func (db *DBClient) JournalEnd(block *models.Block) error {
+	tx := db.DB.Begin()
+	root, err := db.StateRoot(tx, block.BlockNumber)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	block.StateRoot = root
+	err = tx.Save(block).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Where("block_number = ?", block.BlockNumber).Delete(&models.BlockJournal{}).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// JournalUnfinished returns the lowest height whose application did not finish.
+func (db *DBClient) JournalUnfinished() (int64, bool, error) {
+	journal := &models.BlockJournal{}
+	err := db.DB.Order("block_number asc").First(journal).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return journal.BlockNumber, true, nil
+}
+
+// JournalClear drops the journal rows from height on.
+func (db *DBClient) JournalClear(tx *gorm.DB, height int64) error {
+	return tx.Where("block_number >= ?", height).Delete(&models.BlockJournal{}).Error
+}
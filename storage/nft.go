@@ -23,22 +23,33 @@ func (db *DBClient) NftDeploy(tx *gorm.DB, model *models.NftInfo) error {
 		return fmt.Errorf("NftDeploy err: %s order_id: %s", err.Error(), model.OrderId)
 	}
 
+	// a revert without addresses marks the deploy
+	revert := &models.NftRevert{
+		Tick:        model.Tick,
+		BlockNumber: model.BlockNumber,
+	}
+
+	err = tx.Create(revert).Error
+	if err != nil {
+		return fmt.Errorf("NftDeploy InstallNftRevert err: %s order_id: %s", err.Error(), model.OrderId)
+	}
+
 	return nil
 }
 
 func (db *DBClient) NftMint(tx *gorm.DB, model *models.NftInfo) error {
-	//err := db.MintNft(tx, model.Tick, model.HolderAddress, model.Seed, model.Prompt, model.Image, model.ImagePath, model.TxHash, model.BlockNumber, false)
-	//if err != nil {
-	//	return fmt.Errorf("NftMint err: %s order_id: %s", err.Error(), model.OrderId)
-	//}
+	err := db.MintNft(tx, model.Tick, model.HolderAddress, model.Prompt, model.ImagePath, model.TxHash, model.BlockNumber)
+	if err != nil {
+		return fmt.Errorf("NftMint err: %s order_id: %s", err.Error(), model.OrderId)
+	}
 	return nil
 }
 
 func (db *DBClient) NftTransfer(tx *gorm.DB, model *models.NftInfo) error {
-	//err := db.TransferNft(tx, model.Tick, model.HolderAddress, model.ToAddress, model.TickId, model.BlockNumber, false)
-	//if err != nil {
-	//	return fmt.Errorf("NftTransfer err: %s order_id: %s", err.Error(), model.OrderId)
-	//}
-	//
+	err := db.TransferNft(tx, model.Tick, model.HolderAddress, model.ToAddress, model.TickId, model.BlockNumber, false)
+	if err != nil {
+		return fmt.Errorf("NftTransfer err: %s order_id: %s", err.Error(), model.OrderId)
+	}
+
 	return nil
 }
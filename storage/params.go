@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"dogeuni-indexer/params"
+	"github.com/dogecoinw/doged/chaincfg"
+)
+
+// SetNetParams selects the network the reserve addresses are derived for. It
+// is set once at startup, mainnet until then.
+func (db *DBClient) SetNetParams(net *chaincfg.Params) {
+	db.netParams = net
+}
+
+func (db *DBClient) NetParams() *chaincfg.Params {
+	return db.netParams
+}
+
+// SetProtocolParams replaces the protocol parameters schedule. It is set once
+// at startup, the mainnet values until then.
+func (db *DBClient) SetProtocolParams(schedule *params.Schedule) {
+	db.protocol = schedule
+}
+
+// ProtocolParams returns the protocol parameters in force at height.
+func (db *DBClient) ProtocolParams(height int64) *params.Params {
+	return db.protocol.At(height)
+}
+
+// ProtocolGate reports why op of protocol p is gated off at height, nil when
+// it is accepted.
+func (db *DBClient) ProtocolGate(p, op string, height int64) error {
+	return db.protocol.Gate(p, op, height)
+}
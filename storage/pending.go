@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"dogeuni-indexer/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WithTx returns a client that runs every query of the callee on tx and
+// shares everything else with db.
+func (db *DBClient) WithTx(tx *gorm.DB) *DBClient {
+	c := *db
+	c.DB = tx
+	return &c
+}
+
+func (db *DBClient) PendingCreate(pending *models.PendingInfo) error {
+	return db.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(pending).Error
+}
+
+func (db *DBClient) PendingTxHashes() ([]string, error) {
+	hashes := make([]string, 0)
+	err := db.DB.Model(&models.PendingInfo{}).Pluck("tx_hash", &hashes).Error
+	return hashes, err
+}
+
+func (db *DBClient) PendingDelete(hashes []string) error {
+	if len(hashes) == 0 {
+		return nil
+	}
+	return db.DB.Where("tx_hash in ?", hashes).Delete(&models.PendingInfo{}).Error
+}
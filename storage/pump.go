@@ -2,33 +2,26 @@ package storage
 
 import (
 	"dogeuni-indexer/models"
+	"dogeuni-indexer/utils"
 	"errors"
 	"fmt"
 	"github.com/dogecoinw/doged/btcutil"
-	"github.com/dogecoinw/doged/chaincfg"
 	"github.com/dogecoinw/go-dogecoin/log"
-	"github.com/google/uuid"
 	"gorm.io/gorm"
 	"math/big"
 )
 
-const (
-	FinishFeeAddress = "DJ9wVHBFnbcZUtfWdHWPEnijdxz1CABPUY"
-	TxFeeAddress     = "D7NfMMzqWB9FaUssLwgCs14Q5F6CCfpf9A"
-)
-
 var (
-	MemeMax             = models.NewNumber(100000000000000000)
-	DogeInit            = models.NewNumber(300000000000)
-	DogeMax             = models.NewNumber(10000000000000)
-	DogeKingMax         = models.NewNumber(1000000000000)
-	PumpFinishFee       = models.NewNumber(100000000000)
-	PumpCreateHolderFee = models.NewNumber(10000000000)
+	DogeMax     = models.NewNumber(10000000000000)
+	DogeKingMax = models.NewNumber(1000000000000)
 )
 
 func (db *DBClient) PumpDeploy(tx *gorm.DB, pump *models.PumpInfo) error {
 
-	reservesAddress, _ := btcutil.NewAddressScriptHash([]byte(pump.Tick0Id+pump.Tick1Id), &chaincfg.MainNetParams)
+	reservesAddress, _ := btcutil.NewAddressScriptHash([]byte(pump.Tick0Id+pump.Tick1Id), db.NetParams())
+	p := db.ProtocolParams(pump.BlockNumber)
+	memeMax := models.NewNumber(p.MemeMax)
+	dogeInit := models.NewNumber(p.DogeInit)
 
 	meme20c := &models.Meme20Collect{}
 	err := tx.Where("tick_id = ?", pump.Tick0Id).First(meme20c).Error
@@ -42,7 +35,7 @@ func (db *DBClient) PumpDeploy(tx *gorm.DB, pump *models.PumpInfo) error {
 			TickId:        pump.Tick0Id,
 			Name:          pump.Name,
 			Logo:          pump.Logo,
-			Max:           MemeMax,
+			Max:           memeMax,
 			Dec:           8,
 			Reserve:       pump.Reserve,
 			HolderAddress: reservesAddress.String(),
@@ -54,7 +47,7 @@ func (db *DBClient) PumpDeploy(tx *gorm.DB, pump *models.PumpInfo) error {
 		}
 	} else {
 		updates := map[string]interface{}{
-			"max_":    MemeMax,
+			"max_":    memeMax,
 			"tick":    pump.Symbol,
 			"name":    pump.Name,
 			"logo":    pump.Logo,
@@ -70,7 +63,7 @@ func (db *DBClient) PumpDeploy(tx *gorm.DB, pump *models.PumpInfo) error {
 
 	if pump.Reserve > 0 {
 
-		mememax := new(big.Int).Div(MemeMax.Int(), big.NewInt(100))
+		mememax := new(big.Int).Div(memeMax.Int(), big.NewInt(100))
 		mememax = new(big.Int).Mul(mememax, big.NewInt(int64(100-pump.Reserve)))
 
 		meme20_0 := &models.Meme20CollectAddress{
@@ -88,7 +81,7 @@ func (db *DBClient) PumpDeploy(tx *gorm.DB, pump *models.PumpInfo) error {
 		meme20_1 := &models.Meme20CollectAddress{
 			TickId:        pump.Tick0Id,
 			HolderAddress: pump.HolderAddress,
-			Amt:           (*models.Number)(big.NewInt(0).Sub(MemeMax.Int(), mememax)),
+			Amt:           (*models.Number)(big.NewInt(0).Sub(memeMax.Int(), mememax)),
 			Transactions:  1,
 		}
 
@@ -103,7 +96,7 @@ func (db *DBClient) PumpDeploy(tx *gorm.DB, pump *models.PumpInfo) error {
 			Amt0:            (*models.Number)(mememax),
 			Tick1:           pump.Tick1Id,
 			Tick1Id:         pump.Tick1Id,
-			Amt1:            DogeInit,
+			Amt1:            dogeInit,
 			HolderAddress:   pump.HolderAddress,
 			ReservesAddress: reservesAddress.String(),
 			KingDate:        1,
@@ -114,15 +107,30 @@ func (db *DBClient) PumpDeploy(tx *gorm.DB, pump *models.PumpInfo) error {
 			return fmt.Errorf("PumpLiquidity Create err: %s", err.Error())
 		}
 
+		err = db.StateChange(tx, pump.BlockNumber, "meme20", pump.Tick0Id, meme20_0.HolderAddress, meme20_0.Amt.String())
+		if err != nil {
+			return err
+		}
+
+		err = db.StateChange(tx, pump.BlockNumber, "meme20", pump.Tick0Id, meme20_1.HolderAddress, meme20_1.Amt.String())
+		if err != nil {
+			return err
+		}
+
+		err = db.StateChange(tx, pump.BlockNumber, "pump", sl.Tick0Id, "", sl.Amt0.String(), sl.Amt1.String())
+		if err != nil {
+			return err
+		}
+
 		pump.Amt0Out = (*models.Number)(mememax)
-		pump.Amt1Out = DogeInit
+		pump.Amt1Out = dogeInit
 
 	} else {
 
 		meme20_0 := &models.Meme20CollectAddress{
 			TickId:        pump.Tick0Id,
 			HolderAddress: reservesAddress.String(),
-			Amt:           MemeMax,
+			Amt:           memeMax,
 			Transactions:  1,
 		}
 
@@ -134,10 +142,10 @@ func (db *DBClient) PumpDeploy(tx *gorm.DB, pump *models.PumpInfo) error {
 		sl := &models.PumpLiquidity{
 			Tick0:           pump.Symbol,
 			Tick0Id:         pump.Tick0Id,
-			Amt0:            MemeMax,
+			Amt0:            memeMax,
 			Tick1:           pump.Tick1Id,
 			Tick1Id:         pump.Tick1Id,
-			Amt1:            DogeInit,
+			Amt1:            dogeInit,
 			HolderAddress:   pump.HolderAddress,
 			ReservesAddress: reservesAddress.String(),
 			KingDate:        1,
@@ -148,8 +156,18 @@ func (db *DBClient) PumpDeploy(tx *gorm.DB, pump *models.PumpInfo) error {
 			return fmt.Errorf("PumpLiquidity Create err: %s", err.Error())
 		}
 
-		pump.Amt0Out = MemeMax
-		pump.Amt1Out = DogeInit
+		err = db.StateChange(tx, pump.BlockNumber, "meme20", pump.Tick0Id, meme20_0.HolderAddress, meme20_0.Amt.String())
+		if err != nil {
+			return err
+		}
+
+		err = db.StateChange(tx, pump.BlockNumber, "pump", sl.Tick0Id, "", sl.Amt0.String(), sl.Amt1.String())
+		if err != nil {
+			return err
+		}
+
+		pump.Amt0Out = memeMax
+		pump.Amt1Out = dogeInit
 	}
 
 	err = db.SummaryPumpCreate(tx, pump)
@@ -212,17 +230,18 @@ func (db *DBClient) PumpTrade(tx *gorm.DB, pump *models.PumpInfo) error {
 		return fmt.Errorf("pumpTrade error: %v", err)
 	}
 
+	txFeeAddress := db.ProtocolParams(pump.BlockNumber).PumpTxFeeAddress
 	amtfee0 := big.NewInt(0)
 	if len(pump.Tick0Id) < MEMETICKID_LENGTH {
 		amtfee0 = new(big.Int).Div(pump.Amt0.Int(), big.NewInt(100))
-		err = db.TransferDrc20(tx, pump.Tick0Id, pump.HolderAddress, TxFeeAddress, amtfee0, pump.TxHash, pump.BlockNumber, false)
+		err = db.TransferDrc20(tx, pump.Tick0Id, pump.HolderAddress, txFeeAddress, amtfee0, pump.TxHash, pump.BlockNumber, false)
 		if err != nil {
 			return err
 		}
 
 		if len(inviter.InviteAddress) > 0 {
 			amtfee1 := new(big.Int).Div(amtfee0, big.NewInt(2))
-			err = db.TransferDrc20(tx, pump.Tick0Id, TxFeeAddress, inviter.InviteAddress, amtfee1, pump.TxHash, pump.BlockNumber, false)
+			err = db.TransferDrc20(tx, pump.Tick0Id, txFeeAddress, inviter.InviteAddress, amtfee1, pump.TxHash, pump.BlockNumber, false)
 			if err != nil {
 				return err
 			}
@@ -254,7 +273,7 @@ func (db *DBClient) PumpTrade(tx *gorm.DB, pump *models.PumpInfo) error {
 
 	if len(pump.Tick1Id) < MEMETICKID_LENGTH {
 		amtfee1 := new(big.Int).Div(amtout, big.NewInt(100))
-		err = db.TransferDrc20(tx, pump.Tick1Id, pumpl.ReservesAddress, TxFeeAddress, amtfee1, pump.TxHash, pump.BlockNumber, false)
+		err = db.TransferDrc20(tx, pump.Tick1Id, pumpl.ReservesAddress, txFeeAddress, amtfee1, pump.TxHash, pump.BlockNumber, false)
 		if err != nil {
 			return err
 		}
@@ -263,7 +282,7 @@ func (db *DBClient) PumpTrade(tx *gorm.DB, pump *models.PumpInfo) error {
 
 		if len(inviter.InviteAddress) > 0 {
 			amtfee2 := new(big.Int).Div(amtfee1, big.NewInt(2))
-			err = db.TransferDrc20(tx, pump.Tick1Id, TxFeeAddress, inviter.InviteAddress, amtfee2, pump.TxHash, pump.BlockNumber, false)
+			err = db.TransferDrc20(tx, pump.Tick1Id, txFeeAddress, inviter.InviteAddress, amtfee2, pump.TxHash, pump.BlockNumber, false)
 			if err != nil {
 				return err
 			}
@@ -338,6 +357,11 @@ func (db *DBClient) PumpTrade(tx *gorm.DB, pump *models.PumpInfo) error {
 		return fmt.Errorf("pumpTrade  error: %v", err)
 	}
 
+	err = db.StateChange(tx, pump.BlockNumber, "pump", pumpl.Tick0Id, "", pumpl.Amt0.String(), pumpl.Amt1.String())
+	if err != nil {
+		return err
+	}
+
 	if meme.AmtSum.Int().Cmp(DogeMax.Int()) >= 0 {
 		err = db.PumpFinish(tx, pump, pumpl)
 		if err != nil {
@@ -359,20 +383,24 @@ func (db *DBClient) PumpTrade(tx *gorm.DB, pump *models.PumpInfo) error {
 
 func (db *DBClient) PumpFinish(tx *gorm.DB, pump *models.PumpInfo, pumpl *models.PumpLiquidity) error {
 
+	p := db.ProtocolParams(pump.BlockNumber)
+	finishFee := models.NewNumber(p.PumpFinishFee)
+	createHolderFee := models.NewNumber(p.PumpCreateHolderFee)
 	if len(pumpl.Tick1Id) < MEMETICKID_LENGTH {
 
-		err := db.TransferDrc20(tx, pumpl.Tick1Id, pumpl.ReservesAddress, FinishFeeAddress, PumpFinishFee.Int(), pump.TxHash, pump.BlockNumber, false)
+		err := db.TransferDrc20(tx, pumpl.Tick1Id, pumpl.ReservesAddress, p.PumpFinishFeeAddress, finishFee.Int(), pump.TxHash, pump.BlockNumber, false)
 		if err != nil {
 			return err
 		}
 
 		wdoge := &models.WDogeInfo{}
-		wdoge.OrderId = uuid.New().String()
 		wdoge.Op = "withdraw-pump"
 		wdoge.Tick = "WDOGE(WRAPPED-DOGE)"
-		wdoge.Amt = PumpFinishFee
-		wdoge.HolderAddress = FinishFeeAddress
+		wdoge.Amt = finishFee
+		wdoge.HolderAddress = p.PumpFinishFeeAddress
 		wdoge.TxHash = pump.TxHash
+		wdoge.TxIndex = pump.TxIndex
+		wdoge.OrderId = utils.OrderId(wdoge.TxHash, wdoge.TxIndex, utils.SubOpFinishFee)
 		wdoge.BlockHash = pump.BlockHash
 		wdoge.BlockNumber = pump.BlockNumber
 		err = db.wdogeWithdrawPump(tx, wdoge)
@@ -381,18 +409,19 @@ func (db *DBClient) PumpFinish(tx *gorm.DB, pump *models.PumpInfo, pumpl *models
 			return fmt.Errorf("wdogeWithdrawPump err: %s", err.Error())
 		}
 
-		err = db.TransferDrc20(tx, pumpl.Tick1Id, pumpl.ReservesAddress, pumpl.HolderAddress, PumpCreateHolderFee.Int(), pump.TxHash, pump.BlockNumber, false)
+		err = db.TransferDrc20(tx, pumpl.Tick1Id, pumpl.ReservesAddress, pumpl.HolderAddress, createHolderFee.Int(), pump.TxHash, pump.BlockNumber, false)
 		if err != nil {
 			return err
 		}
 
 		wdoge = &models.WDogeInfo{}
-		wdoge.OrderId = uuid.New().String()
 		wdoge.Op = "withdraw-pump"
 		wdoge.Tick = "WDOGE(WRAPPED-DOGE)"
-		wdoge.Amt = PumpCreateHolderFee
+		wdoge.Amt = createHolderFee
 		wdoge.HolderAddress = pumpl.HolderAddress
 		wdoge.TxHash = pump.TxHash
+		wdoge.TxIndex = pump.TxIndex
+		wdoge.OrderId = utils.OrderId(wdoge.TxHash, wdoge.TxIndex, utils.SubOpFinishHolder)
 		wdoge.BlockHash = pump.BlockHash
 		wdoge.BlockNumber = pump.BlockNumber
 		err = db.wdogeWithdrawPump(tx, wdoge)
@@ -0,0 +1,35 @@
+package storage
+
+import (
+	"dogeuni-indexer/models"
+	"gorm.io/gorm"
+)
+
+// ReorgAffected groups the inscriptions decoded above height by protocol.
+func (db *DBClient) ReorgAffected(tx *gorm.DB, height int64) (models.ReorgAffected, error) {
+	list := make([]*models.BlockInscription, 0)
+	err := tx.Where("block_number > ?", height).Order("block_number asc, seq asc").Find(&list).Error
+	if err != nil {
+		return nil, err
+	}
+
+	affected := make(models.ReorgAffected)
+	for _, ins := range list {
+		affected[ins.P] = append(affected[ins.P], ins.TxHash)
+	}
+	return affected, nil
+}
+
+func (db *DBClient) ReorgEventCreate(tx *gorm.DB, event *models.ReorgEvent) error {
+	return tx.Create(event).Error
+}
+
+// ReorgEventLast is the latest reorg met, nil before any.
+func (db *DBClient) ReorgEventLast() (*models.ReorgEvent, error) {
+	events := make([]*models.ReorgEvent, 0)
+	err := db.DB.Order("id desc").Limit(1).Find(&events).Error
+	if err != nil || len(events) == 0 {
+		return nil, err
+	}
+	return events[0], nil
+}
@@ -0,0 +1,28 @@
+package storage
+
+import (
+	"dogeuni-indexer/models"
+	"sync/atomic"
+)
+
+// SetReplay marks the stored *_info rows as being replayed ahead of the block
+// of time since, 0 ends the replay. Their trades are logged again but only
+// added to the candles from the one that contains since, see SummaryRebuild.
+func (db *DBClient) SetReplay(since int64) {
+	atomic.StoreInt64(db.replay, since)
+}
+
+// ReplayFrom is the block time the running replay started at, 0 outside one.
+func (db *DBClient) ReplayFrom() int64 {
+	return atomic.LoadInt64(db.replay)
+}
+
+func (db *DBClient) BlockInscriptionCreate(ins *models.BlockInscription) error {
+	return db.DB.Create(ins).Error
+}
+
+func (db *DBClient) BlockInscriptions(height int64) ([]*models.BlockInscription, error) {
+	list := make([]*models.BlockInscription, 0)
+	err := db.DB.Where("block_number = ?", height).Order("seq asc").Find(&list).Error
+	return list, err
+}
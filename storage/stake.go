@@ -39,11 +39,13 @@ func (db *DBClient) StakeUnStake(tx *gorm.DB, stake *models.StakeInfo, reservesA
 
 func (db *DBClient) StakeGetReward(tx *gorm.DB, stake *models.StakeInfo) error {
 
-	rewards, err := db.StakeGetRewardV1(tx, stake.HolderAddress, stake.Tick)
+	rewards, err := db.StakeGetRewardV1(tx, stake.HolderAddress, stake.Tick, stake.BlockNumber)
 	if err != nil {
 		return err
 	}
 
+	stakePoolAddress := db.ProtocolParams(stake.BlockNumber).StakePoolAddress
+
 	for _, reward := range rewards {
 		err = db.TransferDrc20(tx, reward.Tick, stakePoolAddress, stake.HolderAddress, reward.Reward, stake.TxHash, stake.BlockNumber, false)
 		if err != nil {
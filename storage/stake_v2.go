@@ -53,6 +53,22 @@ func (db *DBClient) StakeV2Stake(tx *gorm.DB, stake *models.StakeV2Info) error {
 	}
 
 	if stakec.LastRewardBlock == 0 {
+		// the first stake starts the pool, keep its unstarted state for forks
+		revert := &models.StakeV2Revert{
+			Op:                "stake-pool",
+			StakeId:           stakec.StakeId,
+			Amt:               stakec.TotalStaked,
+			AccRewardPerShare: stakec.AccRewardPerShare,
+			LastRewardBlock:   stakec.LastRewardBlock,
+			LastBlock:         stakec.LastBlock,
+			BlockNumber:       stake.BlockNumber,
+		}
+
+		err = tx.Create(revert).Error
+		if err != nil {
+			return err
+		}
+
 		lastBlock := big.NewInt(0).Add(big.NewInt(0).Div(stake.Reward.Int(), stake.EachReward.Int()), big.NewInt(stake.BlockNumber))
 		stakec.LastRewardBlock = stake.BlockNumber
 		stakec.LastBlock = lastBlock.Int64()
@@ -116,6 +132,7 @@ func (db *DBClient) StakeV2Stake(tx *gorm.DB, stake *models.StakeV2Info) error {
 		Amt:           stakea.Amt,
 		RewardDebt:    stakea.RewardDebt,
 		PendingReward: stakea.PendingReward,
+		LastBlock:     stakea.LastBlock,
 		HolderAddress: stake.HolderAddress,
 		BlockNumber:   stake.BlockNumber,
 	}
@@ -140,12 +157,17 @@ func (db *DBClient) StakeV2Stake(tx *gorm.DB, stake *models.StakeV2Info) error {
 		"amt":            stakea.Amt,
 		"reward_debt":    stakea.RewardDebt,
 		"pending_reward": stakea.PendingReward,
-		"lock_block":     stake.BlockNumber + stakec.LastBlock,
+		"last_block":     stake.BlockNumber + stakec.LastBlock,
 	}).Error
 	if err != nil {
 		return err
 	}
 
+	err = db.StateChange(tx, stake.BlockNumber, "stake-v2", stake.StakeId, stake.HolderAddress, stakea.Amt.String())
+	if err != nil {
+		return err
+	}
+
 	stakec.TotalStaked = (*models.Number)(big.NewInt(0).Add(stakec.TotalStaked.Int(), stake.Amt.Int()))
 	err = tx.Model(stakec).Update("total_staked", stakec.TotalStaked).Error
 	if err != nil {
@@ -207,6 +229,11 @@ func (db *DBClient) StakeV2UnStake(tx *gorm.DB, stake *models.StakeV2Info) error
 		return err
 	}
 
+	err = db.StateChange(tx, stake.BlockNumber, "stake-v2", stake.StakeId, stake.HolderAddress, stakea.Amt.String())
+	if err != nil {
+		return err
+	}
+
 	stakec.TotalStaked = (*models.Number)(big.NewInt(0).Sub(stakec.TotalStaked.Int(), stake.Amt.Int()))
 	err = tx.Model(stakec).Update("total_staked", stakec.TotalStaked).Error
 	if err != nil {
@@ -295,6 +322,7 @@ func (db *DBClient) StakeV2UpdatePool(tx *gorm.DB, stakec *models.StakeV2Collect
 		Amt:               stakec.TotalStaked,
 		AccRewardPerShare: stakec.AccRewardPerShare,
 		LastRewardBlock:   stakec.LastRewardBlock,
+		LastBlock:         stakec.LastBlock,
 		BlockNumber:       height,
 	}
 
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"dogeuni-indexer/models"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"strings"
+)
+
+// StateChange records a balance or liquidity value written while height is
+// applied. It goes into the caller's transaction, so an inscription that is
+// rolled back leaves no change behind.
+func (db *DBClient) StateChange(tx *gorm.DB, height int64, kind, key, holderAddress string, value ...string) error {
+	change := &models.StateChange{
+		BlockNumber:   height,
+		Kind:          kind,
+		Key:           key,
+		HolderAddress: holderAddress,
+		Value:         strings.Join(value, ","),
+	}
+	return tx.Create(change).Error
+}
+
+// StateRootGenesis is the first block of the state root chain, the block the
+// indexer scans from by default. Its root chains onto the empty root, and so
+// does the first block of an index started above it.
+const StateRootGenesis int64 = 0
+
+// StateRoot hashes the changes of height, in the order they were written, onto
+// the state root of the block before. A block indexed before state roots
+// existed has none, the blocks above it get none either until a reindex from
+// the first of them backfills the chain, see StateRootMissing.
+func (db *DBClient) StateRoot(tx *gorm.DB, height int64) (string, error) {
+	prev := &models.Block{}
+	err := tx.Where("block_number = ?", height-1).First(prev).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", fmt.Errorf("StateRoot prev block err: %s", err.Error())
+	}
+
+	if height > StateRootGenesis && err == nil && prev.StateRoot == "" {
+		return "", nil
+	}
+
+	changes := make([]*models.StateChange, 0)
+	err = tx.Where("block_number = ?", height).Order("id asc").Find(&changes).Error
+	if err != nil {
+		return "", fmt.Errorf("StateRoot changes err: %s", err.Error())
+	}
+
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%s\n%d\n", prev.StateRoot, height)
+	for _, c := range changes {
+		_, _ = fmt.Fprintf(h, "%s\x1f%s\x1f%s\x1f%s\n", c.Kind, c.Key, c.HolderAddress, c.Value)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// StateRootUpdate sets the state root of an indexed block again, after its
+// inscriptions were replayed.
+func (db *DBClient) StateRootUpdate(height int64) error {
+	root, err := db.StateRoot(db.DB, height)
+	if err != nil {
+		return err
+	}
+	return db.DB.Model(&models.Block{}).Where("block_number = ?", height).Update("state_root", root).Error
+}
+
+// StateRootMissing is the first indexed block without a state root. A reindex
+// from it backfills the roots of every block above.
+func (db *DBClient) StateRootMissing() (int64, bool, error) {
+	block := &models.Block{}
+	err := db.DB.Where("block_number >= ? and state_root = ?", StateRootGenesis, "").Order("block_number").First(block).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return block.BlockNumber, true, nil
+}
@@ -23,65 +23,7 @@ func (db *DBClient) SummarySwapV2(tx *gorm.DB, swap *models.SwapV2Info) error {
 		price, _ = priceF.Float64()
 	}
 
-	startDate := time.Now()
-	timeStamp := time.Date(startDate.Year(), startDate.Month(), startDate.Day(), startDate.Hour(), startDate.Minute(), 0, 0, startDate.Location()).Unix()
-
-	err := SummaryK(tx, tickId, price, volume, timeStamp, "1m")
-	if err != nil {
-		return err
-	}
-
-	temp := startDate.Minute() % 5
-	timeStamp = time.Date(startDate.Year(), startDate.Month(), startDate.Day(), startDate.Hour(), startDate.Minute()-temp, 0, 0, startDate.Location()).Unix()
-	err = SummaryK(tx, tickId, price, volume, timeStamp, "5m")
-	if err != nil {
-		return err
-	}
-
-	temp = startDate.Minute() % 15
-	timeStamp = time.Date(startDate.Year(), startDate.Month(), startDate.Day(), startDate.Hour(), startDate.Minute()-temp, 0, 0, startDate.Location()).Unix()
-	err = SummaryK(tx, tickId, price, volume, timeStamp, "15m")
-	if err != nil {
-		return err
-	}
-
-	timeStamp = time.Date(startDate.Year(), startDate.Month(), startDate.Day(), startDate.Hour(), 0, 0, 0, startDate.Location()).Unix()
-	err = SummaryK(tx, tickId, price, volume, timeStamp, "1h")
-	if err != nil {
-		return err
-	}
-
-	temp = startDate.Hour() % 4
-	timeStamp = time.Date(startDate.Year(), startDate.Month(), startDate.Day(), startDate.Hour()-temp, 0, 0, 0, startDate.Location()).Unix()
-	err = SummaryK(tx, tickId, price, volume, timeStamp, "4h")
-	if err != nil {
-		return err
-	}
-
-	timeStamp = time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, startDate.Location()).Unix()
-	err = SummaryK(tx, tickId, price, volume, timeStamp, "1d")
-	if err != nil {
-		return err
-	}
-
-	if startDate.Weekday() == time.Sunday {
-		timeStamp = time.Date(startDate.Year(), startDate.Month(), startDate.Day()-6, 0, 0, 0, 0, startDate.Location()).Unix()
-	} else {
-		timeStamp = time.Date(startDate.Year(), startDate.Month(), startDate.Day()-int(startDate.Weekday())+1, 0, 0, 0, 0, startDate.Location()).Unix()
-	}
-
-	err = SummaryK(tx, tickId, price, volume, timeStamp, "1w")
-	if err != nil {
-		return err
-	}
-
-	timeStamp = time.Date(startDate.Year(), startDate.Month(), 1, 0, 0, 0, 0, startDate.Location()).Unix()
-	err = SummaryK(tx, tickId, price, volume, timeStamp, "1M")
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return db.SummaryCandles(tx, tickId, price, volume, swap.BlockNumber, swap.BlockTime)
 }
 
 func (db *DBClient) SummaryPump(tx *gorm.DB, pump *models.PumpInfo) error {
@@ -99,139 +41,176 @@ func (db *DBClient) SummaryPump(tx *gorm.DB, pump *models.PumpInfo) error {
 		price, _ = priceF.Float64()
 	}
 
-	startDate := time.Now()
-	timeStamp := time.Date(startDate.Year(), startDate.Month(), startDate.Day(), startDate.Hour(), startDate.Minute(), 0, 0, startDate.Location()).Unix()
-
-	err := SummaryK(tx, tickId, price, volume, timeStamp, "1m")
-	if err != nil {
-		return err
-	}
+	return db.SummaryCandles(tx, tickId, price, volume, pump.BlockNumber, pump.BlockTime)
+}
 
-	temp := startDate.Minute() % 5
-	timeStamp = time.Date(startDate.Year(), startDate.Month(), startDate.Day(), startDate.Hour(), startDate.Minute()-temp, 0, 0, startDate.Location()).Unix()
-	err = SummaryK(tx, tickId, price, volume, timeStamp, "5m")
-	if err != nil {
-		return err
+func (db *DBClient) SummaryPumpCreate(tx *gorm.DB, pump *models.PumpInfo) error {
+	price := 0.0
+	volume := big.NewInt(0)
+	tickId := pump.Tick0Id
+	if pump.Tick0Id == "WDOGE(WRAPPED-DOGE)" {
+		priceF := new(big.Float).Quo(new(big.Float).SetInt(pump.Amt0Out.Int()), new(big.Float).SetInt(pump.Amt1Out.Int()))
+		price, _ = priceF.Float64()
+		tickId = pump.Tick1Id
+	} else if pump.Tick1Id == "WDOGE(WRAPPED-DOGE)" {
+		priceF := new(big.Float).Quo(new(big.Float).SetInt(pump.Amt1Out.Int()), new(big.Float).SetInt(pump.Amt0Out.Int()))
+		price, _ = priceF.Float64()
 	}
 
-	temp = startDate.Minute() % 15
-	timeStamp = time.Date(startDate.Year(), startDate.Month(), startDate.Day(), startDate.Hour(), startDate.Minute()-temp, 0, 0, startDate.Location()).Unix()
-	err = SummaryK(tx, tickId, price, volume, timeStamp, "15m")
-	if err != nil {
-		return err
-	}
+	return db.SummaryCandles(tx, tickId, price, volume, pump.BlockNumber, pump.BlockTime)
+}
 
-	timeStamp = time.Date(startDate.Year(), startDate.Month(), startDate.Day(), startDate.Hour(), 0, 0, 0, startDate.Location()).Unix()
-	err = SummaryK(tx, tickId, price, volume, timeStamp, "1h")
-	if err != nil {
-		return err
+// candleIntervals are the candles kept in swap_v2_summary.
+var candleIntervals = []string{"1m", "5m", "15m", "1h", "4h", "1d", "1w", "1M"}
+
+// candleStart is the start of the interval candle that contains blockTime.
+// Candles are bucketed in UTC so every node builds the same ones.
+func candleStart(blockTime int64, interval string) int64 {
+	startDate := time.Unix(blockTime, 0).UTC()
+	year, month, day := startDate.Date()
+	hour, minute := startDate.Hour(), startDate.Minute()
+
+	switch interval {
+	case "1m":
+	case "5m":
+		minute -= minute % 5
+	case "15m":
+		minute -= minute % 15
+	case "1h":
+		minute = 0
+	case "4h":
+		hour, minute = hour-hour%4, 0
+	case "1d":
+		hour, minute = 0, 0
+	case "1w":
+		hour, minute = 0, 0
+		if startDate.Weekday() == time.Sunday {
+			day -= 6
+		} else {
+			day -= int(startDate.Weekday()) - 1
+		}
+	case "1M":
+		day, hour, minute = 1, 0, 0
 	}
 
-	temp = startDate.Hour() % 4
-	timeStamp = time.Date(startDate.Year(), startDate.Month(), startDate.Day(), startDate.Hour()-temp, 0, 0, 0, startDate.Location()).Unix()
-	err = SummaryK(tx, tickId, price, volume, timeStamp, "4h")
-	if err != nil {
-		return err
-	}
+	return time.Date(year, month, day, hour, minute, 0, 0, time.UTC).Unix()
+}
 
-	timeStamp = time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, startDate.Location()).Unix()
-	err = SummaryK(tx, tickId, price, volume, timeStamp, "1d")
-	if err != nil {
-		return err
+// CandleWindow is the start of the oldest candle that contains since. A
+// replay from the block of since has to go over the trades from there on to
+// rebuild every candle SummaryDelete drops.
+func CandleWindow(since int64) int64 {
+	window := since
+	for _, interval := range candleIntervals {
+		if start := candleStart(since, interval); start < window {
+			window = start
+		}
 	}
+	return window
+}
 
-	if startDate.Weekday() == time.Sunday {
-		timeStamp = time.Date(startDate.Year(), startDate.Month(), startDate.Day()-6, 0, 0, 0, 0, startDate.Location()).Unix()
-	} else {
-		timeStamp = time.Date(startDate.Year(), startDate.Month(), startDate.Day()-int(startDate.Weekday())+1, 0, 0, 0, 0, startDate.Location()).Unix()
+// SummaryDelete drops the candles that contain since or start after it, of
+// every interval.
+func (db *DBClient) SummaryDelete(tx *gorm.DB, since int64) error {
+	for _, interval := range candleIntervals {
+		err := tx.Table("swap_v2_summary").Where("date_interval = ? and time_stamp >= ?", interval, candleStart(since, interval)).Delete(&models.Summary{}).Error
+		if err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	err = SummaryK(tx, tickId, price, volume, timeStamp, "1w")
-	if err != nil {
-		return err
+// SummaryCandles logs a trade of block blockNumber and adds it to the candle
+// of every interval that contains blockTime. While replaying, the candles
+// older than those SummaryDelete dropped are final and left alone.
+func (db *DBClient) SummaryCandles(tx *gorm.DB, tickId string, price float64, volume *big.Int, blockNumber, blockTime int64) error {
+	trade := &models.SummaryTrade{
+		BlockNumber: blockNumber,
+		TickId:      tickId,
+		Price:       price,
+		Volume:      (*models.Number)(volume),
+		BlockTime:   blockTime,
 	}
 
-	timeStamp = time.Date(startDate.Year(), startDate.Month(), 1, 0, 0, 0, 0, startDate.Location()).Unix()
-	err = SummaryK(tx, tickId, price, volume, timeStamp, "1M")
+	err := tx.Create(trade).Error
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return summaryBuckets(tx, tickId, price, volume, blockTime, db.ReplayFrom())
 }
 
-func (db *DBClient) SummaryPumpCreate(tx *gorm.DB, pump *models.PumpInfo) error {
-	price := 0.0
-	volume := big.NewInt(0)
-	tickId := pump.Tick0Id
-	if pump.Tick0Id == "WDOGE(WRAPPED-DOGE)" {
-		priceF := new(big.Float).Quo(new(big.Float).SetInt(pump.Amt0Out.Int()), new(big.Float).SetInt(pump.Amt1Out.Int()))
-		price, _ = priceF.Float64()
-		tickId = pump.Tick1Id
-	} else if pump.Tick1Id == "WDOGE(WRAPPED-DOGE)" {
-		priceF := new(big.Float).Quo(new(big.Float).SetInt(pump.Amt1Out.Int()), new(big.Float).SetInt(pump.Amt0Out.Int()))
-		price, _ = priceF.Float64()
-	}
-
-	startDate := time.Now()
-	timeStamp := time.Date(startDate.Year(), startDate.Month(), startDate.Day(), startDate.Hour(), startDate.Minute(), 0, 0, startDate.Location()).Unix()
+// summaryBuckets adds a trade to the candles that contain blockTime and do not
+// start before the ones that contain since.
+func summaryBuckets(tx *gorm.DB, tickId string, price float64, volume *big.Int, blockTime, since int64) error {
+	for _, interval := range candleIntervals {
+		timeStamp := candleStart(blockTime, interval)
+		if since != 0 && timeStamp < candleStart(since, interval) {
+			continue
+		}
 
-	err := SummaryK(tx, tickId, price, volume, timeStamp, "1m")
-	if err != nil {
-		return err
+		err := SummaryK(tx, tickId, price, volume, timeStamp, interval)
+		if err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	temp := startDate.Minute() % 5
-	timeStamp = time.Date(startDate.Year(), startDate.Month(), startDate.Day(), startDate.Hour(), startDate.Minute()-temp, 0, 0, startDate.Location()).Unix()
-	err = SummaryK(tx, tickId, price, volume, timeStamp, "5m")
+// SummaryRebuild rolls the candles back to height. The trades above it are
+// dropped, and the candles from the ones that contain since are rebuilt from
+// the trades logged up to height.
+func (db *DBClient) SummaryRebuild(tx *gorm.DB, height, since int64) error {
+	err := tx.Where("block_number > ?", height).Delete(&models.SummaryTrade{}).Error
 	if err != nil {
 		return err
 	}
 
-	temp = startDate.Minute() % 15
-	timeStamp = time.Date(startDate.Year(), startDate.Month(), startDate.Day(), startDate.Hour(), startDate.Minute()-temp, 0, 0, startDate.Location()).Unix()
-	err = SummaryK(tx, tickId, price, volume, timeStamp, "15m")
+	err = db.SummaryDelete(tx, since)
 	if err != nil {
 		return err
 	}
 
-	timeStamp = time.Date(startDate.Year(), startDate.Month(), startDate.Day(), startDate.Hour(), 0, 0, 0, startDate.Location()).Unix()
-	err = SummaryK(tx, tickId, price, volume, timeStamp, "1h")
+	trades := make([]*models.SummaryTrade, 0)
+	err = tx.Where("block_time >= ?", CandleWindow(since)).Order("block_number, id").Find(&trades).Error
 	if err != nil {
 		return err
 	}
 
-	temp = startDate.Hour() % 4
-	timeStamp = time.Date(startDate.Year(), startDate.Month(), startDate.Day(), startDate.Hour()-temp, 0, 0, 0, startDate.Location()).Unix()
-	err = SummaryK(tx, tickId, price, volume, timeStamp, "4h")
-	if err != nil {
-		return err
+	for _, trade := range trades {
+		err = summaryBuckets(tx, trade.TickId, trade.Price, trade.Volume.Int(), trade.BlockTime, since)
+		if err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	timeStamp = time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, startDate.Location()).Unix()
-	err = SummaryK(tx, tickId, price, volume, timeStamp, "1d")
-	if err != nil {
-		return err
-	}
+// SummaryLogged tells whether the trade log holds every trade SummaryRebuild
+// needs to roll the candles back to height. Blocks indexed before trades were
+// logged only left their candles behind.
+func (db *DBClient) SummaryLogged(tx *gorm.DB, height, since int64) (bool, error) {
+	window := CandleWindow(since)
 
-	if startDate.Weekday() == time.Sunday {
-		timeStamp = time.Date(startDate.Year(), startDate.Month(), startDate.Day()-6, 0, 0, 0, 0, startDate.Location()).Unix()
-	} else {
-		timeStamp = time.Date(startDate.Year(), startDate.Month(), startDate.Day()-int(startDate.Weekday())+1, 0, 0, 0, 0, startDate.Location()).Unix()
+	first := int64(-1)
+	err := tx.Model(&models.Block{}).Select("coalesce(min(block_number), -1)").Where("block_number <= ? and block_time >= ?", height, window).Scan(&first).Error
+	if err != nil || first < 0 {
+		return err == nil, err
 	}
 
-	err = SummaryK(tx, tickId, price, volume, timeStamp, "1w")
-	if err != nil {
-		return err
+	logged := height + 1
+	err = tx.Model(&models.SummaryTrade{}).Select("coalesce(min(block_number), ?)", logged).Scan(&logged).Error
+	if err != nil || first >= logged {
+		return err == nil, err
 	}
 
-	timeStamp = time.Date(startDate.Year(), startDate.Month(), 1, 0, 0, 0, 0, startDate.Location()).Unix()
-	err = SummaryK(tx, tickId, price, volume, timeStamp, "1M")
+	var candles int64
+	err = tx.Table("swap_v2_summary").Where("time_stamp >= ?", window).Count(&candles).Error
 	if err != nil {
-		return err
+		return false, err
 	}
-
-	return nil
+	return candles == 0, nil
 }
 
 func SummaryK(tx *gorm.DB, tickId string, price float64, volume *big.Int, timeStamp int64, dateInterval string) error {
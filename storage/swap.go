@@ -5,23 +5,19 @@ import (
 	"dogeuni-indexer/utils"
 	"fmt"
 	"github.com/dogecoinw/doged/btcutil"
-	"github.com/dogecoinw/doged/chaincfg"
 	"gorm.io/gorm"
 	"math/big"
 )
 
-const (
-	MINI_LIQUIDITY = 1000
-)
-
 func (db *DBClient) SwapCreate(tx *gorm.DB, swap *models.SwapInfo) error {
 
-	reservesAddress, _ := btcutil.NewAddressScriptHash([]byte(swap.Tick0+swap.Tick1), &chaincfg.MainNetParams)
+	reservesAddress, _ := btcutil.NewAddressScriptHash([]byte(swap.Tick0+swap.Tick1), db.NetParams())
 	swap.Tick = swap.Tick0 + "-SWAP-" + swap.Tick1
 
+	miniLiquidity := big.NewInt(db.ProtocolParams(swap.BlockNumber).MiniLiquidity)
 	liquidityBase := new(big.Int).Sqrt(new(big.Int).Mul(swap.Amt0.Int(), swap.Amt1.Int()))
-	if liquidityBase.Cmp(big.NewInt(MINI_LIQUIDITY)) > 0 {
-		liquidityBase = new(big.Int).Sub(liquidityBase, big.NewInt(MINI_LIQUIDITY))
+	if liquidityBase.Cmp(miniLiquidity) > 0 {
+		liquidityBase = new(big.Int).Sub(liquidityBase, miniLiquidity)
 	} else {
 		return fmt.Errorf("add liquidity must be greater than MINI_LIQUIDITY firstly")
 	}
@@ -70,12 +66,12 @@ func (db *DBClient) SwapCreate(tx *gorm.DB, swap *models.SwapInfo) error {
 		return err
 	}
 
-	err = db.MintDrc20(tx, swap.Tick, reservesAddress.String(), big.NewInt(MINI_LIQUIDITY), swap.TxHash, swap.BlockNumber, false)
+	err = db.MintDrc20(tx, swap.Tick, reservesAddress.String(), miniLiquidity, swap.TxHash, swap.BlockNumber, false)
 	if err != nil {
 		return err
 	}
 
-	err = db.UpdateLiquidity(tx, swap.Tick)
+	err = db.UpdateLiquidity(tx, swap.Tick, swap.BlockNumber)
 	if err != nil {
 		return err
 	}
@@ -96,7 +92,7 @@ func (db *DBClient) SwapCreate(tx *gorm.DB, swap *models.SwapInfo) error {
 
 func (db *DBClient) SwapAdd(tx *gorm.DB, swap *models.SwapInfo) error {
 
-	reservesAddress, _ := btcutil.NewAddressScriptHash([]byte(swap.Tick0+swap.Tick1), &chaincfg.MainNetParams)
+	reservesAddress, _ := btcutil.NewAddressScriptHash([]byte(swap.Tick0+swap.Tick1), db.NetParams())
 	swap.Tick = swap.Tick0 + "-SWAP-" + swap.Tick1
 
 	amt0Out := big.NewInt(0)
@@ -157,7 +153,7 @@ func (db *DBClient) SwapAdd(tx *gorm.DB, swap *models.SwapInfo) error {
 	}
 
 	// Update amt0
-	err = db.UpdateLiquidity(tx, swap.Tick)
+	err = db.UpdateLiquidity(tx, swap.Tick, swap.BlockNumber)
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -202,7 +198,7 @@ func (db *DBClient) SwapRemove(tx *gorm.DB, swap *models.SwapInfo) error {
 		return err
 	}
 
-	err = db.UpdateLiquidity(tx, swapl.Tick)
+	err = db.UpdateLiquidity(tx, swapl.Tick, swap.BlockNumber)
 	if err != nil {
 		return err
 	}
@@ -243,7 +239,7 @@ func (db *DBClient) SwapExec(tx *gorm.DB, swap *models.SwapInfo) error {
 		return err
 	}
 
-	err = db.UpdateLiquidity(tx, swapl.Tick)
+	err = db.UpdateLiquidity(tx, swapl.Tick, swap.BlockNumber)
 	if err != nil {
 		return err
 	}
@@ -251,7 +247,7 @@ func (db *DBClient) SwapExec(tx *gorm.DB, swap *models.SwapInfo) error {
 	return nil
 }
 
-func (db *DBClient) UpdateLiquidity(tx *gorm.DB, tick string) error {
+func (db *DBClient) UpdateLiquidity(tx *gorm.DB, tick string, height int64) error {
 
 	err := tx.Exec(`UPDATE swap_liquidity
 				SET amt0 = (
@@ -304,7 +300,13 @@ func (db *DBClient) UpdateLiquidity(tx *gorm.DB, tick string) error {
 		return err
 	}
 
-	return nil
+	liquidity := &models.SwapLiquidity{}
+	err = tx.Where("tick = ?", tick).First(liquidity).Error
+	if err != nil {
+		return fmt.Errorf("UpdateLiquidity FindLiquidity error: %s", err.Error())
+	}
+
+	return db.StateChange(tx, height, "swap", tick, "", liquidity.Amt0.String(), liquidity.Amt1.String(), liquidity.LiquidityTotal.String())
 }
 
 func (db *DBClient) UpdateLiquidityFork(tx *gorm.DB) error {
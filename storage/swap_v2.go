@@ -4,7 +4,6 @@ import (
 	"dogeuni-indexer/models"
 	"fmt"
 	"github.com/dogecoinw/doged/btcutil"
-	"github.com/dogecoinw/doged/chaincfg"
 	"github.com/dogecoinw/go-dogecoin/log"
 	"gorm.io/gorm"
 	"math/big"
@@ -16,11 +15,12 @@ const (
 
 func (db *DBClient) SwapV2Create(tx *gorm.DB, swap *models.SwapV2Info) error {
 
-	reservesAddress, _ := btcutil.NewAddressScriptHash([]byte(swap.PairId), &chaincfg.MainNetParams)
+	reservesAddress, _ := btcutil.NewAddressScriptHash([]byte(swap.PairId), db.NetParams())
 
+	miniLiquidity := big.NewInt(db.ProtocolParams(swap.BlockNumber).MiniLiquidity)
 	liquidityBase := new(big.Int).Sqrt(new(big.Int).Mul(swap.Amt0.Int(), swap.Amt1.Int()))
-	if liquidityBase.Cmp(big.NewInt(MINI_LIQUIDITY)) > 0 {
-		liquidityBase = new(big.Int).Sub(liquidityBase, big.NewInt(MINI_LIQUIDITY))
+	if liquidityBase.Cmp(miniLiquidity) > 0 {
+		liquidityBase = new(big.Int).Sub(liquidityBase, miniLiquidity)
 	} else {
 		return fmt.Errorf("add liquidity must be greater than MINI_LIQUIDITY firstly")
 	}
@@ -86,12 +86,12 @@ func (db *DBClient) SwapV2Create(tx *gorm.DB, swap *models.SwapV2Info) error {
 		return err
 	}
 
-	err = db.MintMeme20(tx, swap.PairId, reservesAddress.String(), big.NewInt(MINI_LIQUIDITY), swap.TxHash, swap.BlockNumber, false)
+	err = db.MintMeme20(tx, swap.PairId, reservesAddress.String(), miniLiquidity, swap.TxHash, swap.BlockNumber, false)
 	if err != nil {
 		return err
 	}
 
-	err = db.UpdateV2Liquidity(tx, swap.PairId)
+	err = db.UpdateV2Liquidity(tx, swap.PairId, swap.BlockNumber)
 	if err != nil {
 		return err
 	}
@@ -112,7 +112,7 @@ func (db *DBClient) SwapV2Create(tx *gorm.DB, swap *models.SwapV2Info) error {
 
 func (db *DBClient) SwapV2Add(tx *gorm.DB, swap *models.SwapV2Info) error {
 
-	reservesAddress, _ := btcutil.NewAddressScriptHash([]byte(swap.PairId), &chaincfg.MainNetParams)
+	reservesAddress, _ := btcutil.NewAddressScriptHash([]byte(swap.PairId), db.NetParams())
 
 	amt0Out := big.NewInt(0)
 	amt1Out := big.NewInt(0)
@@ -189,7 +189,7 @@ func (db *DBClient) SwapV2Add(tx *gorm.DB, swap *models.SwapV2Info) error {
 		return err
 	}
 
-	err = db.UpdateV2Liquidity(tx, swap.PairId)
+	err = db.UpdateV2Liquidity(tx, swap.PairId, swap.BlockNumber)
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -253,7 +253,7 @@ func (db *DBClient) SwapV2Remove(tx *gorm.DB, swap *models.SwapV2Info) error {
 		return err
 	}
 
-	err = db.UpdateV2Liquidity(tx, swap.PairId)
+	err = db.UpdateV2Liquidity(tx, swap.PairId, swap.BlockNumber)
 	if err != nil {
 		return err
 	}
@@ -318,7 +318,7 @@ func (db *DBClient) SwapV2Exec(tx *gorm.DB, swap *models.SwapV2Info) error {
 		}
 	}
 
-	err = db.UpdateV2Liquidity(tx, swap.PairId)
+	err = db.UpdateV2Liquidity(tx, swap.PairId, swap.BlockNumber)
 	if err != nil {
 		return err
 	}
@@ -333,7 +333,7 @@ func (db *DBClient) SwapV2Exec(tx *gorm.DB, swap *models.SwapV2Info) error {
 	return nil
 }
 
-func (db *DBClient) UpdateV2Liquidity(tx *gorm.DB, tickId string) error {
+func (db *DBClient) UpdateV2Liquidity(tx *gorm.DB, tickId string, height int64) error {
 
 	// tick0
 	err := tx.Exec(`UPDATE swap_v2_liquidity
@@ -429,7 +429,13 @@ func (db *DBClient) UpdateV2Liquidity(tx *gorm.DB, tickId string) error {
 		return err
 	}
 
-	return nil
+	liquidity := &models.SwapV2Liquidity{}
+	err = tx.Where("pair_id = ?", tickId).First(liquidity).Error
+	if err != nil {
+		return fmt.Errorf("UpdateV2Liquidity FindLiquidity error: %s", err.Error())
+	}
+
+	return db.StateChange(tx, height, "swap-v2", tickId, "", liquidity.Amt0.String(), liquidity.Amt1.String(), liquidity.LiquidityTotal.String())
 }
 
 func (db *DBClient) UpdateV2LiquidityFork(tx *gorm.DB) error {
@@ -17,14 +17,6 @@ func (e *MysqlClient) ScheduledTasks(height int64) error {
 		return err
 	}
 
-	//if height < 5260645 {
-	//	err = e.StakeUpdatePoolScheduled(tx, height)
-	//	if err != nil {
-	//		tx.Rollback()
-	//		return err
-	//	}
-	//}
-
 	//err = e.BoxDeployScheduled(tx, height)
 	//if err != nil {
 	//	tx.Rollback()
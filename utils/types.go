@@ -9,12 +9,14 @@ import (
 
 // Config
 type HttpConfig struct {
-	Switch bool   `json:"switch"`
-	Server string `json:"server"`
+	Switch   bool   `json:"switch"`
+	Server   string `json:"server"`
+	ReadyLag int64  `json:"ready_lag"` // blocks behind the node /readyz tolerates, 0 means 3
 }
 
 type LevelDBConfig struct {
-	Path string `json:"path"`
+	Path       string `json:"path"`
+	ChainCache int64  `json:"chain_cache"` // MB of node blocks and txs kept on disk, 0 disables
 }
 
 type SqliteConfig struct {
@@ -36,13 +38,22 @@ type ChainConfig struct {
 	Rpc       string `json:"rpc"`
 	UserName  string `json:"user_name"`
 	PassWord  string `json:"pass_word"`
+	Notify    string `json:"notify"`    // "zmq", "ws" or "" to poll only
+	ZmqBlock  string `json:"zmq_block"` // zmqpubhashblock endpoint, e.g. tcp://127.0.0.1:28332
+	Archive   string `json:"archive"`   // recorded blocks to index instead of the node
+	Record    string `json:"record"`    // directory the blocks read from the node are recorded to
 }
 
 type ExplorerConfig struct {
-	Switch       bool  `json:"switch"`
-	FromBlock    int64 `json:"from_block"`
-	InitMintData bool  `json:"init_mint_data"`
-	InitForkData bool  `json:"init_fork_data"`
+	Switch          bool   `json:"switch"`
+	FromBlock       int64  `json:"from_block"`
+	InitMintData    bool   `json:"init_mint_data"`
+	InitForkData    bool   `json:"init_fork_data"`
+	PrefetchBlocks  int    `json:"prefetch_blocks"`
+	PrefetchWorkers int    `json:"prefetch_workers"`
+	Mempool         bool   `json:"mempool"`
+	MaxReorgDepth   int64  `json:"max_reorg_depth"` // deepest reorg rolled back without an operator, 0 means 100
+	Params          string `json:"params"`          // protocol parameter upgrades on top of the mainnet values
 }
 
 type HttpResult struct {
@@ -9,11 +9,20 @@ import (
 	"github.com/dogecoinw/doged/btcec/ecdsa"
 	"github.com/dogecoinw/doged/btcutil"
 	"github.com/dogecoinw/doged/chaincfg"
-	"math"
+	"github.com/google/uuid"
 	"math/big"
 	"time"
 )
 
+// Sub-operation indexes of the rows an inscription writes besides its own.
+const (
+	SubOpInscription = iota
+	SubOpDeposit
+	SubOpWithdraw
+	SubOpFinishFee
+	SubOpFinishHolder
+)
+
 var (
 	MAX_NUMBER, _ = big.NewInt(0).SetString("99999999999999999999999999999999999999999", 10)
 )
@@ -143,18 +152,6 @@ func TimeCom(dateInterval string) (time.Time, error) {
 	return startDate, nil
 }
 
-func Float64ToBigInt(input float64) *big.Int {
-
-	rounded := math.Ceil(input)
-
-	if rounded < math.MinInt64 || rounded > math.MaxInt64 {
-		return big.NewInt(0)
-	}
-
-	result := int64(rounded)
-	return big.NewInt(result)
-}
-
 func Base64ToPng(base64Str string) ([]byte, error) {
 	imageBytes, err := base64.StdEncoding.DecodeString(base64Str)
 	if err != nil {
@@ -211,7 +208,8 @@ func WriteVarInt(w *bytes.Buffer, n int64) {
 	}
 }
 
-func GetAddressFromSig(msg string, sig string) (string, error) {
+// GetAddressFromSig recovers the P2PKH address of params that signed msg.
+func GetAddressFromSig(msg string, sig string, params *chaincfg.Params) (string, error) {
 
 	message := msg
 	sigBytes, err := base64.StdEncoding.DecodeString(sig)
@@ -233,7 +231,7 @@ func GetAddressFromSig(msg string, sig string) (string, error) {
 		return "", err
 	}
 
-	inadress, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(publicKey.SerializeCompressed()), &chaincfg.MainNetParams)
+	inadress, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(publicKey.SerializeCompressed()), params)
 	if err != nil {
 		return "", err
 	}
@@ -263,3 +261,20 @@ func IntervalToSecond(dateInterval string) int64 {
 		return 0
 	}
 }
+
+// OrderId derives the order id of an indexed row from the transaction input
+// that produced it, so every node and every reindex assign the same id.
+// subIndex tells apart the rows written for the same input.
+func OrderId(txHash string, txIndex int, subIndex int) string {
+	name := fmt.Sprintf("%s:%d:%d", txHash, txIndex, subIndex)
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(name)).String()
+}
+
+// InscriptionId is the id a create or deploy derives from the transaction
+// input carrying it. The first input keeps the bare tx hash ids always were.
+func InscriptionId(txHash string, txIndex int) string {
+	if txIndex == 0 {
+		return txHash
+	}
+	return fmt.Sprintf("%si%d", txHash, txIndex)
+}